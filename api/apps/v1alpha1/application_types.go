@@ -70,6 +70,10 @@ type ContainerResources struct {
 	Selector string `json:"selector,omitempty"`
 	// The names of the resources to optimize. Defaults to ["memory", "cpu"].
 	Resources []corev1.ResourceName `json:"resources,omitempty"`
+	// The granularity of each resource, e.g. "64Mi" of memory or "50m" of CPU.
+	Step corev1.ResourceList `json:"step,omitempty"`
+	// Optimize the limits separately from the requests.
+	SeparateLimits bool `json:"separateLimits,omitempty"`
 }
 
 // Replicas specifies which resources in the application should have their replica count optimized.
@@ -90,6 +94,16 @@ type EnvironmentVariable struct {
 	Suffix string `json:"suffix,omitempty"`
 	// The discrete values of the environment variable.
 	Values []string `json:"values,omitempty"`
+	// Only optimize the environment variable for specific values of another environment variable.
+	ActiveWhen *EnvironmentVariableCondition `json:"activeWhen,omitempty"`
+}
+
+// EnvironmentVariableCondition restricts an environment variable to specific values of another environment variable.
+type EnvironmentVariableCondition struct {
+	// The name of the other environment variable, it must be in the same container and have discrete values.
+	Name string `json:"name"`
+	// The values of the other environment variable.
+	Values []string `json:"values"`
 }
 
 // Ingress describes the point of ingress to the application.
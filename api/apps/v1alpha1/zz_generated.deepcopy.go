@@ -98,6 +98,13 @@ func (in *ContainerResources) DeepCopyInto(out *ContainerResources) {
 		*out = make([]v1.ResourceName, len(*in))
 		copy(*out, *in)
 	}
+	if in.Step != nil {
+		in, out := &in.Step, &out.Step
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResources.
@@ -168,6 +175,11 @@ func (in *EnvironmentVariable) DeepCopyInto(out *EnvironmentVariable) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ActiveWhen != nil {
+		in, out := &in.ActiveWhen, &out.ActiveWhen
+		*out = new(EnvironmentVariableCondition)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentVariable.
@@ -180,6 +192,26 @@ func (in *EnvironmentVariable) DeepCopy() *EnvironmentVariable {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentVariableCondition) DeepCopyInto(out *EnvironmentVariableCondition) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentVariableCondition.
+func (in *EnvironmentVariableCondition) DeepCopy() *EnvironmentVariableCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentVariableCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ErrorRateGoal) DeepCopyInto(out *ErrorRateGoal) {
 	*out = *in
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CronExperimentSpec defines the desired state of CronExperiment
+type CronExperimentSpec struct {
+	// Schedule is a cron expression ("minute hour day-of-month month day-of-week") of when to create new experiments
+	Schedule string `json:"schedule"`
+	// TimeZone is the IANA time zone name used to interpret the schedule, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	// Suspend prevents new experiments from being created, it does not apply to experiments that are already running
+	Suspend *bool `json:"suspend,omitempty"`
+	// TemplateRef is the reference to the experiment template, in the same namespace, used to create new experiments
+	TemplateRef corev1.LocalObjectReference `json:"templateRef"`
+	// Variables are the values substituted into the template, overriding the template defaults
+	Variables []TemplateVariable `json:"variables,omitempty"`
+	// SuccessfulExperimentsHistoryLimit is the number of completed experiments to keep, defaults to 3
+	SuccessfulExperimentsHistoryLimit *int32 `json:"successfulExperimentsHistoryLimit,omitempty"`
+	// FailedExperimentsHistoryLimit is the number of failed experiments to keep, defaults to 1
+	FailedExperimentsHistoryLimit *int32 `json:"failedExperimentsHistoryLimit,omitempty"`
+}
+
+// CronExperimentStatus defines the observed state of CronExperiment
+type CronExperimentStatus struct {
+	// Active is the list of experiments which have not finished yet
+	Active []corev1.ObjectReference `json:"active,omitempty"`
+	// LastScheduleTime is the last time an experiment was scheduled; scheduled runs are skipped if an earlier
+	// experiment is still active
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastExperimentName is the name of the most recently created experiment
+	LastExperimentName string `json:"lastExperimentName,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// CronExperiment is the Schema for the cronexperiments API
+// +kubebuilder:resource:shortName=cronexp
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="Cron schedule"
+// +kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend",description="Suspended"
+// +kubebuilder:printcolumn:name="Last Schedule",type="date",JSONPath=".status.lastScheduleTime",description="Last schedule time"
+type CronExperiment struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Specification of the recurring experiment
+	Spec CronExperimentSpec `json:"spec,omitempty"`
+	// Current status of the recurring experiment
+	Status CronExperimentStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CronExperimentList contains a list of CronExperiment
+type CronExperimentList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// The list of cron experiments
+	Items []CronExperiment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CronExperiment{}, &CronExperimentList{})
+}
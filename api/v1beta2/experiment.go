@@ -17,6 +17,10 @@ limitations under the License.
 package v1beta2
 
 import (
+	"math"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -54,3 +58,71 @@ func (in *Experiment) TrialSelector() *metav1.LabelSelector {
 		},
 	}
 }
+
+// StepBounds returns the smallest and largest multiples of the step in the range of the integer parameter, if there
+// are no multiples of the step in range the minimum will be greater then the maximum
+func (in *Parameter) StepBounds() (int32, int32) {
+	if in.Step <= 1 {
+		return in.Min, in.Max
+	}
+	step := float64(in.Step)
+	return int32(math.Ceil(float64(in.Min)/step) * step), int32(math.Floor(float64(in.Max)/step) * step)
+}
+
+// Snap returns the multiple of the step closest to the supplied value, staying in the range of the integer parameter
+func (in *Parameter) Snap(v int64) int32 {
+	min, max := in.StepBounds()
+	if in.Step > 1 {
+		step := float64(in.Step)
+		v = int64(math.Round(float64(v)/step) * step)
+	}
+	if v < int64(min) {
+		return min
+	}
+	if v > int64(max) {
+		return max
+	}
+	return int32(v)
+}
+
+// IsActive checks the activation condition of the parameter against the string representation of the assigned values
+func (in *Parameter) IsActive(values map[string]string) bool {
+	if in.ActiveWhen == nil {
+		return true
+	}
+
+	if v, ok := values[in.ActiveWhen.Parameter]; ok {
+		for _, av := range in.ActiveWhen.Values {
+			if v == av {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Bounds returns the inclusive range of the floating point parameter
+func (in *FloatParameter) Bounds() (float64, float64) {
+	return quantityFloat(&in.Min), quantityFloat(&in.Max)
+}
+
+// Format rounds a value to the precision of the floating point parameter, keeping it in range, and returns the
+// string representation
+func (in *FloatParameter) Format(v float64) string {
+	prec := -1
+	if in.Precision != nil && *in.Precision >= 0 {
+		prec = int(*in.Precision)
+		scale := math.Pow10(prec)
+		v = math.Round(v*scale) / scale
+	}
+
+	min, max := in.Bounds()
+	v = math.Max(min, math.Min(max, v))
+	return strconv.FormatFloat(v, 'f', prec, 64)
+}
+
+// quantityFloat returns the exact floating point value of a quantity
+func quantityFloat(q *resource.Quantity) float64 {
+	f, _ := strconv.ParseFloat(q.AsDec().String(), 64)
+	return f
+}
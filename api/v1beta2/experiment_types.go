@@ -83,6 +83,34 @@ type Parameter struct {
 	Max int32 `json:"max,omitempty"`
 	// The discrete allowed values of the parameter
 	Values []string `json:"values,omitempty"`
+	// The granularity of an integer parameter, when greater then one only multiples of the step are allowed
+	Step int32 `json:"step,omitempty"`
+	// The domain of a floating point parameter, mutually exclusive with min, max and values
+	Float *FloatParameter `json:"float,omitempty"`
+	// The condition under which the parameter applies, inactive parameters are not assigned to trials
+	ActiveWhen *ParameterCondition `json:"activeWhen,omitempty"`
+}
+
+// ParameterCondition activates a parameter only for specific values of a categorical parameter
+type ParameterCondition struct {
+	// The name of the categorical parameter, it must not be conditional itself
+	Parameter string `json:"parameter"`
+	// The values of the categorical parameter that activate the condition
+	Values []string `json:"values"`
+}
+
+// FloatParameter represents the domain of a continuous parameter
+type FloatParameter struct {
+	// The inclusive minimum value of the parameter
+	Min resource.Quantity `json:"min"`
+	// The inclusive maximum value of the parameter
+	Max resource.Quantity `json:"max"`
+	// LogScale samples values uniformly on a logarithmic scale, the bounds must be positive. Note that the server only
+	// sees the base 10 logarithm of the parameter values, so log scale parameters may only be used in order constraints
+	// with other log scale parameters.
+	LogScale bool `json:"logScale,omitempty"`
+	// Precision is the number of digits after the decimal point assigned values are rounded to
+	Precision *int32 `json:"precision,omitempty"`
 }
 
 // Constraint represents a constraint to the domain of the parameters
@@ -93,6 +121,10 @@ type Constraint struct {
 	Order *OrderConstraint `json:"order,omitempty"`
 	// The sum constraint to impose
 	Sum *SumConstraint `json:"sum,omitempty"`
+	// The linear constraint to impose
+	Linear *LinearConstraint `json:"linear,omitempty"`
+	// The ratio constraint to impose
+	Ratio *RatioConstraint `json:"ratio,omitempty"`
 }
 
 // OrderConstraint defines a constraint between the ordering of two parameters in the experiment
@@ -121,6 +153,37 @@ type SumConstraint struct {
 	Parameters []SumConstraintParameter `json:"parameters"`
 }
 
+// LinearConstraintTerm is a parameter and its coefficient in a linear constraint
+type LinearConstraintTerm struct {
+	// Name of the parameter
+	Name string `json:"name"`
+	// Coefficient of the parameter
+	Coefficient resource.Quantity `json:"coefficient"`
+}
+
+// LinearConstraint defines inclusive bounds for a linear combination of parameters, products of parameters (e.g.
+// "replicas × cpu") are not supported
+type LinearConstraint struct {
+	// Terms of the linear combination
+	Terms []LinearConstraintTerm `json:"terms"`
+	// Min is the optional lower bound of the linear combination
+	Min *resource.Quantity `json:"min,omitempty"`
+	// Max is the optional upper bound of the linear combination
+	Max *resource.Quantity `json:"max,omitempty"`
+}
+
+// RatioConstraint defines inclusive bounds for the ratio between two parameters, the denominator must be positive
+type RatioConstraint struct {
+	// Numerator is the name of the parameter being compared
+	Numerator string `json:"numerator"`
+	// Denominator is the name of the parameter being compared against
+	Denominator string `json:"denominator"`
+	// Min is the optional lower bound of the ratio
+	Min *resource.Quantity `json:"min,omitempty"`
+	// Max is the optional upper bound of the ratio
+	Max *resource.Quantity `json:"max,omitempty"`
+}
+
 // MetricType represents the allowable types of metrics
 type MetricType string
 
@@ -199,12 +262,26 @@ type PatchTemplate struct {
 	ReadinessGates []PatchReadinessGate `json:"readinessGates,omitempty"`
 }
 
-// NamespaceTemplateSpec is used as a template for creating new namespaces
+// NamespaceTemplateSpec is used as a template for creating new namespaces. Created namespaces (along with the trials
+// in them) are deleted once the experiment is finished and the recommendation is ready. Like trial patches, creating,
+// cloning into and deleting namespaces requires a customer defined role; use `generate rbac` to produce one.
 type NamespaceTemplateSpec struct {
 	// Standard object metadata
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// Specification of the namespace
 	Spec corev1.NamespaceSpec `json:"spec,omitempty"`
+	// Clone copies matching resources from an existing namespace into each new namespace
+	Clone *NamespaceCloneSpec `json:"clone,omitempty"`
+}
+
+// NamespaceCloneSpec describes the resources to copy into a new trial namespace
+type NamespaceCloneSpec struct {
+	// Namespace to copy resources from, defaults to the experiment namespace
+	Namespace string `json:"namespace,omitempty"`
+	// Selector matches the Deployments, Services and ConfigMaps to copy
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Flag indicating matching Secrets should also be copied
+	Secrets bool `json:"secrets,omitempty"`
 }
 
 // TrialTemplateSpec is used as a template for creating new trials
@@ -223,6 +300,8 @@ const (
 	ExperimentComplete ExperimentConditionType = "stormforge.io/experiment-complete"
 	// ExperimentFailed is a condition that indicates an experiment failed
 	ExperimentFailed ExperimentConditionType = "stormforge.io/experiment-failed"
+	// ExperimentWaiting is a condition that indicates an experiment is waiting to start new trials
+	ExperimentWaiting ExperimentConditionType = "stormforge.io/experiment-waiting"
 )
 
 // ExperimentCondition represents an observed condition of an experiment
@@ -241,10 +320,57 @@ type ExperimentCondition struct {
 	Message string `json:"message,omitempty"`
 }
 
+// TrialSchedule restricts when new trials can be started
+type TrialSchedule struct {
+	// Windows are the recurring periods of time during which trials can run
+	Windows []ScheduleWindow `json:"windows"`
+	// TimeZone is the IANA time zone name used to evaluate the window start times, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ScheduleWindow is a recurring period of time
+type ScheduleWindow struct {
+	// Start is a cron expression (e.g. "0 22 * * 1-5") for when the window opens
+	Start string `json:"start"`
+	// Duration is how long the window stays open
+	Duration metav1.Duration `json:"duration"`
+}
+
+// ParameterMapping renames a parameter of a previous experiment
+type ParameterMapping struct {
+	// From is the name of the parameter in the previous experiment
+	From string `json:"from"`
+	// To is the name of the parameter in this experiment
+	To string `json:"to"`
+}
+
+// WarmStart describes a previous experiment whose observations are imported into a new experiment
+type WarmStart struct {
+	// ExperimentRef is the reference to a previous experiment in the cluster, the previous experiment must be in the
+	// namespace of this experiment
+	ExperimentRef *corev1.ObjectReference `json:"experimentRef,omitempty"`
+	// ServerExperimentName is the name of a previous experiment on the server. The server does not record how the
+	// cluster parameters are encoded, so the assignments are interpreted using the parameters of this experiment:
+	// the log scale of floating point parameters must match the previous experiment (values which are out of range
+	// or do not match the step of this experiment are skipped)
+	ServerExperimentName string `json:"serverExperimentName,omitempty"`
+	// ParameterMappings rename the parameters of the previous experiment to the parameters of this experiment
+	ParameterMappings []ParameterMapping `json:"parameterMappings,omitempty"`
+}
+
 // ExperimentSpec defines the desired state of Experiment
 type ExperimentSpec struct {
 	// Replicas is the number of trials to execute concurrently, defaults to 1
 	Replicas *int32 `json:"replicas,omitempty"`
+	// PriorityClassName is the name of the priority class used to order experiments waiting to start trials when the
+	// number of concurrent trials across the cluster is limited
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Schedule restricts new trials to recurring windows of time
+	Schedule *TrialSchedule `json:"schedule,omitempty"`
+	// WarmStart imports (up to 50 of) the completed trials of a previous experiment when the experiment is created on
+	// the server; trials with assignments that are no longer valid or without values for every optimized metric are
+	// skipped
+	WarmStart *WarmStart `json:"warmStart,omitempty"`
 	// Optimization defines additional configuration for the optimization
 	Optimization []Optimization `json:"optimization,omitempty"`
 	// Parameters defines the search space for the experiment
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TemplateVariable is a named value substituted into an experiment template
+type TemplateVariable struct {
+	// Name of the variable, references of the form "$(NAME)" anywhere in the template are replaced by the value
+	Name string `json:"name"`
+	// Value of the variable, on a template this is the default value used when no other value is supplied
+	Value string `json:"value,omitempty"`
+}
+
+// ExperimentTemplateSpec is used as a template for creating new experiments
+type ExperimentTemplateSpec struct {
+	// Standard object metadata
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Specification of the desired behavior for the experiment
+	Spec ExperimentSpec `json:"spec,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// ExperimentTemplate is the Schema for the experimenttemplates API
+// +kubebuilder:resource:shortName=expt
+type ExperimentTemplate struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Variables are the variables which can be substituted into the template
+	Variables []TemplateVariable `json:"variables,omitempty"`
+	// Template describes the experiments that will be created from this template
+	Template ExperimentTemplateSpec `json:"template"`
+}
+
+// +kubebuilder:object:root=true
+
+// ExperimentTemplateList contains a list of ExperimentTemplate
+type ExperimentTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// The list of experiment templates
+	Items []ExperimentTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExperimentTemplate{}, &ExperimentTemplateList{})
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RecommendedPatch represents an experiment patch rendered using the recommended assignments
+type RecommendedPatch struct {
+	// The reference to the object the patch should be applied to
+	TargetRef corev1.ObjectReference `json:"targetRef"`
+	// The patch content type, must be a type supported by the Kubernetes API server
+	PatchType types.PatchType `json:"patchType"`
+	// The rendered patch data
+	Data string `json:"data"`
+}
+
+// RecommendedValue represents a metric value observed for the recommended trial
+type RecommendedValue struct {
+	// The metric name the value corresponds to
+	Name string `json:"name"`
+	// The observed float64 value, formatted as a string
+	Value string `json:"value"`
+	// The value observed for the baseline trial, formatted as a string
+	Baseline string `json:"baseline,omitempty"`
+	// The relative improvement over the baseline taking the metric direction into account (e.g. "0.25" for 25% better)
+	Improvement string `json:"improvement,omitempty"`
+}
+
+// RecommendationConditionType represents the possible observable conditions for a recommendation
+type RecommendationConditionType string
+
+const (
+	// RecommendationReady is a condition that indicates the recommendation has been produced from the experiment
+	RecommendationReady RecommendationConditionType = "stormforge.io/recommendation-ready"
+	// RecommendationApproved is a condition that indicates the recommendation has been approved for promotion
+	RecommendationApproved RecommendationConditionType = "stormforge.io/recommendation-approved"
+	// RecommendationPromoted is a condition that indicates the recommended patches were applied to the promotion target
+	// and the patched objects are ready
+	RecommendationPromoted RecommendationConditionType = "stormforge.io/recommendation-promoted"
+	// RecommendationRolledBack is a condition that indicates a failed promotion was reverted
+	RecommendationRolledBack RecommendationConditionType = "stormforge.io/recommendation-rolled-back"
+)
+
+// RecommendationCondition represents an observed condition of a recommendation
+type RecommendationCondition struct {
+	// The condition type
+	Type RecommendationConditionType `json:"type"`
+	// The status of the condition, one of "True", "False", or "Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// The last known time the condition was checked
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
+	// The time at which the condition last changed status
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// A reason code describing the why the condition occurred
+	Reason string `json:"reason,omitempty"`
+	// A human readable message describing the transition
+	Message string `json:"message,omitempty"`
+}
+
+// RecommendationPromotion describes where the recommended patches are applied once the recommendation is approved
+type RecommendationPromotion struct {
+	// TargetNamespace overrides the namespace of every recommended patch target, defaults to the namespace of each target.
+	// Without a kubeconfig every patch target must be in the namespace of the recommendation
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+	// KubeConfig selects a key of a secret in the recommendation namespace that contains the kubeconfig of the
+	// cluster to promote to, defaults to the cluster the controller is running in. The controller is not granted access
+	// to secrets, the "get" permission on the secret must be granted along with the permissions on the patch targets
+	KubeConfig *corev1.SecretKeySelector `json:"kubeConfig,omitempty"`
+	// ReadinessGates are the conditions every patched object must satisfy for the promotion to succeed, if the
+	// conditions are not met the changes are rolled back; defaults to "stormforge.io/app-ready"
+	ReadinessGates []PatchReadinessGate `json:"readinessGates,omitempty"`
+}
+
+// PromotedChange represents a recommended patch applied during promotion
+type PromotedChange struct {
+	// The reference to the object the patch was applied to
+	TargetRef corev1.ObjectReference `json:"targetRef"`
+	// The patch content type
+	PatchType types.PatchType `json:"patchType"`
+	// The applied patch data
+	Data string `json:"data"`
+	// Original is the JSON representation of the object before the patch was applied, it is recorded before the
+	// patch is applied and used to restore the object during a rollback
+	Original string `json:"original,omitempty"`
+	// Applied indicates the patch was successfully applied to the object
+	Applied bool `json:"applied,omitempty"`
+}
+
+// PromotionStatus records the approval and changes made while promoting a recommendation
+type PromotionStatus struct {
+	// ApprovedBy is the name of the approver of the promotion, it is only the authenticated user if the admission
+	// webhooks are enabled
+	ApprovedBy string `json:"approvedBy,omitempty"`
+	// ApprovedTime is the time at which the approval was observed
+	ApprovedTime *metav1.Time `json:"approvedTime,omitempty"`
+	// Changes are the patches applied to the promotion target
+	Changes []PromotedChange `json:"changes,omitempty"`
+	// ReadinessChecks are the checks evaluated against the patched objects
+	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+}
+
+// RecommendationSpec defines the desired state of Recommendation
+type RecommendationSpec struct {
+	// ExperimentRef is the reference to the experiment the recommendation is produced from, defaults to an experiment
+	// in the same namespace with the same name
+	ExperimentRef *corev1.ObjectReference `json:"experimentRef,omitempty"`
+	// TrialName is the name of the recommended trial, if empty the best completed trial is selected by comparing the
+	// optimized metrics in the order they appear on the experiment (i.e. the first metric is the most important)
+	TrialName string `json:"trialName,omitempty"`
+	// Assignments are the parameter assignments of the recommended trial
+	Assignments []Assignment `json:"assignments,omitempty"`
+	// Patches are the experiment patches rendered using the recommended assignments
+	Patches []RecommendedPatch `json:"patches,omitempty"`
+	// Values are the metric values of the recommended trial
+	Values []RecommendedValue `json:"values,omitempty"`
+	// Promotion describes where the recommended patches are applied once approved
+	Promotion *RecommendationPromotion `json:"promotion,omitempty"`
+}
+
+// RecommendationStatus defines the observed state of Recommendation
+type RecommendationStatus struct {
+	// Phase is a brief human readable description of the recommendation status
+	Phase string `json:"phase"`
+	// Conditions is the current state of the recommendation
+	Conditions []RecommendationCondition `json:"conditions,omitempty"`
+	// Promotion is the record of an approved promotion
+	Promotion *PromotionStatus `json:"promotion,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// Recommendation is the Schema for the recommendations API
+// +kubebuilder:resource:shortName=rec
+// +kubebuilder:printcolumn:name="Trial",type="string",JSONPath=".spec.trialName",description="Recommended trial"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase",description="Recommendation status"
+type Recommendation struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Specification of the recommended configuration
+	Spec RecommendationSpec `json:"spec,omitempty"`
+	// Current status of a recommendation
+	Status RecommendationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RecommendationList contains a list of Recommendation
+type RecommendationList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// The list of recommendations
+	Items []Recommendation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Recommendation{}, &RecommendationList{})
+}
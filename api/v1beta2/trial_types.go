@@ -20,6 +20,7 @@ import (
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -32,6 +33,8 @@ type Assignment struct {
 	Name string `json:"name"`
 	// Value of the assignment
 	Value intstr.IntOrString `json:"value"`
+	// Float indicates the string value is the representation of a floating point number
+	Float bool `json:"float,omitempty"`
 }
 
 // TrialReadinessGate represents a readiness check on one or more objects that must pass after patches
@@ -56,6 +59,34 @@ type TrialReadinessGate struct {
 	// FailureThreshold is number of times that any of the specified ready conditions may be "False";
 	// defaults to 3, minimum value is 1
 	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+	// SuccessThreshold is the number of consecutive times all of the specified ready conditions must be "True";
+	// defaults to 1, minimum value is 1
+	SuccessThreshold int32 `json:"successThreshold,omitempty"`
+	// Stabilization evaluates a metric until the observed values settle, mutually exclusive with "Kind"
+	Stabilization *MetricStabilization `json:"stabilization,omitempty"`
+}
+
+// MetricStabilization represents a metric that must stop changing before the trial run job can start
+type MetricStabilization struct {
+	// Metric is the query to evaluate, the "min", "max", "minimize" and "optimize" fields are ignored. Queries are
+	// evaluated as if the trial run started at the beginning of the window.
+	Metric Metric `json:"metric"`
+	// Tolerance is the maximum difference between the largest and smallest values observed in the window relative
+	// to the average (e.g. "0.05" for 5%); when the average is zero the tolerance is an absolute difference; defaults to 0.05
+	Tolerance *resource.Quantity `json:"tolerance,omitempty"`
+	// Window is the amount of time the observed values must remain within the tolerance; defaults to 1 minute
+	Window *metav1.Duration `json:"window,omitempty"`
+	// Timeout is the amount of time to wait for the metric to stabilize, measured from the first attempt of the
+	// readiness check, attempts are not counted against the failure threshold while waiting; defaults to 10 minutes
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ReadinessObservation represents a metric value observed while evaluating a readiness check
+type ReadinessObservation struct {
+	// The time at which the value was observed
+	Time metav1.Time `json:"time"`
+	// The observed float64 value, formatted as a string
+	Value string `json:"value"`
 }
 
 // HelmValue represents a value in a Helm template
@@ -89,7 +120,8 @@ type ParameterSelector struct {
 type HelmValuesFromSource struct {
 	// The ConfigMap to select from
 	ConfigMap *ConfigMapHelmValuesFromSource `json:"configMap,omitempty"`
-	// TODO Secret support?
+	// The Secret to select from
+	Secret *SecretHelmValuesFromSource `json:"secret,omitempty"`
 }
 
 // ConfigMapHelmValuesFromSource is a reference to a ConfigMap that contains "*values.yaml" keys
@@ -98,6 +130,11 @@ type ConfigMapHelmValuesFromSource struct {
 	corev1.LocalObjectReference `json:",inline"`
 }
 
+// SecretHelmValuesFromSource is a reference to a Secret that contains "*values.yaml" keys
+type SecretHelmValuesFromSource struct {
+	corev1.LocalObjectReference `json:",inline"`
+}
+
 // SetupTask represents the configuration necessary to apply application state to the cluster
 // prior to each trial run and remove that state after the run concludes
 type SetupTask struct {
@@ -115,7 +152,7 @@ type SetupTask struct {
 	SkipDelete bool `json:"skipDelete,omitempty"`
 	// Volume mounts for the setup task
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
-	// The Helm chart reference to release as part of this task
+	// The Helm chart reference to release as part of this task, may be an "oci://" registry reference
 	HelmChart string `json:"helmChart,omitempty"`
 	// The Helm chart version, empty means use the latest
 	HelmChartVersion string `json:"helmChartVersion,omitempty"`
@@ -125,6 +162,52 @@ type SetupTask struct {
 	HelmValuesFrom []HelmValuesFromSource `json:"helmValuesFrom,omitempty"`
 	// The Helm repository to fetch the chart from
 	HelmRepository string `json:"helmRepository,omitempty"`
+	// The Helm values YAML, evaluated as a template using the same rules as patches; ignored unless helmChart is also set
+	HelmValuesInline string `json:"helmValuesInline,omitempty"`
+	// The Helm release name, defaults to the task name
+	HelmReleaseName string `json:"helmReleaseName,omitempty"`
+	// The Helm release namespace, defaults to the trial namespace
+	HelmReleaseNamespace string `json:"helmReleaseNamespace,omitempty"`
+	// Flag to indicate the Helm release should be upgraded in place for each trial (and rolled back if the upgrade
+	// fails) instead of being installed and deleted; the deletion part of the task is always skipped
+	HelmUpgrade bool `json:"helmUpgrade,omitempty"`
+	// Manifests are applied directly by the controller instead of running a setup task container
+	Manifests *SetupManifests `json:"manifests,omitempty"`
+	// DependsOn is the list of setup task names that must complete before this task starts; the order is
+	// reversed when deleting. Tasks with manifests are always applied before tasks that run in a container.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// OutputsConfigMap is the name of a config map the task writes key/value outputs to; the outputs are read once
+	// the setup tasks are created. Outputs may also be published as "key=value" lines in the termination message.
+	// These outputs are copied to the trial status in plain text, use an outputs secret for credentials.
+	OutputsConfigMap *corev1.LocalObjectReference `json:"outputsConfigMap,omitempty"`
+	// OutputsSecret is the name of a secret the task writes sensitive key/value outputs to; only references to the
+	// secret keys are recorded on the trial and the trial run job reads the values from the secret. Secret outputs are
+	// not available to patch or metric templates.
+	OutputsSecret *corev1.LocalObjectReference `json:"outputsSecret,omitempty"`
+}
+
+// SetupTaskStatus represents the current state of an individual setup task
+type SetupTaskStatus struct {
+	// Name is the name of the setup task
+	Name string `json:"name"`
+	// Mode indicates if the task was creating or deleting
+	Mode string `json:"mode"`
+	// Phase is the current phase of the setup task
+	Phase corev1.PodPhase `json:"phase"`
+	// Message is a human readable description of the setup task status
+	Message string `json:"message,omitempty"`
+	// Objects are references to the objects applied from the setup task manifests, they are pruned after the run
+	Objects []corev1.ObjectReference `json:"objects,omitempty"`
+}
+
+// SetupManifests represents Kubernetes objects which are applied to the cluster prior to each trial run and pruned
+// after the run concludes. Manifests are rendered as Go templates using the same data available to patches.
+type SetupManifests struct {
+	// Inline is a YAML document stream of objects to apply
+	Inline string `json:"inline,omitempty"`
+	// ConfigMap references a config map whose values are YAML document streams of objects to apply; if the config
+	// map contains a "kustomization.yaml" key, the values are treated as a kustomization directory and built first
+	ConfigMap *corev1.LocalObjectReference `json:"configMap,omitempty"`
 }
 
 // PatchOperation represents a patch used to prepare the cluster for a trial run, includes the evaluated
@@ -160,6 +243,14 @@ type ReadinessCheck struct {
 	// AttemptsRemaining is the number of failed attempts to allow before marking the entire trial as failed, will be
 	// automatically set to zero if the check has been successfully evaluated
 	AttemptsRemaining int32 `json:"attemptsRemaining,omitempty"`
+	// SuccessThreshold is the number of consecutive successful attempts required before the check is considered passed
+	SuccessThreshold int32 `json:"successThreshold,omitempty"`
+	// ConsecutiveSuccesses is the number of successful attempts since the last failed attempt
+	ConsecutiveSuccesses int32 `json:"consecutiveSuccesses,omitempty"`
+	// Stabilization is the metric that must settle before this check passes
+	Stabilization *MetricStabilization `json:"stabilization,omitempty"`
+	// Observations are the metric values observed during the current stabilization window
+	Observations []ReadinessObservation `json:"observations,omitempty"`
 	// LastCheckTime is the timestamp of the last evaluation attempt
 	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
 }
@@ -178,6 +269,19 @@ type Value struct {
 	AttemptsRemaining int `json:"attemptsRemaining,omitempty"`
 }
 
+// TrialFailureCategory classifies the cause of a trial failure
+type TrialFailureCategory string
+
+const (
+	// FailureInfeasible indicates the trial assignments produced a configuration that cannot run successfully (e.g. the
+	// patches were rejected by the cluster, pods were OOMKilled or could not be scheduled)
+	FailureInfeasible TrialFailureCategory = "Infeasible"
+	// FailureInfrastructure indicates the trial failed for reasons unrelated to the trial assignments
+	FailureInfrastructure TrialFailureCategory = "Infrastructure"
+	// FailureMeasurement indicates the trial ran but the metric values could not be collected
+	FailureMeasurement TrialFailureCategory = "Measurement"
+)
+
 // TrialConditionType represents the possible observable conditions for a trial
 type TrialConditionType string
 
@@ -271,6 +375,18 @@ type TrialStatus struct {
 	PatchOperations []PatchOperation `json:"patchOperations,omitempty"`
 	// ReadinessChecks are the all of the objects whose conditions need to be inspected for this trial
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+	// FailureCategory classifies the cause of a failed trial
+	FailureCategory TrialFailureCategory `json:"failureCategory,omitempty"`
+	// SetupTasks is the status of the individual setup tasks
+	SetupTasks []SetupTaskStatus `json:"setupTasks,omitempty"`
+	// SetupOutputs are the key/value outputs published by the setup tasks
+	SetupOutputs map[string]string `json:"setupOutputs,omitempty"`
+	// SetupSecretOutputs are references to the sensitive outputs published by the setup tasks
+	SetupSecretOutputs map[string]corev1.SecretKeySelector `json:"setupSecretOutputs,omitempty"`
+	// Diagnostics references the config map containing the container logs and events captured when the trial failed
+	Diagnostics *corev1.LocalObjectReference `json:"diagnostics,omitempty"`
+	// Snapshot is the location of the patches, manifests and Helm values recorded when the trial was patched
+	Snapshot string `json:"snapshot,omitempty"`
 }
 
 // +genclient
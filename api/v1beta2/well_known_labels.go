@@ -28,8 +28,16 @@ const (
 	// AnnotationServerSync controls additional behavior around synchronizing the experiment remotely
 	AnnotationServerSync = "stormforge.io/server-sync"
 
+	// AnnotationCreatedNamespace indicates a namespace was created for trials and should be deleted once it is no longer needed
+	AnnotationCreatedNamespace = "stormforge.io/created-namespace"
+
+	// AnnotationPreviousExperiment is the name of the experiment created by the previous run of a cron experiment
+	AnnotationPreviousExperiment = "stormforge.io/previous-experiment"
+
 	// LabelExperiment is the name of the experiment associated with an object
 	LabelExperiment = "stormforge.io/experiment"
+	// LabelCronExperiment is the name of the cron experiment that created an experiment
+	LabelCronExperiment = "stormforge.io/cron-experiment"
 )
 
 // Trial labels and annotations
@@ -38,9 +46,23 @@ const (
 	// AnnotationInitializer is a comma-delimited list of initializing processes. Similar to a "finalizer", the trial
 	// will not start executing until the initializer is empty.
 	AnnotationInitializer = "stormforge.io/initializer"
+	// AnnotationRetryCount is the number of earlier trials with the same assignments that were abandoned so the
+	// assignments could be retried
+	AnnotationRetryCount = "stormforge.io/retry-count"
 
 	// LabelTrial contains the name of the trial associated with an object
 	LabelTrial = "stormforge.io/trial"
 	// LabelTrialRole contains the role in trial execution
 	LabelTrialRole = "stormforge.io/trial-role"
+	// LabelSetupTask contains the name of the setup task which created an object
+	LabelSetupTask = "stormforge.io/setup-task"
+)
+
+// Recommendation labels and annotations
+
+const (
+	// AnnotationApprovedBy is the name of the approver of a recommendation; setting it triggers the promotion of the
+	// recommended patches. When the admission webhooks are enabled the value is replaced with the authenticated user
+	// making the change, otherwise it is self-reported by whoever can edit the recommendation
+	AnnotationApprovedBy = "stormforge.io/approved-by"
 )
@@ -22,9 +22,9 @@ package v1beta2
 
 import (
 	"k8s.io/api/batch/v1beta1"
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -74,6 +74,16 @@ func (in *Constraint) DeepCopyInto(out *Constraint) {
 		*out = new(SumConstraint)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Linear != nil {
+		in, out := &in.Linear, &out.Linear
+		*out = new(LinearConstraint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ratio != nil {
+		in, out := &in.Ratio, &out.Ratio
+		*out = new(RatioConstraint)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Constraint.
@@ -86,6 +96,125 @@ func (in *Constraint) DeepCopy() *Constraint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronExperiment) DeepCopyInto(out *CronExperiment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronExperiment.
+func (in *CronExperiment) DeepCopy() *CronExperiment {
+	if in == nil {
+		return nil
+	}
+	out := new(CronExperiment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CronExperiment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronExperimentList) DeepCopyInto(out *CronExperimentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CronExperiment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronExperimentList.
+func (in *CronExperimentList) DeepCopy() *CronExperimentList {
+	if in == nil {
+		return nil
+	}
+	out := new(CronExperimentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CronExperimentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronExperimentSpec) DeepCopyInto(out *CronExperimentSpec) {
+	*out = *in
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+	out.TemplateRef = in.TemplateRef
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]TemplateVariable, len(*in))
+		copy(*out, *in)
+	}
+	if in.SuccessfulExperimentsHistoryLimit != nil {
+		in, out := &in.SuccessfulExperimentsHistoryLimit, &out.SuccessfulExperimentsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedExperimentsHistoryLimit != nil {
+		in, out := &in.FailedExperimentsHistoryLimit, &out.FailedExperimentsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronExperimentSpec.
+func (in *CronExperimentSpec) DeepCopy() *CronExperimentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CronExperimentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronExperimentStatus) DeepCopyInto(out *CronExperimentStatus) {
+	*out = *in
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]v1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronExperimentStatus.
+func (in *CronExperimentStatus) DeepCopy() *CronExperimentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CronExperimentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Experiment) DeepCopyInto(out *Experiment) {
 	*out = *in
@@ -170,6 +299,16 @@ func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(TrialSchedule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WarmStart != nil {
+		in, out := &in.WarmStart, &out.WarmStart
+		*out = new(WarmStart)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Optimization != nil {
 		in, out := &in.Optimization, &out.Optimization
 		*out = make([]Optimization, len(*in))
@@ -205,7 +344,7 @@ func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 	}
 	if in.NamespaceSelector != nil {
 		in, out := &in.NamespaceSelector, &out.NamespaceSelector
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.NamespaceTemplate != nil {
@@ -215,7 +354,7 @@ func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 	}
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 	in.TrialTemplate.DeepCopyInto(&out.TrialTemplate)
@@ -253,6 +392,108 @@ func (in *ExperimentStatus) DeepCopy() *ExperimentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentTemplate) DeepCopyInto(out *ExperimentTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]TemplateVariable, len(*in))
+		copy(*out, *in)
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentTemplate.
+func (in *ExperimentTemplate) DeepCopy() *ExperimentTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentTemplateList) DeepCopyInto(out *ExperimentTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExperimentTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentTemplateList.
+func (in *ExperimentTemplateList) DeepCopy() *ExperimentTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentTemplateSpec) DeepCopyInto(out *ExperimentTemplateSpec) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentTemplateSpec.
+func (in *ExperimentTemplateSpec) DeepCopy() *ExperimentTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FloatParameter) DeepCopyInto(out *FloatParameter) {
+	*out = *in
+	out.Min = in.Min.DeepCopy()
+	out.Max = in.Max.DeepCopy()
+	if in.Precision != nil {
+		in, out := &in.Precision, &out.Precision
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FloatParameter.
+func (in *FloatParameter) DeepCopy() *FloatParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(FloatParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmValue) DeepCopyInto(out *HelmValue) {
 	*out = *in
@@ -302,247 +543,752 @@ func (in *HelmValuesFromSource) DeepCopyInto(out *HelmValuesFromSource) {
 		*out = new(ConfigMapHelmValuesFromSource)
 		**out = **in
 	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(SecretHelmValuesFromSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmValuesFromSource.
+func (in *HelmValuesFromSource) DeepCopy() *HelmValuesFromSource {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmValuesFromSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinearConstraint) DeepCopyInto(out *LinearConstraint) {
+	*out = *in
+	if in.Terms != nil {
+		in, out := &in.Terms, &out.Terms
+		*out = make([]LinearConstraintTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinearConstraint.
+func (in *LinearConstraint) DeepCopy() *LinearConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(LinearConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinearConstraintTerm) DeepCopyInto(out *LinearConstraintTerm) {
+	*out = *in
+	out.Coefficient = in.Coefficient.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinearConstraintTerm.
+func (in *LinearConstraintTerm) DeepCopy() *LinearConstraintTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(LinearConstraintTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metric) DeepCopyInto(out *Metric) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Optimize != nil {
+		in, out := &in.Optimize, &out.Optimize
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(ResourceTarget)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metric.
+func (in *Metric) DeepCopy() *Metric {
+	if in == nil {
+		return nil
+	}
+	out := new(Metric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricStabilization) DeepCopyInto(out *MetricStabilization) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	if in.Tolerance != nil {
+		in, out := &in.Tolerance, &out.Tolerance
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricStabilization.
+func (in *MetricStabilization) DeepCopy() *MetricStabilization {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricStabilization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceCloneSpec) DeepCopyInto(out *NamespaceCloneSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceCloneSpec.
+func (in *NamespaceCloneSpec) DeepCopy() *NamespaceCloneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceCloneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceTemplateSpec) DeepCopyInto(out *NamespaceTemplateSpec) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(NamespaceCloneSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceTemplateSpec.
+func (in *NamespaceTemplateSpec) DeepCopy() *NamespaceTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Optimization) DeepCopyInto(out *Optimization) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Optimization.
+func (in *Optimization) DeepCopy() *Optimization {
+	if in == nil {
+		return nil
+	}
+	out := new(Optimization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrderConstraint) DeepCopyInto(out *OrderConstraint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrderConstraint.
+func (in *OrderConstraint) DeepCopy() *OrderConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(OrderConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Parameter) DeepCopyInto(out *Parameter) {
+	*out = *in
+	if in.Baseline != nil {
+		in, out := &in.Baseline, &out.Baseline
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Float != nil {
+		in, out := &in.Float, &out.Float
+		*out = new(FloatParameter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActiveWhen != nil {
+		in, out := &in.ActiveWhen, &out.ActiveWhen
+		*out = new(ParameterCondition)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Parameter.
+func (in *Parameter) DeepCopy() *Parameter {
+	if in == nil {
+		return nil
+	}
+	out := new(Parameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterCondition) DeepCopyInto(out *ParameterCondition) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterCondition.
+func (in *ParameterCondition) DeepCopy() *ParameterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterMapping) DeepCopyInto(out *ParameterMapping) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterMapping.
+func (in *ParameterMapping) DeepCopy() *ParameterMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterSelector) DeepCopyInto(out *ParameterSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterSelector.
+func (in *ParameterSelector) DeepCopy() *ParameterSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchOperation) DeepCopyInto(out *PatchOperation) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchOperation.
+func (in *PatchOperation) DeepCopy() *PatchOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(PatchOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchReadinessGate) DeepCopyInto(out *PatchReadinessGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchReadinessGate.
+func (in *PatchReadinessGate) DeepCopy() *PatchReadinessGate {
+	if in == nil {
+		return nil
+	}
+	out := new(PatchReadinessGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchTemplate) DeepCopyInto(out *PatchTemplate) {
+	*out = *in
+	if in.TargetRef != nil {
+		in, out := &in.TargetRef, &out.TargetRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.ReadinessGates != nil {
+		in, out := &in.ReadinessGates, &out.ReadinessGates
+		*out = make([]PatchReadinessGate, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchTemplate.
+func (in *PatchTemplate) DeepCopy() *PatchTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(PatchTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotedChange) DeepCopyInto(out *PromotedChange) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotedChange.
+func (in *PromotedChange) DeepCopy() *PromotedChange {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotedChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionStatus) DeepCopyInto(out *PromotionStatus) {
+	*out = *in
+	if in.ApprovedTime != nil {
+		in, out := &in.ApprovedTime, &out.ApprovedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]PromotedChange, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReadinessChecks != nil {
+		in, out := &in.ReadinessChecks, &out.ReadinessChecks
+		*out = make([]ReadinessCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionStatus.
+func (in *PromotionStatus) DeepCopy() *PromotionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RatioConstraint) DeepCopyInto(out *RatioConstraint) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RatioConstraint.
+func (in *RatioConstraint) DeepCopy() *RatioConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(RatioConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConditionTypes != nil {
+		in, out := &in.ConditionTypes, &out.ConditionTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Stabilization != nil {
+		in, out := &in.Stabilization, &out.Stabilization
+		*out = new(MetricStabilization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Observations != nil {
+		in, out := &in.Observations, &out.Observations
+		*out = make([]ReadinessObservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
+func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessObservation) DeepCopyInto(out *ReadinessObservation) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmValuesFromSource.
-func (in *HelmValuesFromSource) DeepCopy() *HelmValuesFromSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessObservation.
+func (in *ReadinessObservation) DeepCopy() *ReadinessObservation {
 	if in == nil {
 		return nil
 	}
-	out := new(HelmValuesFromSource)
+	out := new(ReadinessObservation)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Metric) DeepCopyInto(out *Metric) {
+func (in *Recommendation) DeepCopyInto(out *Recommendation) {
 	*out = *in
-	if in.Min != nil {
-		in, out := &in.Min, &out.Min
-		x := (*in).DeepCopy()
-		*out = &x
-	}
-	if in.Max != nil {
-		in, out := &in.Max, &out.Max
-		x := (*in).DeepCopy()
-		*out = &x
-	}
-	if in.Optimize != nil {
-		in, out := &in.Optimize, &out.Optimize
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Target != nil {
-		in, out := &in.Target, &out.Target
-		*out = new(ResourceTarget)
-		(*in).DeepCopyInto(*out)
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metric.
-func (in *Metric) DeepCopy() *Metric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recommendation.
+func (in *Recommendation) DeepCopy() *Recommendation {
 	if in == nil {
 		return nil
 	}
-	out := new(Metric)
+	out := new(Recommendation)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Recommendation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NamespaceTemplateSpec) DeepCopyInto(out *NamespaceTemplateSpec) {
+func (in *RecommendationCondition) DeepCopyInto(out *RecommendationCondition) {
 	*out = *in
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceTemplateSpec.
-func (in *NamespaceTemplateSpec) DeepCopy() *NamespaceTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationCondition.
+func (in *RecommendationCondition) DeepCopy() *RecommendationCondition {
 	if in == nil {
 		return nil
 	}
-	out := new(NamespaceTemplateSpec)
+	out := new(RecommendationCondition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Optimization) DeepCopyInto(out *Optimization) {
+func (in *RecommendationList) DeepCopyInto(out *RecommendationList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Recommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Optimization.
-func (in *Optimization) DeepCopy() *Optimization {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationList.
+func (in *RecommendationList) DeepCopy() *RecommendationList {
 	if in == nil {
 		return nil
 	}
-	out := new(Optimization)
+	out := new(RecommendationList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RecommendationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OrderConstraint) DeepCopyInto(out *OrderConstraint) {
+func (in *RecommendationPromotion) DeepCopyInto(out *RecommendationPromotion) {
 	*out = *in
+	if in.KubeConfig != nil {
+		in, out := &in.KubeConfig, &out.KubeConfig
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessGates != nil {
+		in, out := &in.ReadinessGates, &out.ReadinessGates
+		*out = make([]PatchReadinessGate, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrderConstraint.
-func (in *OrderConstraint) DeepCopy() *OrderConstraint {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationPromotion.
+func (in *RecommendationPromotion) DeepCopy() *RecommendationPromotion {
 	if in == nil {
 		return nil
 	}
-	out := new(OrderConstraint)
+	out := new(RecommendationPromotion)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Parameter) DeepCopyInto(out *Parameter) {
+func (in *RecommendationSpec) DeepCopyInto(out *RecommendationSpec) {
 	*out = *in
-	if in.Baseline != nil {
-		in, out := &in.Baseline, &out.Baseline
-		*out = new(intstr.IntOrString)
+	if in.ExperimentRef != nil {
+		in, out := &in.ExperimentRef, &out.ExperimentRef
+		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.Assignments != nil {
+		in, out := &in.Assignments, &out.Assignments
+		*out = make([]Assignment, len(*in))
+		copy(*out, *in)
+	}
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]RecommendedPatch, len(*in))
+		copy(*out, *in)
+	}
 	if in.Values != nil {
 		in, out := &in.Values, &out.Values
-		*out = make([]string, len(*in))
+		*out = make([]RecommendedValue, len(*in))
 		copy(*out, *in)
 	}
+	if in.Promotion != nil {
+		in, out := &in.Promotion, &out.Promotion
+		*out = new(RecommendationPromotion)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Parameter.
-func (in *Parameter) DeepCopy() *Parameter {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationSpec.
+func (in *RecommendationSpec) DeepCopy() *RecommendationSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(Parameter)
+	out := new(RecommendationSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ParameterSelector) DeepCopyInto(out *ParameterSelector) {
+func (in *RecommendationStatus) DeepCopyInto(out *RecommendationStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]RecommendationCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Promotion != nil {
+		in, out := &in.Promotion, &out.Promotion
+		*out = new(PromotionStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterSelector.
-func (in *ParameterSelector) DeepCopy() *ParameterSelector {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationStatus.
+func (in *RecommendationStatus) DeepCopy() *RecommendationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ParameterSelector)
+	out := new(RecommendationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PatchOperation) DeepCopyInto(out *PatchOperation) {
+func (in *RecommendedPatch) DeepCopyInto(out *RecommendedPatch) {
 	*out = *in
 	out.TargetRef = in.TargetRef
-	if in.Data != nil {
-		in, out := &in.Data, &out.Data
-		*out = make([]byte, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchOperation.
-func (in *PatchOperation) DeepCopy() *PatchOperation {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendedPatch.
+func (in *RecommendedPatch) DeepCopy() *RecommendedPatch {
 	if in == nil {
 		return nil
 	}
-	out := new(PatchOperation)
+	out := new(RecommendedPatch)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PatchReadinessGate) DeepCopyInto(out *PatchReadinessGate) {
+func (in *RecommendedValue) DeepCopyInto(out *RecommendedValue) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchReadinessGate.
-func (in *PatchReadinessGate) DeepCopy() *PatchReadinessGate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendedValue.
+func (in *RecommendedValue) DeepCopy() *RecommendedValue {
 	if in == nil {
 		return nil
 	}
-	out := new(PatchReadinessGate)
+	out := new(RecommendedValue)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PatchTemplate) DeepCopyInto(out *PatchTemplate) {
+func (in *ResourceTarget) DeepCopyInto(out *ResourceTarget) {
 	*out = *in
-	if in.TargetRef != nil {
-		in, out := &in.TargetRef, &out.TargetRef
-		*out = new(corev1.ObjectReference)
-		**out = **in
-	}
-	if in.ReadinessGates != nil {
-		in, out := &in.ReadinessGates, &out.ReadinessGates
-		*out = make([]PatchReadinessGate, len(*in))
-		copy(*out, *in)
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchTemplate.
-func (in *PatchTemplate) DeepCopy() *PatchTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceTarget.
+func (in *ResourceTarget) DeepCopy() *ResourceTarget {
 	if in == nil {
 		return nil
 	}
-	out := new(PatchTemplate)
+	out := new(ResourceTarget)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
+func (in *ScheduleWindow) DeepCopyInto(out *ScheduleWindow) {
 	*out = *in
-	out.TargetRef = in.TargetRef
-	if in.Selector != nil {
-		in, out := &in.Selector, &out.Selector
-		*out = new(v1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ConditionTypes != nil {
-		in, out := &in.ConditionTypes, &out.ConditionTypes
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.LastCheckTime != nil {
-		in, out := &in.LastCheckTime, &out.LastCheckTime
-		*out = (*in).DeepCopy()
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleWindow.
+func (in *ScheduleWindow) DeepCopy() *ScheduleWindow {
+	if in == nil {
+		return nil
 	}
+	out := new(ScheduleWindow)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
-func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretHelmValuesFromSource) DeepCopyInto(out *SecretHelmValuesFromSource) {
+	*out = *in
+	out.LocalObjectReference = in.LocalObjectReference
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretHelmValuesFromSource.
+func (in *SecretHelmValuesFromSource) DeepCopy() *SecretHelmValuesFromSource {
 	if in == nil {
 		return nil
 	}
-	out := new(ReadinessCheck)
+	out := new(SecretHelmValuesFromSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResourceTarget) DeepCopyInto(out *ResourceTarget) {
+func (in *SetupManifests) DeepCopyInto(out *SetupManifests) {
 	*out = *in
-	if in.LabelSelector != nil {
-		in, out := &in.LabelSelector, &out.LabelSelector
-		*out = new(v1.LabelSelector)
-		(*in).DeepCopyInto(*out)
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.LocalObjectReference)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceTarget.
-func (in *ResourceTarget) DeepCopy() *ResourceTarget {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetupManifests.
+func (in *SetupManifests) DeepCopy() *SetupManifests {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourceTarget)
+	out := new(SetupManifests)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -562,7 +1308,7 @@ func (in *SetupTask) DeepCopyInto(out *SetupTask) {
 	}
 	if in.VolumeMounts != nil {
 		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]corev1.VolumeMount, len(*in))
+		*out = make([]v1.VolumeMount, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -581,6 +1327,26 @@ func (in *SetupTask) DeepCopyInto(out *SetupTask) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Manifests != nil {
+		in, out := &in.Manifests, &out.Manifests
+		*out = new(SetupManifests)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OutputsConfigMap != nil {
+		in, out := &in.OutputsConfigMap, &out.OutputsConfigMap
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.OutputsSecret != nil {
+		in, out := &in.OutputsSecret, &out.OutputsSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetupTask.
@@ -593,6 +1359,26 @@ func (in *SetupTask) DeepCopy() *SetupTask {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetupTaskStatus) DeepCopyInto(out *SetupTaskStatus) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]v1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetupTaskStatus.
+func (in *SetupTaskStatus) DeepCopy() *SetupTaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SetupTaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SumConstraint) DeepCopyInto(out *SumConstraint) {
 	*out = *in
@@ -632,6 +1418,21 @@ func (in *SumConstraintParameter) DeepCopy() *SumConstraintParameter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateVariable) DeepCopyInto(out *TemplateVariable) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateVariable.
+func (in *TemplateVariable) DeepCopy() *TemplateVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Trial) DeepCopyInto(out *Trial) {
 	*out = *in
@@ -713,7 +1514,7 @@ func (in *TrialReadinessGate) DeepCopyInto(out *TrialReadinessGate) {
 	*out = *in
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.ConditionTypes != nil {
@@ -721,6 +1522,11 @@ func (in *TrialReadinessGate) DeepCopyInto(out *TrialReadinessGate) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Stabilization != nil {
+		in, out := &in.Stabilization, &out.Stabilization
+		*out = new(MetricStabilization)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialReadinessGate.
@@ -733,12 +1539,32 @@ func (in *TrialReadinessGate) DeepCopy() *TrialReadinessGate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrialSchedule) DeepCopyInto(out *TrialSchedule) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]ScheduleWindow, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialSchedule.
+func (in *TrialSchedule) DeepCopy() *TrialSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(TrialSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 	*out = *in
 	if in.ExperimentRef != nil {
 		in, out := &in.ExperimentRef, &out.ExperimentRef
-		*out = new(corev1.ObjectReference)
+		*out = new(v1.ObjectReference)
 		**out = **in
 	}
 	if in.Assignments != nil {
@@ -748,7 +1574,7 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 	}
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.JobTemplate != nil {
@@ -758,12 +1584,12 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 	}
 	if in.StartTimeOffset != nil {
 		in, out := &in.StartTimeOffset, &out.StartTimeOffset
-		*out = new(v1.Duration)
+		*out = new(metav1.Duration)
 		**out = **in
 	}
 	if in.ApproximateRuntime != nil {
 		in, out := &in.ApproximateRuntime, &out.ApproximateRuntime
-		*out = new(v1.Duration)
+		*out = new(metav1.Duration)
 		**out = **in
 	}
 	if in.TTLSecondsAfterFinished != nil {
@@ -797,7 +1623,7 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 	}
 	if in.SetupVolumes != nil {
 		in, out := &in.SetupVolumes, &out.SetupVolumes
-		*out = make([]corev1.Volume, len(*in))
+		*out = make([]v1.Volume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -853,6 +1679,32 @@ func (in *TrialStatus) DeepCopyInto(out *TrialStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SetupTasks != nil {
+		in, out := &in.SetupTasks, &out.SetupTasks
+		*out = make([]SetupTaskStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SetupOutputs != nil {
+		in, out := &in.SetupOutputs, &out.SetupOutputs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SetupSecretOutputs != nil {
+		in, out := &in.SetupSecretOutputs, &out.SetupSecretOutputs
+		*out = make(map[string]v1.SecretKeySelector, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Diagnostics != nil {
+		in, out := &in.Diagnostics, &out.Diagnostics
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialStatus.
@@ -896,3 +1748,28 @@ func (in *Value) DeepCopy() *Value {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarmStart) DeepCopyInto(out *WarmStart) {
+	*out = *in
+	if in.ExperimentRef != nil {
+		in, out := &in.ExperimentRef, &out.ExperimentRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.ParameterMappings != nil {
+		in, out := &in.ParameterMappings, &out.ParameterMappings
+		*out = make([]ParameterMapping, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarmStart.
+func (in *WarmStart) DeepCopy() *WarmStart {
+	if in == nil {
+		return nil
+	}
+	out := new(WarmStart)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -37,6 +37,8 @@ func NewCommand(o *Options) *cobra.Command {
 	}
 
 	cmd.AddCommand(NewMetricQueryCommand(&MetricQueryOptions{Config: o.Config}))
+	cmd.AddCommand(NewTrialDiagnosticsCommand(&TrialDiagnosticsOptions{Config: o.Config}))
+	cmd.AddCommand(NewSnapshotCommand(&SnapshotOptions{Config: o.Config}))
 
 	return cmd
 }
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-controller/v2/internal/snapshot"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// SnapshotOptions configure the re-materialization of a trial snapshot.
+type SnapshotOptions struct {
+	Config *config.OptimizeConfig
+	commander.IOStreams
+
+	TrialName  string
+	Filename   string
+	HelmValues bool
+	Patches    bool
+}
+
+// NewSnapshotCommand creates a command for re-materializing the manifests of a trial.
+func NewSnapshotCommand(o *SnapshotOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot [NAME]",
+		Short: "Show trial manifests",
+		Long:  "Re-materialize the manifests recorded when a trial was patched",
+		Args:  cobra.MaximumNArgs(1),
+
+		PreRun: commander.StreamsPreRun(&o.IOStreams),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				o.TrialName = args[0]
+			}
+			return o.Debug(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "snapshot `file` to read instead of looking up the trial")
+	cmd.Flags().BoolVar(&o.HelmValues, "helm-values", false, "show the resolved Helm values instead of the manifests")
+	cmd.Flags().BoolVar(&o.Patches, "patches", false, "show the rendered patches instead of the manifests")
+
+	_ = cmd.MarkFlagFilename("filename", "gz")
+
+	return cmd
+}
+
+// Debug prints the manifests (or patches or Helm values) recorded in a trial snapshot.
+func (o *SnapshotOptions) Debug(ctx context.Context) error {
+	data, err := o.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	s, err := snapshot.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	var docs []interface{}
+	switch {
+	case o.HelmValues:
+		docs = append(docs, s.HelmValues)
+	case o.Patches:
+		for i := range s.Patches {
+			docs = append(docs, patchDocument(&s.Patches[i]))
+		}
+	default:
+		for _, u := range s.Manifests {
+			docs = append(docs, u.Object)
+		}
+	}
+
+	for i, doc := range docs {
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			_, _ = fmt.Fprintln(o.Out, "---")
+		}
+		_, _ = o.Out.Write(b)
+	}
+	return nil
+}
+
+// read returns the encoded snapshot from a file or from the location recorded on the trial
+func (o *SnapshotOptions) read(ctx context.Context) ([]byte, error) {
+	if o.Filename != "" {
+		return ioutil.ReadFile(o.Filename)
+	}
+	if o.TrialName == "" {
+		return nil, fmt.Errorf("a trial name or snapshot file is required")
+	}
+
+	t := &optimizev1beta2.Trial{}
+	if err := kubectlGet(ctx, o.Config, t, "trial", o.TrialName); err != nil {
+		return nil, err
+	}
+
+	if t.Status.Snapshot == "" {
+		return nil, fmt.Errorf("no snapshot available for trial %q", o.TrialName)
+	}
+
+	namespace, name, ok := snapshot.ParseConfigMapLocation(t.Status.Snapshot)
+	if !ok {
+		return nil, fmt.Errorf("unsupported snapshot location %q", t.Status.Snapshot)
+	}
+	if namespace == "" {
+		// Older snapshots were stored next to the trial
+		namespace = t.Namespace
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := kubectlGet(ctx, o.Config, cm, "configmap", name, "--namespace", namespace); err != nil {
+		return nil, err
+	}
+	return cm.BinaryData[snapshot.ConfigMapKey], nil
+}
+
+// patchDocument returns a readable representation of a patch operation
+func patchDocument(po *optimizev1beta2.PatchOperation) interface{} {
+	var data interface{}
+	if err := yaml.Unmarshal(po.Data, &data); err != nil {
+		data = string(po.Data)
+	}
+	return map[string]interface{}{
+		"targetRef": po.TargetRef,
+		"patchType": po.PatchType,
+		"data":      data,
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// TrialDiagnosticsOptions configure the display of archived trial diagnostics.
+type TrialDiagnosticsOptions struct {
+	Config *config.OptimizeConfig
+	commander.IOStreams
+
+	TrialName string
+}
+
+// NewTrialDiagnosticsCommand creates a command for displaying the diagnostics of a failed trial.
+func NewTrialDiagnosticsCommand(o *TrialDiagnosticsOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trial NAME",
+		Short: "Show failed trial diagnostics",
+		Long:  "Show the container logs and events captured when a trial failed, use --namespace for trials outside the current namespace",
+		Args:  cobra.ExactArgs(1),
+
+		PreRun: commander.StreamsPreRun(&o.IOStreams),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.TrialName = args[0]
+			return o.Debug(cmd.Context())
+		},
+	}
+
+	return cmd
+}
+
+// Debug prints the diagnostics archived for the trial.
+func (o *TrialDiagnosticsOptions) Debug(ctx context.Context) error {
+	t := &optimizev1beta2.Trial{}
+	if err := kubectlGet(ctx, o.Config, t, "trial", o.TrialName); err != nil {
+		return err
+	}
+	if t.Status.Diagnostics == nil {
+		return fmt.Errorf("no diagnostics available for trial %q", o.TrialName)
+	}
+
+	// The diagnostics are always archived in the namespace of the trial
+	cm := &corev1.ConfigMap{}
+	if err := kubectlGet(ctx, o.Config, cm, "configmap", t.Status.Diagnostics.Name, "--namespace", t.Namespace); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(cm.Data))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(o.Out, "==> %s <==\n%s\n", k, cm.Data[k])
+	}
+	return nil
+}
+
+// kubectlGet uses kubectl to fetch a single object, additional arguments (e.g. the namespace) take precedence over
+// the values from the configuration
+func kubectlGet(ctx context.Context, cfg *config.OptimizeConfig, obj interface{}, kind, name string, arg ...string) error {
+	get, err := cfg.Kubectl(ctx, append([]string{"get", kind, name, "--output", "yaml"}, arg...)...)
+	if err != nil {
+		return err
+	}
+	output, err := get.Output()
+	if err != nil {
+		return fmt.Errorf("could not get %s %q: %w", kind, name, err)
+	}
+	return yaml.Unmarshal(output, obj)
+}
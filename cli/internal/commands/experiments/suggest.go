@@ -20,7 +20,9 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"math"
 	"math/rand"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -50,6 +52,10 @@ type SuggestOptions struct {
 	DefaultBehavior  string
 	Labels           string
 	Baselines        map[string]*numstr.NumberOrString
+	// LogScale identifies parameters whose server values are the base 10 logarithm of the assigned value
+	LogScale map[string]bool
+	// Steps identifies integer parameters whose values must be a multiple of the step
+	Steps map[string]int64
 }
 
 // NewSuggestCommand creates a new suggestion command
@@ -125,7 +131,7 @@ func (o *SuggestOptions) SuggestAssignments(exp *experimentsv1alpha1.Experiment,
 func (o *SuggestOptions) assign(p *experimentsv1alpha1.Parameter) (*numstr.NumberOrString, error) {
 	// Look for explicit assignments
 	if a, ok := o.Assignments[p.Name]; ok {
-		return checkValue(p, a)
+		return o.checkValue(p, a)
 	}
 
 	// Compute a default value (may be needed for interactive prompt)
@@ -172,7 +178,7 @@ func (o *SuggestOptions) defaultValue(p *experimentsv1alpha1.Parameter) (*numstr
 	case DefaultMaximum, "maximum":
 		return p.UpperBound()
 	case DefaultRandom, "random":
-		return randomValue(p)
+		return randomValue(p, o.Steps[p.Name])
 	case DefaultBaseline, "baseline":
 		return o.Baselines[p.Name], nil
 	default:
@@ -181,7 +187,7 @@ func (o *SuggestOptions) defaultValue(p *experimentsv1alpha1.Parameter) (*numstr
 }
 
 func (o *SuggestOptions) assignInteractive(p *experimentsv1alpha1.Parameter, def *numstr.NumberOrString) (*numstr.NumberOrString, error) {
-	_, _ = fmt.Fprint(o.ErrOut, prompt(p, def))
+	_, _ = fmt.Fprint(o.ErrOut, prompt(p, def, o.LogScale[p.Name], o.Steps[p.Name]))
 	s := bufio.NewScanner(o.In)
 	for attempts := 0; attempts < 3; attempts++ {
 		if attempts > 0 {
@@ -194,7 +200,7 @@ func (o *SuggestOptions) assignInteractive(p *experimentsv1alpha1.Parameter, def
 		if text == "" && def != nil {
 			return def, nil
 		}
-		result, err := checkValue(p, text)
+		result, err := o.checkValue(p, text)
 		if err != nil {
 			continue
 		}
@@ -207,19 +213,26 @@ func (o *SuggestOptions) assignInteractive(p *experimentsv1alpha1.Parameter, def
 	return nil, fmt.Errorf("no assignment for parameter: %s", p.Name)
 }
 
-func prompt(p *experimentsv1alpha1.Parameter, def *numstr.NumberOrString) string {
+func prompt(p *experimentsv1alpha1.Parameter, def *numstr.NumberOrString, logScale bool, step int64) string {
 	var b strings.Builder
 	b.WriteString(fmt.Sprintf("Assignment for %v parameter '%s'", p.Type, p.Name))
 
 	// Add the bounds
 	if p.Type == experimentsv1alpha1.ParameterTypeCategorical {
 		b.WriteString(fmt.Sprintf(" [%s]", strings.Join(p.Values, ", ")))
+	} else if p.Bounds != nil && logScale {
+		min, max, _ := floatBounds(p.Bounds)
+		b.WriteString(fmt.Sprintf(" [%s,%s] (log scale)", formatExp10(min), formatExp10(max)))
+	} else if p.Bounds != nil && step > 1 {
+		b.WriteString(fmt.Sprintf(" [%v,%v] (step %d)", p.Bounds.Min, p.Bounds.Max, step))
 	} else if p.Bounds != nil {
 		b.WriteString(fmt.Sprintf(" [%v,%v]", p.Bounds.Min, p.Bounds.Max))
 	}
 
 	// Add the default
-	if def != nil {
+	if def != nil && logScale {
+		b.WriteString(fmt.Sprintf(" (%s)", formatExp10(def.Float64Value())))
+	} else if def != nil {
 		b.WriteString(fmt.Sprintf(" (%s)", def.String()))
 	}
 
@@ -227,7 +240,19 @@ func prompt(p *experimentsv1alpha1.Parameter, def *numstr.NumberOrString) string
 	return b.String()
 }
 
-func checkValue(p *experimentsv1alpha1.Parameter, s string) (*numstr.NumberOrString, error) {
+func (o *SuggestOptions) checkValue(p *experimentsv1alpha1.Parameter, s string) (*numstr.NumberOrString, error) {
+	// Log scale values are entered as is, but the server works with the logarithm
+	if o.LogScale[p.Name] {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		if f <= 0 {
+			return nil, fmt.Errorf("log scale value must be positive: %s", s)
+		}
+		s = strconv.FormatFloat(math.Log10(f), 'f', -1, 64)
+	}
+
 	v, err := p.ParseValue(s)
 	if err != nil {
 		return nil, err
@@ -236,24 +261,34 @@ func checkValue(p *experimentsv1alpha1.Parameter, s string) (*numstr.NumberOrStr
 	if err != nil {
 		return nil, err
 	}
+	if step := o.Steps[p.Name]; step > 1 && v.Int64Value()%step != 0 {
+		return nil, fmt.Errorf("value must be a multiple of %d: %s", step, s)
+	}
 	return v, nil
 }
 
-func randomValue(p *experimentsv1alpha1.Parameter) (*numstr.NumberOrString, error) {
+func randomValue(p *experimentsv1alpha1.Parameter, step int64) (*numstr.NumberOrString, error) {
 	switch p.Type {
 	case experimentsv1alpha1.ParameterTypeInteger:
 		min, max, err := intBounds(p.Bounds)
 		if err != nil {
 			return nil, err
 		}
-		r := numstr.FromInt64(rand.Int63n(max-min) + min)
+		if step < 1 {
+			step = 1
+		}
+		min, max = ceilMultiple(min, step), floorMultiple(max, step)
+		if min > max {
+			return nil, fmt.Errorf("no multiple of %d in range for parameter: %s", step, p.Name)
+		}
+		r := numstr.FromInt64(min + rand.Int63n((max-min)/step+1)*step)
 		return &r, nil
 	case experimentsv1alpha1.ParameterTypeDouble:
 		min, max, err := floatBounds(p.Bounds)
 		if err != nil {
 			return nil, err
 		}
-		r := numstr.FromFloat64(rand.Float64()*max + min)
+		r := numstr.FromFloat64(min + rand.Float64()*(max-min))
 		return &r, nil
 	case experimentsv1alpha1.ParameterTypeCategorical:
 		r := numstr.FromString(p.Values[rand.Intn(len(p.Values))])
@@ -285,3 +320,22 @@ func floatBounds(b *experimentsv1alpha1.Bounds) (float64, float64, error) {
 	}
 	return min, max, err
 }
+
+// floorMultiple returns the largest multiple of the step less than or equal to the value
+func floorMultiple(v, step int64) int64 {
+	m := v % step
+	if m < 0 {
+		m += step
+	}
+	return v - m
+}
+
+// ceilMultiple returns the smallest multiple of the step greater than or equal to the value
+func ceilMultiple(v, step int64) int64 {
+	return -floorMultiple(-v, step)
+}
+
+// formatExp10 formats the value of a log scale parameter
+func formatExp10(v float64) string {
+	return strconv.FormatFloat(math.Pow(10, v), 'g', -1, 64)
+}
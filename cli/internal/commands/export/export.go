@@ -306,7 +306,7 @@ func (o *Options) runner(ctx context.Context) error {
 
 	trial := &optimizev1beta2.Trial{}
 	experiment.PopulateTrialFromTemplate(o.experiment, trial)
-	server.ToClusterTrial(trial, trialDetails.Assignments)
+	server.ToClusterTrial(trial, o.experiment.Spec.Parameters, trialDetails.Assignments)
 
 	// render patches
 	patches, err := createKustomizePatches(o.experiment.Spec.Patches, trial)
@@ -283,6 +283,27 @@ func (o *RBACOptions) appendRules(rules []*rbacv1.PolicyRule, exp *optimizev1bet
 		}
 	}
 
+	// Namespace templates require "create" and "delete" permissions on namespaces, cloning requires "list" and
+	// "create" permissions on the cloned resources and "deletecollection" to remove the cloned deployments
+	if nt := exp.Spec.NamespaceTemplate; nt != nil {
+		rules = append(rules, o.newPolicyRule(&corev1.ObjectReference{Kind: "Namespace", APIVersion: "v1"}, "create", "delete"))
+		if nt.Clone != nil {
+			rules = append(rules, o.newPolicyRule(&corev1.ObjectReference{Kind: "ConfigMap", APIVersion: "v1"}, "list", "create"))
+			rules = append(rules, o.newPolicyRule(&corev1.ObjectReference{Kind: "Service", APIVersion: "v1"}, "list", "create"))
+			rules = append(rules, o.newPolicyRule(&corev1.ObjectReference{Kind: "Deployment", APIVersion: "apps/v1"}, "list", "create", "deletecollection"))
+			if nt.Clone.Secrets {
+				rules = append(rules, o.newPolicyRule(&corev1.ObjectReference{Kind: "Secret", APIVersion: "v1"}, "list", "create"))
+			}
+		}
+	}
+
+	// Setup task outputs secrets require "get" permissions
+	for i := range exp.Spec.TrialTemplate.Spec.SetupTasks {
+		if ref := exp.Spec.TrialTemplate.Spec.SetupTasks[i].OutputsSecret; ref != nil {
+			rules = append(rules, o.newPolicyRule(&corev1.ObjectReference{Kind: "Secret", APIVersion: "v1", Name: ref.Name}, "get"))
+		}
+	}
+
 	// Readiness gates with no name require "list" permissions
 	for i := range exp.Spec.TrialTemplate.Spec.ReadinessGates {
 		rg := &exp.Spec.TrialTemplate.Spec.ReadinessGates[i]
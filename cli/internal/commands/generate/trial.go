@@ -110,6 +110,20 @@ func (o *TrialOptions) generate() error {
 			o.Baselines[a.ParameterName] = &a.Value
 		}
 	}
+	for _, p := range exp.Spec.Parameters {
+		if p.Float != nil && p.Float.LogScale {
+			if o.LogScale == nil {
+				o.LogScale = make(map[string]bool)
+			}
+			o.LogScale[p.Name] = true
+		}
+		if p.Step > 1 {
+			if o.Steps == nil {
+				o.Steps = make(map[string]int64)
+			}
+			o.Steps[p.Name] = int64(p.Step)
+		}
+	}
 	ta := experimentsv1alpha1.TrialAssignments{}
 	if err := o.SuggestAssignments(serverExperiment, &ta); err != nil {
 		return err
@@ -121,7 +135,7 @@ func (o *TrialOptions) generate() error {
 	// Build the trial
 	t := &optimizev1beta2.Trial{}
 	experiment.PopulateTrialFromTemplate(exp, t)
-	server.ToClusterTrial(t, &ta)
+	server.ToClusterTrial(t, exp.Spec.Parameters, &ta)
 
 	// NOTE: Leaving the trial name empty and generateName non-empty means that you MUST use `kubectl create` and not `apply`
 
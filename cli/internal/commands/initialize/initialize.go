@@ -71,7 +71,7 @@ func (o *Options) Initialize(ctx context.Context) error {
 
 	// Run `kubectl wait` to ensure the CRD is installed
 	if o.Wait {
-		kubectlWait, err := o.Config.Kubectl(ctx, "wait", "crd/experiments.optimize.stormforge.io", "crd/trials.optimize.stormforge.io", "--for", "condition=Established")
+		kubectlWait, err := o.Config.Kubectl(ctx, "wait", "crd/experiments.optimize.stormforge.io", "crd/trials.optimize.stormforge.io", "crd/recommendations.optimize.stormforge.io", "crd/experimenttemplates.optimize.stormforge.io", "crd/cronexperiments.optimize.stormforge.io", "--for", "condition=Established")
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,70 @@
+#!/bin/sh
+set -e
+
+# Runs the Helm CLI directly for setup tasks which cannot be expressed as a Konjure Helm generator
+# Usage: helm.sh (create|delete) [HELM_FLAGS...]
+
+mode="$1"
+shift
+
+namespace="${HELM_RELEASE_NAMESPACE:-$NAMESPACE}"
+if [ -z "$HELM_RELEASE_NAME" ] || [ -z "$namespace" ]; then
+    echo "missing Helm release name or namespace"
+    exit 1
+fi
+
+
+case "$mode" in
+  create)
+    chart="$HELM_CHART"
+
+    # The release is installed by the first trial and upgraded in place by later trials when upgrading, an atomic
+    # upgrade rolls back to the previous release on failure
+    set -- "$@" --install --create-namespace --atomic --wait
+
+    # OCI charts must be pulled and exported before they can be installed
+    case "$HELM_CHART" in
+      oci://*)
+        export HELM_EXPERIMENTAL_OCI=1
+        ref="${HELM_CHART#oci://}:${HELM_CHART_VERSION:-latest}"
+        helm chart pull "$ref"
+        helm chart export "$ref" --destination /tmp/charts
+        chart="/tmp/charts/$(basename "${HELM_CHART#oci://}")"
+        ;;
+      *)
+        if [ -n "$HELM_REPOSITORY" ]; then
+          set -- "$@" --repo "$HELM_REPOSITORY"
+        fi
+        if [ -n "$HELM_CHART_VERSION" ]; then
+          set -- "$@" --version "$HELM_CHART_VERSION"
+        fi
+        ;;
+    esac
+
+    # Values files are mounted as globs, expand them here
+    for f in $HELM_VALUES_FILES; do
+      if [ -f "$f" ]; then
+        set -- "$@" --values "$f"
+      fi
+    done
+
+    # Inline values are always applied last
+    if [ -n "$HELM_VALUES" ]; then
+      echo "$HELM_VALUES" | base64 -d > /tmp/helm-values.yaml
+      set -- "$@" --values /tmp/helm-values.yaml
+    fi
+
+    helm upgrade "$HELM_RELEASE_NAME" "$chart" --namespace "$namespace" "$@"
+    ;;
+  delete)
+    if [ "$HELM_UPGRADE" = "true" ]; then
+      echo "not deleting upgraded release $HELM_RELEASE_NAME"
+      exit 0
+    fi
+    helm uninstall "$HELM_RELEASE_NAME" --namespace "$namespace"
+    ;;
+  *)
+    echo "unknown mode: $mode"
+    exit 1
+    ;;
+esac
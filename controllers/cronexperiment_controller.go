@@ -0,0 +1,198 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// CronExperimentReconciler reconciles a CronExperiment object
+type CronExperimentReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=cronexperiments,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experimenttemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch;create;delete
+
+// Reconcile creates new experiments from a template on a recurring schedule
+func (r *CronExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("cronExperiment", req.NamespacedName)
+	now := time.Now()
+
+	ce := &optimizev1beta2.CronExperiment{}
+	if err := r.Get(ctx, req.NamespacedName, ce); err != nil || !ce.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	// Experiment names are derived from the cron experiment name, do not create experiments with invalid names
+	if err := experiment.CheckName(ce); err != nil {
+		log.Error(err, "Invalid cron experiment")
+		return ctrl.Result{}, nil
+	}
+	status := ce.Status.DeepCopy()
+
+	active, err := r.cleanupHistory(ctx, log, ce)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var result ctrl.Result
+	if ce.Spec.Suspend == nil || !*ce.Spec.Suspend {
+		scheduledTime, nextTime, err := experiment.ScheduledTimes(ce, now)
+		if errors.Is(err, experiment.ErrTooManyMissedSchedules) {
+			// Skip the missed schedules instead of walking all of them again on every reconcile
+			log.Error(err, "Skipping missed schedules")
+			ce.Status.LastScheduleTime = &metav1.Time{Time: now}
+			scheduledTime, nextTime, err = experiment.ScheduledTimes(ce, now)
+		}
+		if err != nil {
+			// There is nothing to retry until the schedule is fixed
+			log.Error(err, "Invalid schedule")
+			return ctrl.Result{}, nil
+		}
+
+		if !scheduledTime.IsZero() {
+			if err := r.createExperiment(ctx, log, ce, active, scheduledTime); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		if !nextTime.IsZero() {
+			result.RequeueAfter = nextTime.Sub(now)
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(status, &ce.Status) {
+		if err := r.Update(ctx, ce); err != nil {
+			result, err := controller.RequeueConflict(err)
+			return *result, err
+		}
+	}
+
+	return result, nil
+}
+
+// SetupWithManager registers a new cron experiment reconciler with the supplied manager
+func (r *CronExperimentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("cronexperiment").
+		For(&optimizev1beta2.CronExperiment{}).
+		Owns(&optimizev1beta2.Experiment{}).
+		Complete(r)
+}
+
+// cleanupHistory records the active experiments and deletes the finished experiments exceeding the history limits
+func (r *CronExperimentReconciler) cleanupHistory(ctx context.Context, log logr.Logger, ce *optimizev1beta2.CronExperiment) (int, error) {
+	expList := &optimizev1beta2.ExperimentList{}
+	if err := r.List(ctx, expList, client.InNamespace(ce.Namespace), client.MatchingLabels{optimizev1beta2.LabelCronExperiment: ce.Name}); err != nil {
+		return 0, err
+	}
+
+	active, successful, failed := experiment.CronExperimentRuns(expList.Items)
+
+	ce.Status.Active = nil
+	for _, exp := range active {
+		ce.Status.Active = append(ce.Status.Active, experimentReference(exp))
+	}
+
+	for _, exp := range experiment.ExpiredRuns(ce, successful, failed) {
+		if err := r.Delete(ctx, exp, client.PropagationPolicy(metav1.DeletePropagationBackground)); controller.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+		log.Info("Deleted experiment exceeding the history limit", "experiment", exp.Name)
+	}
+
+	return len(active), nil
+}
+
+// createExperiment creates a new experiment from the template for the scheduled time
+func (r *CronExperimentReconciler) createExperiment(ctx context.Context, log logr.Logger, ce *optimizev1beta2.CronExperiment, active int, scheduledTime time.Time) error {
+	// Skip the scheduled run if the previous experiment is still going
+	if active > 0 {
+		log.Info("Skipping scheduled experiment, a previous experiment is still active", "scheduledTime", scheduledTime)
+		ce.Status.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+		return nil
+	}
+
+	tmpl := &optimizev1beta2.ExperimentTemplate{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ce.Namespace, Name: ce.Spec.TemplateRef.Name}, tmpl); err != nil {
+		return err
+	}
+
+	exp, err := experiment.NewFromTemplate(tmpl, ce.Spec.Variables)
+	if err != nil {
+		return err
+	}
+
+	exp.Name = experiment.RunName(ce, scheduledTime)
+	exp.GenerateName = ""
+	exp.Namespace = ce.Namespace
+	if exp.Labels == nil {
+		exp.Labels = make(map[string]string, 1)
+	}
+	exp.Labels[optimizev1beta2.LabelCronExperiment] = ce.Name
+	if ce.Status.LastExperimentName != "" {
+		if exp.Annotations == nil {
+			exp.Annotations = make(map[string]string, 1)
+		}
+		exp.Annotations[optimizev1beta2.AnnotationPreviousExperiment] = ce.Status.LastExperimentName
+	}
+
+	if err := controllerutil.SetControllerReference(ce, exp, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, exp); controller.IgnoreAlreadyExists(err) != nil {
+		return err
+	}
+
+	log.Info("Created scheduled experiment", "experiment", exp.Name, "scheduledTime", scheduledTime)
+	ce.Status.Active = append(ce.Status.Active, experimentReference(exp))
+	ce.Status.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+	ce.Status.LastExperimentName = exp.Name
+	return nil
+}
+
+// experimentReference returns an object reference to the supplied experiment
+func experimentReference(exp *optimizev1beta2.Experiment) corev1.ObjectReference {
+	return corev1.ObjectReference{
+		Kind:       "Experiment",
+		APIVersion: optimizev1beta2.GroupVersion.String(),
+		Namespace:  exp.Namespace,
+		Name:       exp.Name,
+		UID:        exp.UID,
+	}
+}
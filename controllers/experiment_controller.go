@@ -42,6 +42,7 @@ type ExperimentReconciler struct {
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments;experiments/finalizers,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list;watch;update;delete
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=recommendations,verbs=get;list;watch
 
 func (r *ExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -68,6 +69,10 @@ func (r *ExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return *result, err
 	}
 
+	if err := experiment.CleanupNamespaces(ctx, r, exp, trialList); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -76,9 +81,18 @@ func (r *ExperimentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Named("experiment").
 		For(&optimizev1beta2.Experiment{}).
 		Watches(&source.Kind{Type: &optimizev1beta2.Trial{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(trialToExperimentRequest)}).
+		Watches(&source.Kind{Type: &optimizev1beta2.Recommendation{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(recommendationToExperimentRequest)}).
 		Complete(r)
 }
 
+// recommendationToExperimentRequest extracts the reconcile request for an experiment of a recommendation
+func recommendationToExperimentRequest(o handler.MapObject) []reconcile.Request {
+	if rec, ok := o.Object.(*optimizev1beta2.Recommendation); ok {
+		return []reconcile.Request{{NamespacedName: rec.ExperimentNamespacedName()}}
+	}
+	return nil
+}
+
 // trialToExperimentRequest extracts the reconcile request for an experiment of a trial
 func trialToExperimentRequest(o handler.MapObject) []reconcile.Request {
 	if t, ok := o.Object.(*optimizev1beta2.Trial); ok {
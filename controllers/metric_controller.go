@@ -169,12 +169,12 @@ func (r *MetricReconciler) collectMetrics(ctx context.Context, t *optimizev1beta
 
 		// Apply defaults to our local copy of the metric definition
 		m := metrics[v.Name]
-		if err := r.applyMetricDefaults(ctx, t, m); err != nil {
+		if err := applyMetricDefaults(t, m); err != nil {
 			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
 		}
 
 		// Do any Kube API lookups while we have the API client
-		target, err := r.target(ctx, t, m)
+		target, err := metricTarget(ctx, r, t, m)
 		if err != nil {
 			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
 		}
@@ -200,7 +200,7 @@ func (r *MetricReconciler) collectMetrics(ctx context.Context, t *optimizev1beta
 		for i := range t.Spec.Values {
 			v := &t.Spec.Values[i]
 			if err := validation.CheckMetricBounds(metrics[v.Name], v); err != nil {
-				trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "MetricBound", err.Error(), probeTime)
+				trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfeasible, "MetricBound", err.Error(), probeTime)
 				err := r.Update(ctx, t)
 				return controller.RequeueConflict(err)
 			}
@@ -231,7 +231,7 @@ func (r *MetricReconciler) collectionAttempt(ctx context.Context, log logr.Logge
 
 	// Fail the trial if there is an error and no attempts are left
 	if err != nil && v.AttemptsRemaining == 0 {
-		trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "MetricFailed", err.Error(), probeTime)
+		trial.ApplyFailure(&t.Status, optimizev1beta2.FailureMeasurement, "MetricFailed", err.Error(), probeTime)
 
 		// Metric errors contain additional information which should be logged for debugging
 		if merr, ok := err.(*metric.CaptureError); ok {
@@ -243,8 +243,8 @@ func (r *MetricReconciler) collectionAttempt(ctx context.Context, log logr.Logge
 	return controller.RequeueConflict(r.Update(ctx, t))
 }
 
-// target looks up the Kubernetes object (if any) associated with a metric.
-func (r *MetricReconciler) target(ctx context.Context, t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) (runtime.Object, error) {
+// metricTarget looks up the Kubernetes object (if any) associated with a metric.
+func metricTarget(ctx context.Context, r client.Reader, t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) (runtime.Object, error) {
 	if m.Type != optimizev1beta2.MetricKubernetes && m.Type != "" {
 		return nil, nil
 	}
@@ -286,7 +286,7 @@ func (r *MetricReconciler) target(ctx context.Context, t *optimizev1beta2.Trial,
 }
 
 // applyMetricDefaults fills in default values for the supplied metric.
-func (r *MetricReconciler) applyMetricDefaults(ctx context.Context, t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) error {
+func applyMetricDefaults(t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) error {
 	// Give Prometheus metrics a default URL
 	if m.Type == optimizev1beta2.MetricPrometheus && m.URL == "" {
 		m.URL = fmt.Sprintf("http://optimize-%[1]s-prometheus.%[1]s:9090/", t.Namespace)
@@ -18,6 +18,8 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"sort"
 
 	"github.com/go-logr/logr"
@@ -25,6 +27,7 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
 	"github.com/thestormforge/optimize-controller/v2/internal/patch"
 	"github.com/thestormforge/optimize-controller/v2/internal/ready"
+	"github.com/thestormforge/optimize-controller/v2/internal/snapshot"
 	"github.com/thestormforge/optimize-controller/v2/internal/template"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	"github.com/thestormforge/optimize-controller/v2/internal/validation"
@@ -41,10 +44,13 @@ type PatchReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	snapshots snapshot.Sink
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
 
 // Reconcile inspects a trial to see if patches need to be applied. The "trial patched" status condition
 // is used to control what actions need to be taken. If the status is "unknown" then the experiment is fetched
@@ -73,6 +79,13 @@ func (r *PatchReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 // SetupWithManager registers a new patch reconciler with the supplied manager
 func (r *PatchReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Snapshots are stored in config maps unless another sink is configured
+	snapshots, err := snapshot.NewSink(os.Getenv("STORMFORGE_SNAPSHOT_SINK"), mgr.GetClient())
+	if err != nil {
+		return err
+	}
+	r.snapshots = snapshots
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("patch").
 		For(&optimizev1beta2.Trial{}).
@@ -198,7 +211,7 @@ func (r *PatchReconciler) applyPatches(ctx context.Context, t *optimizev1beta2.T
 			p.AttemptsRemaining = p.AttemptsRemaining - 1
 			if p.AttemptsRemaining == 0 {
 				// There are no remaining patch attempts remaining, fail the trial
-				trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "PatchFailed", err.Error(), probeTime)
+				trial.ApplyFailure(&t.Status, trial.PatchFailureCategory(err), "PatchFailed", err.Error(), probeTime)
 			}
 		} else {
 			p.AttemptsRemaining = 0
@@ -210,11 +223,31 @@ func (r *PatchReconciler) applyPatches(ctx context.Context, t *optimizev1beta2.T
 	}
 
 	// We made it through all of the patches without needing additional changes
+	r.saveSnapshot(ctx, t)
 	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialPatched, corev1.ConditionTrue, "", "", probeTime)
 	err := r.Update(ctx, t)
 	return controller.RequeueConflict(err)
 }
 
+// saveSnapshot records the state applied to the cluster for the trial; failures are logged but otherwise ignored
+func (r *PatchReconciler) saveSnapshot(ctx context.Context, t *optimizev1beta2.Trial) {
+	if r.snapshots == nil {
+		return
+	}
+
+	s, err := snapshot.Capture(ctx, r, t)
+	var data []byte
+	if err == nil {
+		data, err = snapshot.Encode(s)
+	}
+	if err == nil {
+		t.Status.Snapshot, err = r.snapshots.Save(ctx, t, data)
+	}
+	if err != nil {
+		r.Log.WithValues("trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name)).Error(err, "Failed to save trial snapshot")
+	}
+}
+
 // createReadinessCheck creates a readiness check for a patch operation
 func (r *PatchReconciler) createReadinessCheck(t *optimizev1beta2.Trial, ref *corev1.ObjectReference, readinessGates []optimizev1beta2.PatchReadinessGate) (*optimizev1beta2.ReadinessCheck, error) {
 	// Do not create a readiness check on the trial job or if there is already an explicit readiness gate
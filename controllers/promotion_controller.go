@@ -0,0 +1,313 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/ready"
+	"github.com/thestormforge/optimize-controller/v2/internal/recommendation"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PromotionReconciler applies approved recommendations to their promotion target
+type PromotionReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Use the raw API reader for the objects being promoted and the secrets containing the target cluster
+	// configuration so we do not need list/watch permissions on them, see the ReadyReconciler for details.
+	apiReader client.Reader
+
+	// The HTTP client used for HTTP readiness checks
+	httpClient *http.Client
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=recommendations,verbs=get;list;watch;update
+
+// Reconcile promotes an approved recommendation, rolling back the changes if the patched objects do not become ready
+func (r *PromotionReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("recommendation", req.NamespacedName)
+	now := metav1.Now()
+
+	rec := &optimizev1beta2.Recommendation{}
+	if err := r.Get(ctx, req.NamespacedName, rec); err != nil || r.ignoreRecommendation(rec) {
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	if result, err := r.approve(ctx, log, rec, &now); result != nil {
+		return *result, err
+	}
+
+	tc, tr, err := r.target(ctx, rec)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if result, err := r.applyChanges(ctx, log, tc, tr, rec, &now); result != nil {
+		return *result, err
+	}
+
+	if result, err := r.checkReadiness(ctx, log, tc, tr, rec, &now); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers a new promotion reconciler with the supplied manager
+func (r *PromotionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.apiReader = mgr.GetAPIReader()
+	r.httpClient = &http.Client{Timeout: 10 * time.Second}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("promotion").
+		For(&optimizev1beta2.Recommendation{}).
+		Complete(r)
+}
+
+// ignoreRecommendation determines which recommendation objects can be ignored by this reconciler
+func (r *PromotionReconciler) ignoreRecommendation(rec *optimizev1beta2.Recommendation) bool {
+	// Ignore deleted recommendations
+	if !rec.DeletionTimestamp.IsZero() {
+		return true
+	}
+
+	// Ignore recommendations that have not been produced yet
+	if !recommendation.IsPopulated(rec) {
+		return true
+	}
+
+	// Ignore recommendations which are already promoted or rolled back
+	if recommendation.CheckCondition(&rec.Status, optimizev1beta2.RecommendationPromoted, corev1.ConditionTrue) ||
+		recommendation.CheckCondition(&rec.Status, optimizev1beta2.RecommendationRolledBack, corev1.ConditionTrue) {
+		return true
+	}
+
+	// Reconcile everything else
+	return false
+}
+
+// approve records the approval of the recommendation
+func (r *PromotionReconciler) approve(ctx context.Context, log logr.Logger, rec *optimizev1beta2.Recommendation, probeTime *metav1.Time) (*ctrl.Result, error) {
+	// Only record the approval once
+	if rec.Status.Promotion != nil {
+		return nil, nil
+	}
+
+	// Do nothing until the recommendation is approved
+	approvedBy, ok := recommendation.ApprovedBy(rec)
+	if !ok {
+		return &ctrl.Result{}, nil
+	}
+
+	rec.Status.Promotion = &optimizev1beta2.PromotionStatus{
+		ApprovedBy:   approvedBy,
+		ApprovedTime: probeTime,
+	}
+	recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationApproved, corev1.ConditionTrue, "Approved", approvedBy, probeTime)
+	recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationPromoted, corev1.ConditionFalse, "Promoting", "", probeTime)
+	recommendation.UpdateStatus(rec)
+
+	log.Info("Recommendation approved", "approvedBy", approvedBy)
+	err := r.Update(ctx, rec)
+	return controller.RequeueConflict(err)
+}
+
+// applyChanges applies the next recommended patch to the promotion target, recording the original state of the
+// object before it is patched so the change can be rolled back
+func (r *PromotionReconciler) applyChanges(ctx context.Context, log logr.Logger, tc client.Client, tr client.Reader, rec *optimizev1beta2.Recommendation, probeTime *metav1.Time) (*ctrl.Result, error) {
+	// Apply the recorded change, it is safe to apply the same patch more than once
+	if pc := recommendation.PendingChange(rec); pc != nil {
+		u := &unstructured.Unstructured{}
+		u.SetName(pc.TargetRef.Name)
+		u.SetNamespace(pc.TargetRef.Namespace)
+		u.SetGroupVersionKind(pc.TargetRef.GroupVersionKind())
+		if err := tc.Patch(ctx, u, client.RawPatch(pc.PatchType, []byte(pc.Data))); err != nil {
+			return r.rollback(ctx, log, tc, tr, rec, "PatchFailed", err.Error(), probeTime)
+		}
+
+		pc.Applied = true
+		if rc := recommendation.NewReadinessCheck(rec, &pc.TargetRef); rc != nil {
+			rec.Status.Promotion.ReadinessChecks = append(rec.Status.Promotion.ReadinessChecks, *rc)
+		}
+
+		log.Info("Applied recommended patch", "target", pc.TargetRef)
+		err := r.Update(ctx, rec)
+		return controller.RequeueConflict(err)
+	}
+
+	pc := recommendation.NextChange(rec)
+	if pc == nil {
+		return nil, nil
+	}
+
+	if err := recommendation.CheckTarget(rec, &pc.TargetRef); err != nil {
+		return r.rollback(ctx, log, tc, tr, rec, "InvalidTarget", err.Error(), probeTime)
+	}
+
+	// Capture the state of the object before it is patched so it can be restored
+	// RBAC: Just like trial patches, we assume "get" and "patch" permissions from a customer defined role
+	original := &unstructured.Unstructured{}
+	original.SetGroupVersionKind(pc.TargetRef.GroupVersionKind())
+	if err := tr.Get(ctx, types.NamespacedName{Namespace: pc.TargetRef.Namespace, Name: pc.TargetRef.Name}, original); err != nil {
+		return r.rollback(ctx, log, tc, tr, rec, "PatchFailed", err.Error(), probeTime)
+	}
+
+	content, err := recommendation.OriginalContent(original)
+	if err != nil {
+		return nil, err
+	}
+	pc.Original = content
+
+	rec.Status.Promotion.Changes = append(rec.Status.Promotion.Changes, *pc)
+	err = r.Update(ctx, rec)
+	return controller.RequeueConflict(err)
+}
+
+// checkReadiness evaluates the readiness checks of the patched objects
+func (r *PromotionReconciler) checkReadiness(ctx context.Context, log logr.Logger, tc client.Client, tr client.Reader, rec *optimizev1beta2.Recommendation, probeTime *metav1.Time) (*ctrl.Result, error) {
+	checker := &readinessChecker{
+		checker: ready.ReadinessChecker{Reader: tr, HTTPClient: r.httpClient},
+		epoch:   *rec.Status.Promotion.ApprovedTime,
+		ready:   true,
+		requeue: true,
+	}
+	for i := range rec.Status.Promotion.ReadinessChecks {
+		c := &rec.Status.Promotion.ReadinessChecks[i]
+		if checker.skipCheck(c, probeTime) {
+			continue
+		}
+
+		ul, err := getCheckTargets(ctx, tr, c)
+		if err != nil {
+			return r.rollback(ctx, log, tc, tr, rec, "ReadinessCheckFailed", err.Error(), probeTime)
+		}
+
+		if msg, isReady, err := checker.check(ctx, c, ul, probeTime); err != nil {
+			reason, message := "ReadinessCheckFailed", err.Error()
+			if rerr, ok := err.(*ready.ReadinessError); ok {
+				if rerr.Reason != "" {
+					reason = rerr.Reason
+				}
+				if rerr.Message != "" {
+					message = rerr.Message
+				}
+			}
+			return r.rollback(ctx, log, tc, tr, rec, reason, message, probeTime)
+		} else if !isReady {
+			recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationPromoted, corev1.ConditionFalse, "Waiting", msg, probeTime)
+		}
+	}
+
+	if checker.requeue && checker.after > 0 {
+		return &ctrl.Result{RequeueAfter: checker.after}, nil
+	}
+
+	if checker.ready {
+		log.Info("Recommendation promoted")
+		recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationPromoted, corev1.ConditionTrue, "Promoted", "", probeTime)
+		recommendation.UpdateStatus(rec)
+	}
+	err := r.Update(ctx, rec)
+	return controller.RequeueConflict(err)
+}
+
+// rollback reverts the changes made during promotion, most recent first
+func (r *PromotionReconciler) rollback(ctx context.Context, log logr.Logger, tc client.Client, tr client.Reader, rec *optimizev1beta2.Recommendation, reason, message string, probeTime *metav1.Time) (*ctrl.Result, error) {
+	changes := rec.Status.Promotion.Changes
+	for i := len(changes) - 1; i >= 0; i-- {
+		pc := &changes[i]
+		if pc.Original == "" {
+			continue
+		}
+
+		// Compare the original state to the current state, this also reverts patches not yet recorded as applied
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(pc.TargetRef.GroupVersionKind())
+		if err := tr.Get(ctx, types.NamespacedName{Namespace: pc.TargetRef.Namespace, Name: pc.TargetRef.Name}, u); err != nil {
+			if controller.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return nil, err
+		}
+
+		data, err := recommendation.RollbackPatch(pc, u)
+		if err != nil {
+			return nil, err
+		}
+		if string(data) == "{}" {
+			continue
+		}
+
+		if err := tc.Patch(ctx, u, client.RawPatch(types.MergePatchType, data)); err != nil {
+			// Keep trying, the original state is still recorded so nothing is lost
+			return nil, err
+		}
+	}
+
+	log.Info("Recommendation rolled back", "reason", reason, "message", message)
+	recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationPromoted, corev1.ConditionFalse, reason, message, probeTime)
+	recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationRolledBack, corev1.ConditionTrue, reason, message, probeTime)
+	recommendation.UpdateStatus(rec)
+	err := r.Update(ctx, rec)
+	return controller.RequeueConflict(err)
+}
+
+// target returns the client used to modify the promotion target along with the reader used to fetch objects
+func (r *PromotionReconciler) target(ctx context.Context, rec *optimizev1beta2.Recommendation) (client.Client, client.Reader, error) {
+	if rec.Spec.Promotion == nil || rec.Spec.Promotion.KubeConfig == nil {
+		return r.Client, r.apiReader, nil
+	}
+
+	sel := rec.Spec.Promotion.KubeConfig
+	secret := &corev1.Secret{}
+	// RBAC: We assume that we have "get" permission on the kubeconfig secret from a customer defined role
+	if err := r.apiReader.Get(ctx, types.NamespacedName{Namespace: rec.Namespace, Name: sel.Name}, secret); err != nil {
+		return nil, nil, err
+	}
+
+	data, ok := secret.Data[sel.Key]
+	if !ok {
+		return nil, nil, fmt.Errorf("secret %q does not contain key %q", sel.Name, sel.Key)
+	}
+
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: r.Scheme})
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, c, nil
+}
@@ -18,12 +18,16 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
 	"github.com/thestormforge/optimize-controller/v2/internal/ready"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	corev1 "k8s.io/api/core/v1"
@@ -46,10 +50,14 @@ type ReadyReconciler struct {
 	// requires list/watch. If we ever get a way to disable the cache or the cache becomes smart enough to handle
 	// permission errors without hanging we can go back to using standard reader.
 	apiReader client.Reader
+
+	// The HTTP client used for HTTP readiness checks
+	httpClient *http.Client
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=list
 
 // Reconcile inspects a trial to see if the patched objects are ready for the trial job to start
 func (r *ReadyReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -75,6 +83,7 @@ func (r *ReadyReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 // SetupWithManager registers a new ready reconciler with the supplied manager
 func (r *ReadyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.apiReader = mgr.GetAPIReader()
+	r.httpClient = &http.Client{Timeout: 10 * time.Second}
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("ready").
 		For(&optimizev1beta2.Trial{}).
@@ -131,6 +140,8 @@ func (r *ReadyReconciler) evaluateReadinessChecks(ctx context.Context, t *optimi
 			InitialDelaySeconds: c.InitialDelaySeconds,
 			PeriodSeconds:       c.PeriodSeconds,
 			AttemptsRemaining:   c.FailureThreshold,
+			SuccessThreshold:    c.SuccessThreshold,
+			Stabilization:       c.Stabilization.DeepCopy(),
 		}
 
 		// Adjust for defaults/minimums
@@ -144,6 +155,9 @@ func (r *ReadyReconciler) evaluateReadinessChecks(ctx context.Context, t *optimi
 		} else if rc.AttemptsRemaining < 0 {
 			rc.AttemptsRemaining = 1
 		}
+		if rc.SuccessThreshold < 1 {
+			rc.SuccessThreshold = 1
+		}
 
 		t.Status.ReadinessChecks = append(t.Status.ReadinessChecks, rc)
 	}
@@ -162,7 +176,10 @@ func (r *ReadyReconciler) checkReadiness(ctx context.Context, t *optimizev1beta2
 	}
 
 	// Create a new "checker" to maintain state while looping over the readiness checks
-	checker := newReadinessChecker(r.Client, t)
+	checker := newReadinessChecker(r.Client, r.httpClient, t)
+	checker.observe = func(ctx context.Context, c *optimizev1beta2.ReadinessCheck, now *metav1.Time) (float64, error) {
+		return r.observeMetric(ctx, t, c, now)
+	}
 	for i := range t.Status.ReadinessChecks {
 		c := &t.Status.ReadinessChecks[i]
 		if checker.skipCheck(c, probeTime) {
@@ -170,7 +187,7 @@ func (r *ReadyReconciler) checkReadiness(ctx context.Context, t *optimizev1beta2
 		}
 
 		// Get the objects to check
-		ul, err := r.getCheckTargets(ctx, c)
+		ul, err := getCheckTargets(ctx, r.apiReader, c)
 		if err != nil {
 			readinessCheckFailed(t, probeTime, err)
 			err := r.Update(ctx, t)
@@ -202,7 +219,7 @@ func (r *ReadyReconciler) checkReadiness(ctx context.Context, t *optimizev1beta2
 }
 
 // getCheckTargets returns the list of target objects for the readiness check
-func (r *ReadyReconciler) getCheckTargets(ctx context.Context, rc *optimizev1beta2.ReadinessCheck) (*unstructured.UnstructuredList, error) {
+func getCheckTargets(ctx context.Context, reader client.Reader, rc *optimizev1beta2.ReadinessCheck) (*unstructured.UnstructuredList, error) {
 	ul := &unstructured.UnstructuredList{}
 
 	// If there is no kind on the target reference, we can't actually fetch anything
@@ -217,7 +234,7 @@ func (r *ReadyReconciler) getCheckTargets(ctx context.Context, rc *optimizev1bet
 		if err != nil {
 			return nil, err
 		}
-		err = r.apiReader.List(ctx, ul, client.InNamespace(rc.TargetRef.Namespace), client.MatchingLabelsSelector{Selector: s})
+		err = reader.List(ctx, ul, client.InNamespace(rc.TargetRef.Namespace), client.MatchingLabelsSelector{Selector: s})
 		return ul, err
 	}
 
@@ -225,7 +242,7 @@ func (r *ReadyReconciler) getCheckTargets(ctx context.Context, rc *optimizev1bet
 	u := unstructured.Unstructured{}
 	u.SetGroupVersionKind(rc.TargetRef.GroupVersionKind())
 	key := types.NamespacedName{Namespace: rc.TargetRef.Namespace, Name: rc.TargetRef.Name}
-	if err := r.apiReader.Get(ctx, key, &u); err != nil {
+	if err := reader.Get(ctx, key, &u); err != nil {
 		// "Mimic" list behavior by returning an empty list if the object is not found
 		if controller.IgnoreNotFound(err) != nil {
 			return nil, err
@@ -236,10 +253,35 @@ func (r *ReadyReconciler) getCheckTargets(ctx context.Context, rc *optimizev1bet
 	return ul, nil
 }
 
+// observeMetric captures the current value of a stabilization metric
+func (r *ReadyReconciler) observeMetric(ctx context.Context, t *optimizev1beta2.Trial, c *optimizev1beta2.ReadinessCheck, now *metav1.Time) (float64, error) {
+	// Evaluate the queries against a copy of the metric since they are rendered in place
+	m := c.Stabilization.Metric.DeepCopy()
+
+	// Pretend the trial ran for the duration of the window, ending one period ago (to give the metrics provider a chance to catch up)
+	t = t.DeepCopy()
+	t.Status.CompletionTime = &metav1.Time{Time: now.Add(-time.Duration(c.PeriodSeconds) * time.Second)}
+	t.Status.StartTime = &metav1.Time{Time: t.Status.CompletionTime.Add(-ready.StabilizationWindow(c.Stabilization))}
+
+	if err := applyMetricDefaults(t, m); err != nil {
+		return 0, err
+	}
+
+	target, err := metricTarget(ctx, r, t, m)
+	if err != nil {
+		return 0, err
+	}
+
+	value, _, err := metric.CaptureMetric(ctx, r.Log.WithValues("trial", t.Namespace+"/"+t.Name), t, m, target)
+	return value, err
+}
+
 // readinessCheckFailed puts a trial into a failed state due to a failed readiness check
 func readinessCheckFailed(t *optimizev1beta2.Trial, probeTime *metav1.Time, err error) {
-	reason, message := "ReadinessCheckFailed", err.Error()
+	// Readiness errors indicate the patched application will never become ready, anything else is unexpected
+	category, reason, message := optimizev1beta2.FailureInfrastructure, "ReadinessCheckFailed", err.Error()
 	if rerr, ok := err.(*ready.ReadinessError); ok {
+		category = optimizev1beta2.FailureInfeasible
 		if rerr.Reason != "" {
 			reason = rerr.Reason
 		}
@@ -247,7 +289,7 @@ func readinessCheckFailed(t *optimizev1beta2.Trial, probeTime *metav1.Time, err
 			message = rerr.Message
 		}
 	}
-	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, reason, message, probeTime)
+	trial.ApplyFailure(&t.Status, category, reason, message, probeTime)
 }
 
 // readinessChecker is the loop state used to evaluate readiness checks
@@ -262,11 +304,13 @@ type readinessChecker struct {
 	requeue bool
 	// after is the delay after which all of the readiness checks can be evaluated
 	after time.Duration
+	// observe is used to capture the current value of a stabilization metric
+	observe func(context.Context, *optimizev1beta2.ReadinessCheck, *metav1.Time) (float64, error)
 }
 
 // newReadinessChecker returns a new checker for the supplied trial
-func newReadinessChecker(reader client.Reader, t *optimizev1beta2.Trial) *readinessChecker {
-	checker := ready.ReadinessChecker{Reader: reader}
+func newReadinessChecker(reader client.Reader, httpClient *http.Client, t *optimizev1beta2.Trial) *readinessChecker {
+	checker := ready.ReadinessChecker{Reader: reader, HTTPClient: httpClient}
 	epoch := t.GetCreationTimestamp()
 	for i := range t.Status.Conditions {
 		if t.Status.Conditions[i].Type == optimizev1beta2.TrialPatched {
@@ -312,7 +356,15 @@ func (rc *readinessChecker) skipCheck(c *optimizev1beta2.ReadinessCheck, now *me
 // check evaluates a readiness check against a (possibly nil) target, returning a status message and boolean indicating
 // if the target is in fact ready
 func (rc *readinessChecker) check(ctx context.Context, c *optimizev1beta2.ReadinessCheck, ul *unstructured.UnstructuredList, now *metav1.Time) (string, bool, error) {
+	// Do not let a single attempt run longer then the period between attempts
+	if c.PeriodSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.PeriodSeconds)*time.Second)
+		defer cancel()
+	}
+
 	// Evaluate the actual conditions (stop at the first one that isn't "ready")
+	rc.checker.Started = rc.startTime(c)
 	var msg string
 	var ok bool
 	var err error
@@ -329,6 +381,26 @@ func (rc *readinessChecker) check(ctx context.Context, c *optimizev1beta2.Readin
 		ok = true
 	}
 
+	// The metric must also be stable, don't count attempts while waiting for it to settle (up to the deadline)
+	if ok && err == nil && c.Stabilization != nil {
+		var status corev1.ConditionStatus
+		msg, status, err = rc.checkStabilization(ctx, c, now)
+		if status == corev1.ConditionUnknown {
+			c.LastCheckTime = now
+			return msg, false, nil
+		}
+		ok = status == corev1.ConditionTrue
+	}
+
+	// Require multiple consecutive successes before the check is considered done
+	if ok && err == nil {
+		c.ConsecutiveSuccesses++
+		if c.ConsecutiveSuccesses < c.SuccessThreshold {
+			c.LastCheckTime = now
+			return fmt.Sprintf("Waiting for %d more successful attempt(s)", c.SuccessThreshold-c.ConsecutiveSuccesses), false, nil
+		}
+	}
+
 	// Check is done, it is either ok or had a hard failure
 	if ok || err != nil {
 		c.AttemptsRemaining = 0
@@ -336,6 +408,9 @@ func (rc *readinessChecker) check(ctx context.Context, c *optimizev1beta2.Readin
 		return "", ok, err
 	}
 
+	// Any failure resets the consecutive success count
+	c.ConsecutiveSuccesses = 0
+
 	// If there are no items to check, try to provide a useful message
 	if len(ul.Items) == 0 {
 		var missingTargetMsg strings.Builder
@@ -366,6 +441,32 @@ func (rc *readinessChecker) check(ctx context.Context, c *optimizev1beta2.Readin
 	return msg, false, nil
 }
 
+// checkStabilization records a new observation of the stabilization metric and determines if it is stable
+func (rc *readinessChecker) checkStabilization(ctx context.Context, c *optimizev1beta2.ReadinessCheck, now *metav1.Time) (string, corev1.ConditionStatus, error) {
+	if rc.observe == nil {
+		return "", corev1.ConditionTrue, nil
+	}
+
+	value, err := rc.observe(ctx, c, now)
+	if merr, ok := err.(*metric.CaptureError); ok && merr.RetryAfter > 0 {
+		return fmt.Sprintf("Waiting for %s", c.Stabilization.Metric.Name), corev1.ConditionUnknown, nil
+	} else if err != nil {
+		return err.Error(), corev1.ConditionFalse, nil
+	}
+
+	c.Observations = append(c.Observations, optimizev1beta2.ReadinessObservation{
+		Time:  *now,
+		Value: strconv.FormatFloat(value, 'f', -1, 64),
+	})
+	// The deadline is measured from the first attempt of the check
+	return ready.CheckStabilization(c, rc.startTime(c), now.Time)
+}
+
+// startTime returns the approximate time of the first attempt to evaluate a check
+func (rc *readinessChecker) startTime(c *optimizev1beta2.ReadinessCheck) time.Time {
+	return rc.epoch.Add(time.Duration(c.InitialDelaySeconds) * time.Second)
+}
+
 // nextCheckTime returns the approximate time that an attempt should be made to evaluate a check
 func (rc *readinessChecker) nextCheckTime(c *optimizev1beta2.ReadinessCheck) *metav1.Time {
 	if c.LastCheckTime != nil {
@@ -0,0 +1,223 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/recommendation"
+	"github.com/thestormforge/optimize-controller/v2/internal/server"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// RecommendationReconciler reconciles a Recommendation object
+type RecommendationReconciler struct {
+	client.Client
+	Log            logr.Logger
+	Scheme         *runtime.Scheme
+	ExperimentsAPI experimentsv1alpha1.API
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=recommendations,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list;watch
+
+func (r *RecommendationReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("recommendation", req.NamespacedName)
+
+	rec := &optimizev1beta2.Recommendation{}
+	if err := r.Get(ctx, req.NamespacedName, rec); err != nil {
+		if !apierrs.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		// There is no recommendation yet, produce one if the experiment with the same name is complete
+		return ctrl.Result{}, r.createRecommendation(ctx, log, req.NamespacedName)
+	}
+
+	if result, err := r.populateRecommendation(ctx, log, rec); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *RecommendationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.ExperimentsAPI == nil {
+		// The server is only used to recover trials which were already removed from the cluster
+		api, err := server.NewExperimentAPI(context.Background(), "")
+		if err != nil {
+			r.Log.Info("Producing recommendations from cluster trials only", "error", err.Error())
+		} else {
+			r.ExperimentsAPI = api
+		}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("recommendation").
+		For(&optimizev1beta2.Recommendation{}).
+		Watches(&source.Kind{Type: &optimizev1beta2.Experiment{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(experimentToRecommendationRequest)}).
+		Complete(r)
+}
+
+// experimentToRecommendationRequest returns the reconcile request for the recommendation produced when an experiment completes
+func experimentToRecommendationRequest(o handler.MapObject) []reconcile.Request {
+	if _, ok := o.Object.(*optimizev1beta2.Experiment); ok {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: o.Meta.GetNamespace(), Name: o.Meta.GetName()}}}
+	}
+	return nil
+}
+
+// createRecommendation creates a new recommendation for a completed experiment
+func (r *RecommendationReconciler) createRecommendation(ctx context.Context, log logr.Logger, nn types.NamespacedName) error {
+	exp := &optimizev1beta2.Experiment{}
+	if err := r.Get(ctx, nn, exp); err != nil {
+		return controller.IgnoreNotFound(err)
+	}
+
+	if !exp.GetDeletionTimestamp().IsZero() || !isExperimentComplete(exp) {
+		return nil
+	}
+
+	rec := recommendation.NewRecommendation(exp)
+	if err := controllerutil.SetControllerReference(exp, rec, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, rec); err != nil {
+		return controller.IgnoreAlreadyExists(err)
+	}
+
+	log.Info("Created recommendation for completed experiment", "experiment", exp.Name)
+	return nil
+}
+
+// populateRecommendation fills in the recommendation from the selected trial of the experiment
+func (r *RecommendationReconciler) populateRecommendation(ctx context.Context, log logr.Logger, rec *optimizev1beta2.Recommendation) (*ctrl.Result, error) {
+	if recommendation.IsPopulated(rec) {
+		if recommendation.UpdateStatus(rec) {
+			if err := r.Update(ctx, rec); err != nil {
+				return controller.RequeueConflict(err)
+			}
+		}
+		return nil, nil
+	}
+
+	now := metav1.Now()
+	if err := r.populate(ctx, rec); err != nil {
+		// Keep trying, for example the named trial may not have finished yet
+		log.Info("Unable to produce recommendation", "error", err.Error())
+		recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationReady, corev1.ConditionFalse, "Failed", err.Error(), &now)
+		recommendation.UpdateStatus(rec)
+		if err := r.Update(ctx, rec); err != nil {
+			return controller.RequeueConflict(err)
+		}
+		return &ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	recommendation.ApplyCondition(&rec.Status, optimizev1beta2.RecommendationReady, corev1.ConditionTrue, "", "", &now)
+	recommendation.UpdateStatus(rec)
+	if err := r.Update(ctx, rec); err != nil {
+		return controller.RequeueConflict(err)
+	}
+	return nil, nil
+}
+
+// populate selects the trial to recommend and fills in the recommendation
+func (r *RecommendationReconciler) populate(ctx context.Context, rec *optimizev1beta2.Recommendation) error {
+	exp := &optimizev1beta2.Experiment{}
+	if err := r.Get(ctx, rec.ExperimentNamespacedName(), exp); err != nil {
+		return err
+	}
+
+	matchingSelector, err := meta.MatchingSelector(exp.TrialSelector())
+	if err != nil {
+		return err
+	}
+
+	trialList := &optimizev1beta2.TrialList{}
+	if err := r.List(ctx, trialList, matchingSelector); err != nil {
+		return err
+	}
+
+	serverTrials, err := r.serverTrials(ctx, exp)
+	if err != nil {
+		return err
+	}
+	trials := recommendation.MergeTrials(exp, trialList.Items, serverTrials)
+
+	t, err := recommendation.SelectTrial(rec, exp, trials)
+	if err != nil {
+		return err
+	}
+
+	return recommendation.Populate(rec, exp, t, recommendation.FindBaseline(exp, trials))
+}
+
+// serverTrials returns the completed trials of the server experiment, if the experiment is synchronized
+func (r *RecommendationReconciler) serverTrials(ctx context.Context, exp *optimizev1beta2.Experiment) ([]optimizev1beta2.Trial, error) {
+	u := exp.GetAnnotations()[optimizev1beta2.AnnotationExperimentURL]
+	if r.ExperimentsAPI == nil || u == "" || !server.IsServerSyncEnabled(exp) {
+		return nil, nil
+	}
+
+	ee, err := r.ExperimentsAPI.GetExperiment(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	q := experimentsv1alpha1.TrialListQuery{}
+	q.SetStatus(experimentsv1alpha1.TrialCompleted)
+	tl, err := r.ExperimentsAPI.GetAllTrials(ctx, ee.Link(api.RelationTrials), q)
+	if err != nil {
+		return nil, err
+	}
+
+	trials := make([]optimizev1beta2.Trial, len(tl.Trials))
+	for i := range tl.Trials {
+		server.ToClusterTrialItem(&trials[i], exp.Spec.Parameters, &tl.Trials[i])
+	}
+	return trials, nil
+}
+
+// isExperimentComplete checks to see if the experiment completed successfully
+func isExperimentComplete(exp *optimizev1beta2.Experiment) bool {
+	for _, c := range exp.Status.Conditions {
+		if c.Type == optimizev1beta2.ExperimentComplete {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -18,13 +18,16 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/capacity"
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
 	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
 	"github.com/thestormforge/optimize-controller/v2/internal/meta"
@@ -35,6 +38,7 @@ import (
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
@@ -43,9 +47,19 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
+// warmStartLabel is the server trial label used to identify trials imported from a previous experiment
+const warmStartLabel = "warmStart"
+
 var (
 	defaultServerTrialTTLSecondsAfterFinished = int32((4 * time.Hour) / time.Second)
 	defaultServerTrialTTLSecondsAfterFailure  = int32((48 * time.Hour) / time.Second)
+
+	// capacityRetryInterval is how long to wait before trying again when a trial does not fit in the cluster
+	capacityRetryInterval = 30 * time.Second
+	// queueRetryInterval is how long to wait before checking again when an experiment is waiting for a trial slot
+	queueRetryInterval = 10 * time.Second
+	// scheduleRetryInterval is the longest to wait before checking again when an experiment is waiting for a schedule window
+	scheduleRetryInterval = 5 * time.Minute
 )
 
 // trialCreationRateLimit returns the configured rate for allowing trial creations, the
@@ -67,6 +81,24 @@ func trialCreationRateLimit(log logr.Logger) rate.Limit {
 	return rate.Every(d)
 }
 
+// maxConcurrentTrials returns the configured cluster-wide limit on the number of concurrently running trials, the
+// default of zero indicates there is no limit.
+func maxConcurrentTrials(log logr.Logger) int32 {
+	maxConcurrentTrials, ok := os.LookupEnv("STORMFORGE_MAX_CONCURRENT_TRIALS")
+	if !ok {
+		return 0
+	}
+
+	n, err := strconv.ParseInt(maxConcurrentTrials, 10, 32)
+	if err != nil || n < 0 {
+		log.Info("Ignoring invalid maximum concurrent trials", "maxConcurrentTrials", maxConcurrentTrials)
+		return 0
+	}
+
+	log.Info("Using cluster-wide concurrent trial limit", "maxConcurrentTrials", n)
+	return int32(n)
+}
+
 // ServerReconciler reconciles a experiment and trial objects with a remote server
 type ServerReconciler struct {
 	client.Client
@@ -74,12 +106,19 @@ type ServerReconciler struct {
 	Scheme         *runtime.Scheme
 	ExperimentsAPI experimentsv1alpha1.API
 
-	trialCreation *rate.Limiter
+	trialCreation       *rate.Limiter
+	maxConcurrentTrials int32
+
+	// Use the raw API reader for capacity checks and cloned namespace resources so we do not need to cache every node,
+	// pod, patch target or secret in the cluster, see the ReadyReconciler for details.
+	apiReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list;watch;create;update
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=list
+// +kubebuilder:rbac:groups="",resources=nodes;pods;resourcequotas,verbs=list
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=list;watch
 
 func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -123,11 +162,13 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		// Trials that have the server finalizer may need to be reported
 		if meta.HasFinalizer(t, server.Finalizer) {
 			// TODO Combine report and abandon into one function
-			if trial.IsFinished(t) {
+			// NOTE: Only infeasible failures are reported, other failures are abandoned so the assignments can be retried
+			// (until the assignments have been retried too many times, then the failure is also reported)
+			if trial.IsFinished(t) && !trial.IsRetryable(t) {
 				if result, err := r.reportTrial(ctx, tlog, t); result != nil {
 					return *result, err
 				}
-			} else if trial.IsAbandoned(t) {
+			} else if trial.IsAbandoned(t) || trial.IsRetryable(t) {
 				if result, err := r.abandonTrial(ctx, tlog, t); result != nil {
 					return *result, err
 				}
@@ -173,9 +214,14 @@ func (r *ServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.ExperimentsAPI = api
 	}
 
+	r.apiReader = mgr.GetAPIReader()
+
 	// Enforce trial creation rate limit (no burst! that is the whole point)
 	r.trialCreation = rate.NewLimiter(trialCreationRateLimit(r.Log), 1)
 
+	// Enforce the cluster-wide concurrent trial limit
+	r.maxConcurrentTrials = maxConcurrentTrials(r.Log)
+
 	// To search for namespaces by name, we need to index them
 	_ = mgr.GetCache().IndexField(&corev1.Namespace{}, "metadata.name", func(obj runtime.Object) []string { return []string{obj.(*corev1.Namespace).Name} })
 
@@ -253,6 +299,9 @@ func (r *ServerReconciler) createExperiment(ctx context.Context, log logr.Logger
 		}
 	}
 
+	// Best effort to seed the experiment with the observations of a previous experiment
+	r.warmStart(ctx, log, exp, &ee)
+
 	// Apply the server response to the cluster state
 	server.ToCluster(exp, &ee)
 
@@ -265,6 +314,116 @@ func (r *ServerReconciler) createExperiment(ctx context.Context, log logr.Logger
 	return nil, nil
 }
 
+// warmStart imports the compatible trials of a previous experiment into the server experiment; failures are logged
+// but otherwise ignored since the experiment can still run without them
+func (r *ServerReconciler) warmStart(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, ee *experimentsv1alpha1.Experiment) {
+	if exp.Spec.WarmStart == nil {
+		return
+	}
+
+	// The experiment is created again if the cluster update fails, make sure the trials are only imported once
+	if seeded, err := r.isWarmStarted(ctx, ee); err != nil {
+		log.Error(err, "Failed to check for warm start trials")
+		return
+	} else if seeded {
+		return
+	}
+
+	trials, err := r.warmStartTrials(ctx, exp)
+	if err != nil {
+		log.Error(err, "Failed to fetch warm start trials")
+		return
+	}
+
+	var seeded int
+	compatible := experiment.WarmStartTrials(exp, trials)
+	for i := range compatible {
+		t := &compatible[i]
+		ta, err := server.FromClusterTrialAssignments(exp, t)
+		if err != nil {
+			log.Error(err, "Failed to convert warm start trial", "trial", t.Name)
+			continue
+		}
+
+		ta.Labels = map[string]string{warmStartLabel: "true"}
+		st, err := r.ExperimentsAPI.CreateTrial(ctx, ee.Link(api.RelationTrials), *ta)
+		if err != nil {
+			log.Error(err, "Failed to create warm start trial", "trial", t.Name)
+			continue
+		}
+
+		if err := r.ExperimentsAPI.ReportTrial(ctx, st.Location(), *server.FromClusterTrial(t)); err != nil {
+			log.Error(err, "Failed to report warm start trial", "trial", t.Name)
+			continue
+		}
+		seeded++
+	}
+
+	log.Info("Warm started experiment", "trials", seeded, "skipped", len(trials)-seeded)
+}
+
+// isWarmStarted checks to see if the server experiment already has trials imported from a previous experiment
+func (r *ServerReconciler) isWarmStarted(ctx context.Context, ee *experimentsv1alpha1.Experiment) (bool, error) {
+	q := experimentsv1alpha1.TrialListQuery{}
+	q.SetStatus(experimentsv1alpha1.TrialCompleted)
+	tl, err := r.ExperimentsAPI.GetAllTrials(ctx, ee.Link(api.RelationTrials), q)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range tl.Trials {
+		if tl.Trials[i].Labels[warmStartLabel] == "true" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// warmStartTrials returns the trials of the previous experiment referenced by the warm start
+func (r *ServerReconciler) warmStartTrials(ctx context.Context, exp *optimizev1beta2.Experiment) ([]optimizev1beta2.Trial, error) {
+	nn, err := experiment.WarmStartExperimentName(exp)
+	if err != nil {
+		return nil, err
+	}
+
+	if nn.Name != "" {
+		prev := &optimizev1beta2.Experiment{}
+		if err := r.Get(ctx, nn, prev); err != nil {
+			return nil, err
+		}
+
+		trialList := &optimizev1beta2.TrialList{}
+		if err := r.listTrials(ctx, trialList, prev.TrialSelector()); err != nil {
+			return nil, err
+		}
+		return trialList.Items, nil
+	}
+
+	if name := exp.Spec.WarmStart.ServerExperimentName; name != "" {
+		prev, err := r.ExperimentsAPI.GetExperimentByName(ctx, experimentsv1alpha1.NewExperimentName(name))
+		if err != nil {
+			return nil, err
+		}
+
+		q := experimentsv1alpha1.TrialListQuery{}
+		q.SetStatus(experimentsv1alpha1.TrialCompleted)
+		tl, err := r.ExperimentsAPI.GetAllTrials(ctx, prev.Link(api.RelationTrials), q)
+		if err != nil {
+			return nil, err
+		}
+
+		// The server does not know the cluster parameter definitions, assume they only differ by name
+		params := experiment.WarmStartParameters(exp)
+		trials := make([]optimizev1beta2.Trial, len(tl.Trials))
+		for i := range tl.Trials {
+			server.ToClusterTrialItem(&trials[i], params, &tl.Trials[i])
+		}
+		return trials, nil
+	}
+
+	return nil, nil
+}
+
 // unlinkExperiment will delete the experiment from the server using the URLs recorded in the cluster; the finalizer
 // added when the experiment was created on the server will also be removed
 func (r *ServerReconciler) unlinkExperiment(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment) (*ctrl.Result, error) {
@@ -297,6 +456,11 @@ func (r *ServerReconciler) unlinkExperiment(ctx context.Context, log logr.Logger
 // nextTrial will try to obtain a suggestion from the server and create the corresponding cluster state in the form of
 // a trial; if the cluster can not accommodate additional trials at the time of invocation, not action will be taken
 func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (*ctrl.Result, error) {
+	// Wait for a schedule window or a trial slot if the number of concurrent trials is limited
+	if result, err := r.checkWaiting(ctx, exp); result != nil {
+		return result, err
+	}
+
 	// Enforce a rate limit on trial creation
 	res := r.trialCreation.Reserve()
 	if !res.OK() {
@@ -310,7 +474,7 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 	}
 
 	// Determine the namespace (if any) to use for the trial
-	namespace, err := experiment.NextTrialNamespace(ctx, r, exp, trialList)
+	namespace, err := experiment.NextTrialNamespace(ctx, r, r.apiReader, exp, trialList)
 	if err != nil {
 		return &ctrl.Result{}, err
 	}
@@ -332,7 +496,8 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 	t := &optimizev1beta2.Trial{}
 	experiment.PopulateTrialFromTemplate(exp, t)
 	t.Namespace = namespace
-	server.ToClusterTrial(t, &suggestion)
+	server.ToClusterTrial(t, exp.Spec.Parameters, &suggestion)
+	trial.ApplyRetryCount(t, trialList.Items)
 
 	// Since the trial originated from the server, we can delete it out of the cluster (require both TTLs to be unset)
 	if t.Spec.TTLSecondsAfterFinished == nil && t.Spec.TTLSecondsAfterFailure == nil {
@@ -346,6 +511,11 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 		log.Info("Trial is missing a reporting URL")
 	}
 
+	// Make sure the trial will fit in the cluster before creating it
+	if result, err := r.checkCapacity(ctx, log, exp, t); result != nil {
+		return result, err
+	}
+
 	// Create the trial
 	if err := r.Create(ctx, t); err != nil {
 		// If creation fails, abandon the suggestion (ignoring those errors)
@@ -359,6 +529,121 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 	return nil, nil
 }
 
+// checkWaiting determines if the experiment must wait for a schedule window to open or for trials from other
+// experiments to finish before it can start a new trial, the reason for waiting is recorded on the experiment status
+func (r *ServerReconciler) checkWaiting(ctx context.Context, exp *optimizev1beta2.Experiment) (*ctrl.Result, error) {
+	var reason string
+	retry := queueRetryInterval
+
+	// Trials can only start inside of a schedule window
+	now := time.Now()
+	message, next, err := experiment.CheckSchedule(exp, now)
+	if err != nil && experiment.FailExperiment(exp, "InvalidSchedule", err) {
+		err := r.Update(ctx, exp)
+		return controller.RequeueConflict(err)
+	}
+	if message != "" {
+		reason = experiment.ReasonSchedule
+		if retry = next.Sub(now); retry > scheduleRetryInterval {
+			retry = scheduleRetryInterval
+		}
+	}
+
+	if reason == "" && r.maxConcurrentTrials > 0 {
+		experimentList := &optimizev1beta2.ExperimentList{}
+		if err := r.List(ctx, experimentList); err != nil {
+			return &ctrl.Result{}, err
+		}
+
+		trialList := &optimizev1beta2.TrialList{}
+		if err := r.List(ctx, trialList); err != nil {
+			return &ctrl.Result{}, err
+		}
+
+		priorityClassList := &schedulingv1.PriorityClassList{}
+		if err := r.List(ctx, priorityClassList); err != nil {
+			return &ctrl.Result{}, err
+		}
+		priorities := make(map[string]int32, len(priorityClassList.Items))
+		for _, pc := range priorityClassList.Items {
+			priorities[pc.Name] = pc.Value
+			if pc.GlobalDefault {
+				priorities[""] = pc.Value
+			}
+		}
+
+		message = experiment.CheckConcurrency(exp, experimentList, trialList, priorities, r.maxConcurrentTrials)
+		if message == "" {
+			// The cache may not include trials just created by other reconciles, re-count them before admitting
+			trialList = &optimizev1beta2.TrialList{}
+			if err := r.apiReader.List(ctx, trialList); err != nil {
+				return &ctrl.Result{}, err
+			}
+			message = experiment.CheckConcurrency(exp, experimentList, trialList, priorities, r.maxConcurrentTrials)
+		}
+		if message != "" {
+			reason = experiment.ReasonQueued
+		}
+	}
+
+	if experiment.ApplyWaiting(exp, reason, message) {
+		err := r.Update(ctx, exp)
+		return controller.RequeueConflict(err)
+	}
+
+	if reason != "" {
+		return &ctrl.Result{RequeueAfter: retry}, nil
+	}
+
+	return nil, nil
+}
+
+// checkCapacity estimates the resources required by a new trial, if the trial does not fit in the cluster it is
+// either reported as infeasible or abandoned so creation can be deferred until capacity is available
+func (r *ServerReconciler) checkCapacity(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
+	// Estimation is best effort, failures should not prevent the trial from running
+	fp, err := capacity.Estimate(ctx, r.apiReader, exp, t)
+	if err == nil {
+		err = capacity.Check(ctx, r.apiReader, fp)
+	}
+	ice := &capacity.InsufficientCapacityError{}
+	if !errors.As(err, &ice) {
+		if err != nil {
+			log.Error(err, "Failed to check trial capacity")
+		}
+		return nil, nil
+	}
+
+	reportTrialURL := t.GetAnnotations()[optimizev1beta2.AnnotationReportTrialURL]
+	log = log.WithValues("reportTrialURL", reportTrialURL, "assignments", t.Spec.Assignments, "message", ice.Message)
+
+	// Report the trial as failed so the server does not suggest it again
+	if ice.Infeasible {
+		now := metav1.Now()
+		trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfeasible, "InsufficientCapacity", ice.Message, &now)
+		if reportTrialURL != "" {
+			err := r.ExperimentsAPI.ReportTrial(ctx, reportTrialURL, *server.FromClusterTrial(t))
+			if controller.IgnoreReportError(err) != nil {
+				return &ctrl.Result{}, err
+			}
+		}
+
+		log.Info("Reported trial with insufficient capacity")
+		return &ctrl.Result{Requeue: true}, nil
+	}
+
+	// Give the suggestion back and try again later
+	if reportTrialURL != "" {
+		err := r.ExperimentsAPI.AbandonRunningTrial(ctx, reportTrialURL)
+		if controller.IgnoreNotFound(err) != nil {
+			return &ctrl.Result{}, err
+		}
+	}
+
+	log.Info("Deferred trial creation")
+	return &ctrl.Result{RequeueAfter: capacityRetryInterval}, nil
+}
+
 // reportTrial will report the values from a finished in cluster trial back to the server
 func (r *ServerReconciler) reportTrial(ctx context.Context, log logr.Logger, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
 	if !meta.RemoveFinalizer(t, server.Finalizer) {
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -31,6 +32,7 @@ import (
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -41,11 +43,20 @@ type SetupReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	diagnostics trial.DiagnosticsReader
+
+	// Use the raw API reader for the secrets containing setup task outputs so we do not need list/watch permissions
+	// on them, see the ReadyReconciler for details.
+	apiReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials;trials/finalizers,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=list
 // +kubebuilder:rbac:groups=batch;extensions,resources=jobs,verbs=list;watch;create
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
 
 func (r *SetupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -72,7 +83,7 @@ func (r *SetupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	// Finish
-	if result, err := r.finish(ctx, t); result != nil {
+	if result, err := r.finish(ctx, t, &now); result != nil {
 		return *result, err
 	}
 
@@ -80,6 +91,14 @@ func (r *SetupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 }
 
 func (r *SetupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Logs and events are read directly from the API server when a setup job fails
+	c, err := corev1client.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.diagnostics = trial.NewDiagnosticsReader(c)
+	r.apiReader = mgr.GetAPIReader()
+
 	// TODO Have some type of setting to by-pass this
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("setup").
@@ -103,8 +122,15 @@ func (r *SetupReconciler) inspectSetupJobs(ctx context.Context, t *optimizev1bet
 			// Normally if the trial hasn't been deleted and there are no jobs, the status will already be unknown
 			// NOTE: Do not use ApplyCondition unless we are sure the condition is already there
 			for i := range t.Status.Conditions {
-				if ct := t.Status.Conditions[i].Type; ct == optimizev1beta2.TrialSetupCreated || ct == optimizev1beta2.TrialSetupDeleted {
-					trial.ApplyCondition(&t.Status, ct, corev1.ConditionUnknown, "", "", probeTime)
+				switch t.Status.Conditions[i].Type {
+				case optimizev1beta2.TrialSetupCreated:
+					if setup.NeedsJob(t, setup.ModeCreate) {
+						trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupCreated, corev1.ConditionUnknown, "", "", probeTime)
+					}
+				case optimizev1beta2.TrialSetupDeleted:
+					if setup.NeedsJob(t, setup.ModeDelete) {
+						trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupDeleted, corev1.ConditionUnknown, "", "", probeTime)
+					}
 				}
 			}
 		} else if trial.CheckCondition(&t.Status, optimizev1beta2.TrialSetupDeleted, corev1.ConditionFalse) {
@@ -117,9 +143,20 @@ func (r *SetupReconciler) inspectSetupJobs(ctx context.Context, t *optimizev1bet
 	}
 
 	// Update the conditions based on existing jobs
+	var dirty bool
 	for i := range list.Items {
 		job := &list.Items[i]
 
+		// Record the status of the individual tasks run by the job
+		podList := &corev1.PodList{}
+		if matchingSelector, err := meta.MatchingSelector(job.Spec.Selector); err == nil {
+			_ = r.List(ctx, podList, client.InNamespace(job.Namespace), matchingSelector)
+		}
+		for _, ts := range setup.GetTaskStatuses(job, podList.Items) {
+			dirty = setup.ApplyTaskStatus(&t.Status, ts) || dirty
+		}
+		dirty = setup.ApplyOutputs(&t.Status, setup.GetTaskOutputs(job, podList.Items)) || dirty
+
 		// Inspect the job to determine which condition to update
 		conditionType, err := setup.GetTrialConditionType(job)
 		if err != nil {
@@ -133,13 +170,31 @@ func (r *SetupReconciler) inspectSetupJobs(ctx context.Context, t *optimizev1bet
 		}
 		trial.ApplyCondition(&t.Status, conditionType, conditionStatus, "", "", probeTime)
 
+		// Once the setup tasks are created, collect any outputs written to config maps
+		if conditionType == optimizev1beta2.TrialSetupCreated && conditionStatus == corev1.ConditionTrue && failureMessage == "" {
+			if changed, err := r.applyOutputs(ctx, t); err != nil {
+				if !trial.IsFinished(t) {
+					trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfrastructure, "SetupOutputsFailed", err.Error(), probeTime)
+				}
+			} else {
+				dirty = changed || dirty
+			}
+		}
+
 		// Only fail the trial itself if it isn't already finished; both to prevent overwriting an existing success
 		// or failure status and to avoid updating the probe time (which would get us stuck in a busy loop)
 		if failureMessage != "" && !trial.IsFinished(t) {
-			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "SetupJobFailed", failureMessage, probeTime)
+			trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfrastructure, "SetupJobFailed", failureMessage, probeTime)
+			r.archiveDiagnostics(ctx, t, job, podList)
 		}
 	}
 
+	// Check to see if we need to update the trial to record a task status change
+	if dirty {
+		err := r.Update(ctx, t)
+		return controller.RequeueConflict(err)
+	}
+
 	// Check to see if we need to update the trial to record a condition change
 	// TODO This check just looks for the probeTime in "last transition" times, is this causing unnecessary updates?
 	// TODO Can we use pointer equivalence on probeTime to help mitigate that problem?
@@ -152,6 +207,22 @@ func (r *SetupReconciler) inspectSetupJobs(ctx context.Context, t *optimizev1bet
 	return nil, nil
 }
 
+// archiveDiagnostics captures the container logs and events of a failed setup job so they are still available
+// after the pods are removed; failures are logged but otherwise ignored
+func (r *SetupReconciler) archiveDiagnostics(ctx context.Context, t *optimizev1beta2.Trial, job *batchv1.Job, podList *corev1.PodList) {
+	if r.diagnostics == nil {
+		return
+	}
+
+	data, err := trial.CollectDiagnostics(r.diagnostics, job, podList.Items)
+	if err == nil {
+		err = trial.ArchiveDiagnostics(ctx, r, r.Scheme, t, data)
+	}
+	if err != nil {
+		r.Log.WithValues("trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name)).Error(err, "Failed to archive setup diagnostics")
+	}
+}
+
 // inspectSetupJobPods will do further inspection on a job's pods to determine its current state
 func (r *SetupReconciler) inspectSetupJobPods(ctx context.Context, j *batchv1.Job) (corev1.ConditionStatus, string) {
 	list := &corev1.PodList{}
@@ -160,7 +231,10 @@ func (r *SetupReconciler) inspectSetupJobPods(ctx context.Context, j *batchv1.Jo
 	}
 
 	for i := range list.Items {
-		for _, cs := range list.Items[i].Status.ContainerStatuses {
+		var containerStatuses []corev1.ContainerStatus
+		containerStatuses = append(containerStatuses, list.Items[i].Status.InitContainerStatuses...)
+		containerStatuses = append(containerStatuses, list.Items[i].Status.ContainerStatuses...)
+		for _, cs := range containerStatuses {
 			if !cs.Ready && cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0 {
 				return corev1.ConditionTrue, "Setup job has a failed container"
 			}
@@ -196,6 +270,13 @@ func (r *SetupReconciler) createSetupJob(ctx context.Context, t *optimizev1beta2
 		}
 	}
 
+	// Apply or prune setup task manifests
+	if mode != "" {
+		if result, err := r.applySetupManifests(ctx, t, mode, probeTime); result != nil {
+			return result, err
+		}
+	}
+
 	// Create a setup job if necessary
 	if mode != "" {
 		job, err := setup.NewJob(t, mode)
@@ -220,8 +301,55 @@ func (r *SetupReconciler) createSetupJob(ctx context.Context, t *optimizev1beta2
 	return nil, nil
 }
 
+// applySetupManifests applies (or prunes) the objects from setup task manifests, if the setup tasks do not require a
+// job the trial status is updated to reflect that setup is complete
+func (r *SetupReconciler) applySetupManifests(ctx context.Context, t *optimizev1beta2.Trial, mode string, probeTime *metav1.Time) (*ctrl.Result, error) {
+	switch mode {
+
+	case setup.ModeCreate:
+		if err := setup.ApplyManifests(ctx, r.Client, t); err != nil {
+			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupCreated, corev1.ConditionTrue, "ApplyFailed", err.Error(), probeTime)
+			trial.ApplyFailure(&t.Status, trial.PatchFailureCategory(err), "SetupManifestFailed", err.Error(), probeTime)
+			err := r.Update(ctx, t)
+			return controller.RequeueConflict(err)
+		}
+
+		if !setup.NeedsJob(t, mode) {
+			if _, err := r.applyOutputs(ctx, t); err != nil {
+				trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupCreated, corev1.ConditionTrue, "OutputsFailed", err.Error(), probeTime)
+				trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfrastructure, "SetupOutputsFailed", err.Error(), probeTime)
+				err := r.Update(ctx, t)
+				return controller.RequeueConflict(err)
+			}
+			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupCreated, corev1.ConditionTrue, "", "", probeTime)
+			err := r.Update(ctx, t)
+			return controller.RequeueConflict(err)
+		}
+
+	case setup.ModeDelete:
+		// Deletion happens in reverse order, wait for the delete job before pruning
+		if setup.NeedsJob(t, mode) {
+			return nil, nil
+		}
+
+		if err := setup.PruneManifests(ctx, r.Client, t); err != nil {
+			// Do not block the trial deletion, just record the failure
+			r.Log.WithValues("trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name)).Error(err, "Failed to prune setup task manifests")
+			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupDeleted, corev1.ConditionTrue, "PruneFailed", err.Error(), probeTime)
+			err := r.Update(ctx, t)
+			return controller.RequeueConflict(err)
+		}
+
+		trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupDeleted, corev1.ConditionTrue, "", "", probeTime)
+		err := r.Update(ctx, t)
+		return controller.RequeueConflict(err)
+	}
+
+	return nil, nil
+}
+
 // finish takes care of removing initializers and finalizers
-func (r *SetupReconciler) finish(ctx context.Context, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
+func (r *SetupReconciler) finish(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
 	// If the create job isn't finished, wait for it (unless the trial is already finished, i.e. failed)
 	if trial.CheckCondition(&t.Status, optimizev1beta2.TrialSetupCreated, corev1.ConditionFalse) {
 		if !trial.IsFinished(t) && t.DeletionTimestamp.IsZero() {
@@ -239,6 +367,14 @@ func (r *SetupReconciler) finish(ctx context.Context, t *optimizev1beta2.Trial)
 
 	// Do not remove the finalizer until the delete job is finished
 	if trial.CheckCondition(&t.Status, optimizev1beta2.TrialSetupDeleted, corev1.ConditionTrue) {
+		// Prune the manifests after the delete job (failures are logged and recorded but do not block the deletion)
+		if meta.HasFinalizer(t, setup.Finalizer) && setup.NeedsJob(t, setup.ModeDelete) {
+			if err := setup.PruneManifests(ctx, r.Client, t); err != nil {
+				r.Log.WithValues("trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name)).Error(err, "Failed to prune setup task manifests")
+				trial.ApplyCondition(&t.Status, optimizev1beta2.TrialSetupDeleted, corev1.ConditionTrue, "PruneFailed", err.Error(), probeTime)
+			}
+		}
+
 		if meta.RemoveFinalizer(t, setup.Finalizer) {
 			err := r.Update(ctx, t)
 			return controller.RequeueConflict(err)
@@ -259,3 +395,20 @@ func (r *SetupReconciler) finish(ctx context.Context, t *optimizev1beta2.Trial)
 
 	return nil, nil
 }
+
+// applyOutputs records the outputs of the setup tasks on the trial status, returning true if the status changed
+func (r *SetupReconciler) applyOutputs(ctx context.Context, t *optimizev1beta2.Trial) (bool, error) {
+	outputs, err := setup.ReadOutputs(ctx, r, t)
+	if err != nil {
+		return false, err
+	}
+
+	refs, err := setup.ReadSecretOutputs(ctx, r.apiReader, t)
+	if err != nil {
+		return false, err
+	}
+
+	dirty := setup.ApplyOutputs(&t.Status, outputs)
+	dirty = setup.ApplySecretOutputs(&t.Status, refs) || dirty
+	return dirty, setup.CheckOutputsEnv(t)
+}
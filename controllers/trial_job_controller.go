@@ -31,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -41,11 +42,16 @@ type TrialJobReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	diagnostics trial.DiagnosticsReader
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=batch;extensions,resources=jobs,verbs=get;list;watch;create;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=list
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
 
 func (r *TrialJobReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -93,6 +99,13 @@ func (r *TrialJobReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 }
 
 func (r *TrialJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Logs and events are read directly from the API server when a trial fails
+	c, err := corev1client.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.diagnostics = trial.NewDiagnosticsReader(c)
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("trial-job").
 		For(&optimizev1beta2.Trial{}).
@@ -129,6 +142,9 @@ func (r *TrialJobReconciler) ignoreTrial(t *optimizev1beta2.Trial) bool {
 func (r *TrialJobReconciler) updateStatus(ctx context.Context, t *optimizev1beta2.Trial, jobList *batchv1.JobList, probeTime *metav1.Time) (*ctrl.Result, error) {
 	for i := range jobList.Items {
 		if update, requeue := r.applyJobStatus(ctx, t, &jobList.Items[i], probeTime); update {
+			if trial.CheckCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue) {
+				r.archiveDiagnostics(ctx, t, &jobList.Items[i])
+			}
 			err := r.Update(ctx, t)
 			return controller.RequeueConflict(err)
 		} else if requeue {
@@ -140,6 +156,27 @@ func (r *TrialJobReconciler) updateStatus(ctx context.Context, t *optimizev1beta
 	return nil, nil
 }
 
+// archiveDiagnostics captures the container logs and events of a failed trial job so they are still available
+// after the pods are removed; failures are logged but otherwise ignored
+func (r *TrialJobReconciler) archiveDiagnostics(ctx context.Context, t *optimizev1beta2.Trial, job *batchv1.Job) {
+	if r.diagnostics == nil {
+		return
+	}
+
+	podList := &corev1.PodList{}
+	if matchingSelector, err := meta.MatchingSelector(job.Spec.Selector); err == nil {
+		_ = r.List(ctx, podList, client.InNamespace(job.Namespace), matchingSelector)
+	}
+
+	data, err := trial.CollectDiagnostics(r.diagnostics, job, podList.Items)
+	if err == nil {
+		err = trial.ArchiveDiagnostics(ctx, r, r.Scheme, t, data)
+	}
+	if err != nil {
+		r.Log.WithValues("trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name)).Error(err, "Failed to archive trial diagnostics")
+	}
+}
+
 // createJob will create a new trial run job
 func (r *TrialJobReconciler) createJob(ctx context.Context, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
 	job := trial.NewJob(t)
@@ -188,14 +225,14 @@ func (r *TrialJobReconciler) applyJobStatus(ctx context.Context, t *optimizev1be
 			for i := range podList.Items {
 				s := &podList.Items[i].Status
 				if s.Phase == corev1.PodFailed {
-					trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, s.Reason, "trial pod failed", time)
+					trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfeasible, s.Reason, "trial pod failed", time)
 					dirty = true
 				}
 
 				// TODO We should consolidate this with `internal/ready/podFailed`
 				for _, c := range s.Conditions {
 					if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
-						trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, c.Reason, fmt.Sprintf("trial pod: %s", c.Message), time)
+						trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfrastructure, c.Reason, fmt.Sprintf("trial pod: %s", c.Message), time)
 
 						// Patch the job and set parallelism to 0 to suspend the job and terminate any active pods
 						if err := r.Patch(ctx, job, client.RawPatch(types.StrategicMergePatchType, []byte(`{ "spec": { "parallelism": 0  } }`))); err != nil {
@@ -229,7 +266,7 @@ func (r *TrialJobReconciler) applyJobStatus(ctx context.Context, t *optimizev1be
 	// Mark the trial as failed if the job itself failed
 	for _, c := range job.Status.Conditions {
 		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
-			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, c.Reason, c.Message, time)
+			trial.ApplyFailure(&t.Status, optimizev1beta2.FailureInfeasible, c.Reason, c.Message, time)
 			dirty = true
 		}
 	}
@@ -0,0 +1,468 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/patch"
+	"github.com/thestormforge/optimize-controller/v2/internal/resources"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InsufficientCapacityError indicates a trial does not fit in the available cluster capacity
+type InsufficientCapacityError struct {
+	// Flag indicating the trial can never fit, regardless of what else is running
+	Infeasible bool
+	// Message describing the capacity shortfall
+	Message string
+}
+
+// Error returns the message describing the capacity shortfall
+func (e *InsufficientCapacityError) Error() string {
+	return e.Message
+}
+
+// Footprint is the estimated change in requested resources needed to run a trial
+type Footprint struct {
+	// Namespaces is the change in requested resources for each namespace
+	Namespaces map[string]corev1.ResourceList
+	// Limits is the change in resource limits for each namespace
+	Limits map[string]corev1.ResourceList
+	// Pod is the largest request of any single patched pod
+	Pod corev1.ResourceList
+}
+
+// Estimate renders the experiment patches for a trial to compute the change in requested resources of the patched
+// workloads. Targets that cannot be found or patches that cannot be evaluated locally are ignored.
+func Estimate(ctx context.Context, r client.Reader, exp *optimizev1beta2.Experiment, t *optimizev1beta2.Trial) (*Footprint, error) {
+	fp := &Footprint{
+		Namespaces: make(map[string]corev1.ResourceList),
+		Limits:     make(map[string]corev1.ResourceList),
+		Pod:        corev1.ResourceList{},
+	}
+
+	te := template.New()
+	for i := range exp.Spec.Patches {
+		p := &exp.Spec.Patches[i]
+
+		ref, data, err := patch.RenderTemplate(te, t, p)
+		if err != nil {
+			return nil, err
+		}
+
+		po, err := patch.CreatePatchOperation(t, p, ref, data)
+		if err != nil {
+			return nil, err
+		}
+		if po == nil || po.AttemptsRemaining == 0 || ref.GroupVersionKind().Group != appsv1.GroupName {
+			continue
+		}
+
+		before, after, err := workloadRequests(ctx, r, po)
+		if err != nil {
+			return nil, err
+		}
+		if before == nil || after == nil {
+			continue
+		}
+
+		// Accumulate the difference in total requests and limits
+		accumulate(fp.Namespaces, ref.Namespace, after.total(after.pod), before.total(before.pod))
+		accumulate(fp.Limits, ref.Namespace, after.total(after.limits), before.total(before.limits))
+
+		// Track the largest individual pod
+		for rn, q := range after.pod {
+			if cur, ok := fp.Pod[rn]; !ok || q.Cmp(cur) > 0 {
+				fp.Pod[rn] = q
+			}
+		}
+	}
+
+	return fp, nil
+}
+
+var (
+	// schedulableNodes is the field selector for nodes that can accept new pods
+	schedulableNodes = fields.OneTermEqualSelector("spec.unschedulable", "false")
+	// scheduledPods is the field selector for pods that are currently consuming node resources
+	scheduledPods = fields.AndSelectors(
+		fields.OneTermNotEqualSelector("spec.nodeName", ""),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+	)
+)
+
+// Check verifies the footprint fits within the allocatable capacity of the nodes and the resource quotas of the
+// affected namespaces, returning an InsufficientCapacityError if it does not. Nodes and pods are listed across the
+// whole cluster, the reader should not be backed by a cache (which would watch every node and pod).
+func Check(ctx context.Context, r client.Reader, fp *Footprint) error {
+	if err := checkQuotas(ctx, r, fp); err != nil {
+		return err
+	}
+	return checkNodes(ctx, r, fp)
+}
+
+// checkQuotas compares the footprint of each namespace to the remaining resource quota
+func checkQuotas(ctx context.Context, r client.Reader, fp *Footprint) error {
+	for _, ns := range sortedNamespaces(fp) {
+		quotaList := &corev1.ResourceQuotaList{}
+		if err := r.List(ctx, quotaList, client.InNamespace(ns)); err != nil {
+			return err
+		}
+
+		for i := range quotaList.Items {
+			rq := &quotaList.Items[i]
+			for _, rn := range sortedResourceNames(rq.Status.Hard) {
+				requested, ok := quotaUsage(fp, ns, rn)
+				if !ok || requested.Sign() <= 0 {
+					continue
+				}
+
+				hard := rq.Status.Hard[rn]
+				if requested.Cmp(hard) > 0 {
+					return &InsufficientCapacityError{
+						Infeasible: true,
+						Message:    fmt.Sprintf("Trial requires %s of %s which exceeds ResourceQuota %s/%s", requested.String(), rn, ns, rq.Name),
+					}
+				}
+
+				available := hard.DeepCopy()
+				available.Sub(rq.Status.Used[rn])
+				if requested.Cmp(available) > 0 {
+					return &InsufficientCapacityError{
+						Message: fmt.Sprintf("Trial requires %s of %s but only %s is available in ResourceQuota %s/%s", requested.String(), rn, available.String(), ns, rq.Name),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkNodes compares the footprint to the allocatable capacity of the schedulable nodes
+func checkNodes(ctx context.Context, r client.Reader, fp *Footprint) error {
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList, client.MatchingFieldsSelector{Selector: schedulableNodes}); err != nil {
+		return err
+	}
+	if len(nodeList.Items) == 0 {
+		return nil
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingFieldsSelector{Selector: scheduledPods}); err != nil {
+		return err
+	}
+
+	// Compute the requests already scheduled to each node
+	scheduled := make(map[string]corev1.ResourceList, len(nodeList.Items))
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if scheduled[pod.Spec.NodeName] == nil {
+			scheduled[pod.Spec.NodeName] = corev1.ResourceList{}
+		}
+		for rn, q := range PodRequests(&pod.Spec) {
+			add(scheduled[pod.Spec.NodeName], rn, q)
+		}
+	}
+
+	// Total the footprint across namespaces
+	requested := corev1.ResourceList{}
+	for _, rl := range fp.Namespaces {
+		for rn, q := range rl {
+			add(requested, rn, q)
+		}
+	}
+
+	for _, rn := range sortedResourceNames(fp.Pod) {
+		podRequest := fp.Pod[rn]
+		var fits bool
+		available := resource.Quantity{}
+		for i := range nodeList.Items {
+			node := &nodeList.Items[i]
+			if node.Spec.Unschedulable {
+				continue
+			}
+
+			allocatable, ok := node.Status.Allocatable[rn]
+			if !ok {
+				continue
+			}
+			fits = fits || podRequest.Cmp(allocatable) <= 0
+
+			free := allocatable.DeepCopy()
+			free.Sub(scheduled[node.Name][rn])
+			if free.Sign() > 0 {
+				available.Add(free)
+			}
+		}
+
+		if !fits {
+			return &InsufficientCapacityError{
+				Infeasible: true,
+				Message:    fmt.Sprintf("No node has enough allocatable %s for a pod requesting %s", rn, podRequest.String()),
+			}
+		}
+
+		if q, ok := requested[rn]; ok && q.Cmp(available) > 0 {
+			return &InsufficientCapacityError{
+				Message: fmt.Sprintf("Trial requires %s of %s but only %s is available on the cluster nodes", q.String(), rn, available.String()),
+			}
+		}
+	}
+
+	return nil
+}
+
+// workload is the portion of a Deployment, StatefulSet or ReplicaSet used to compute the resource requests
+type workload struct {
+	Spec struct {
+		Replicas *int32                 `json:"replicas"`
+		Template corev1.PodTemplateSpec `json:"template"`
+	} `json:"spec"`
+}
+
+// workloadResources are the resource requests and limits of a workload
+type workloadResources struct {
+	replicas int64
+	pod      corev1.ResourceList
+	limits   corev1.ResourceList
+}
+
+// total returns the supplied per-pod resources across all replicas
+func (w *workloadResources) total(pod corev1.ResourceList) corev1.ResourceList {
+	rl := corev1.ResourceList{}
+	for rn, q := range pod {
+		rl[rn] = *resource.NewMilliQuantity(q.MilliValue()*w.replicas, q.Format)
+	}
+	return rl
+}
+
+// workloadRequests returns the resource requests of the patch target before and after the patch is applied
+func workloadRequests(ctx context.Context, r client.Reader, po *optimizev1beta2.PatchOperation) (*workloadResources, *workloadResources, error) {
+	// RBAC: We assume that we have "get" permission from a customer defined role since we are allowed to patch the target
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(po.TargetRef.GroupVersionKind())
+	if err := r.Get(ctx, types.NamespacedName{Namespace: po.TargetRef.Namespace, Name: po.TargetRef.Name}, u); err != nil {
+		if apierrs.IsNotFound(err) || apierrs.IsForbidden(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	original, err := u.MarshalJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var patched []byte
+	switch po.PatchType {
+	case types.StrategicMergePatchType:
+		var schema interface{} = &appsv1.Deployment{}
+		if po.TargetRef.Kind == "StatefulSet" {
+			schema = &appsv1.StatefulSet{}
+		}
+		if patched, err = strategicpatch.StrategicMergePatch(original, po.Data, schema); err != nil {
+			return nil, nil, err
+		}
+	case types.MergePatchType:
+		if patched, err = mergePatch(original, po.Data); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, nil
+	}
+
+	before, err := decodeWorkload(original)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := decodeWorkload(patched)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// decodeWorkload computes the resource requests and limits of a workload
+func decodeWorkload(data []byte) (*workloadResources, error) {
+	w := &workload{}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+
+	wr := &workloadResources{replicas: 1, pod: PodRequests(&w.Spec.Template.Spec), limits: PodLimits(&w.Spec.Template.Spec)}
+	if w.Spec.Replicas != nil {
+		wr.replicas = int64(*w.Spec.Replicas)
+	}
+	return wr, nil
+}
+
+// PodRequests returns the effective resource requests of a pod: the sum of the container requests or the largest
+// init container request, whichever is greater.
+func PodRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	rl := corev1.ResourceList{}
+	for i := range spec.Containers {
+		for rn, q := range ContainerRequests(&spec.Containers[i].Resources) {
+			add(rl, rn, q)
+		}
+	}
+	for i := range spec.InitContainers {
+		for rn, q := range ContainerRequests(&spec.InitContainers[i].Resources) {
+			if cur, ok := rl[rn]; !ok || q.Cmp(cur) > 0 {
+				rl[rn] = q.DeepCopy()
+			}
+		}
+	}
+	return rl
+}
+
+// PodLimits returns the effective resource limits of a pod: the sum of the container limits or the largest init
+// container limit, whichever is greater. Omitted limits are not included.
+func PodLimits(spec *corev1.PodSpec) corev1.ResourceList {
+	rl := corev1.ResourceList{}
+	for i := range spec.Containers {
+		for rn, q := range spec.Containers[i].Resources.Limits {
+			add(rl, rn, q)
+		}
+	}
+	for i := range spec.InitContainers {
+		for rn, q := range spec.InitContainers[i].Resources.Limits {
+			if cur, ok := rl[rn]; !ok || q.Cmp(cur) > 0 {
+				rl[rn] = q.DeepCopy()
+			}
+		}
+	}
+	return rl
+}
+
+// ContainerRequests returns the effective resource requests of a container, an omitted request defaults to the
+// limit (if it is specified) or the first of the supplied default requests which includes the resource.
+func ContainerRequests(rr *corev1.ResourceRequirements, defaults ...corev1.ResourceList) corev1.ResourceList {
+	lists := append([]corev1.ResourceList{rr.Requests, rr.Limits}, defaults...)
+
+	rl := corev1.ResourceList{}
+	for i := range lists {
+		for rn := range lists[i] {
+			if _, ok := rl[rn]; !ok {
+				rl[rn] = resources.LookupQuantity(rn, lists...)
+			}
+		}
+	}
+	return rl
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386)
+func mergePatch(original, patch []byte) ([]byte, error) {
+	var o, p interface{}
+	if err := json.Unmarshal(original, &o); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(o, p))
+}
+
+func mergeValue(original, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	om, ok := original.(map[string]interface{})
+	if !ok {
+		om = make(map[string]interface{}, len(pm))
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(om, k)
+		} else {
+			om[k] = mergeValue(om[k], v)
+		}
+	}
+	return om
+}
+
+// quotaUsage returns the change in usage of a resource quota from the footprint of a namespace, both "requests." and
+// "limits." quotas are checked (unprefixed compute resources are requests)
+func quotaUsage(fp *Footprint, ns string, rn corev1.ResourceName) (resource.Quantity, bool) {
+	if name := strings.TrimPrefix(string(rn), "limits."); name != string(rn) {
+		q, ok := fp.Limits[ns][corev1.ResourceName(name)]
+		return q, ok
+	}
+	q, ok := fp.Namespaces[ns][corev1.ResourceName(strings.TrimPrefix(string(rn), "requests."))]
+	return q, ok
+}
+
+// accumulate adds the difference between two resource lists to the namespace total
+func accumulate(totals map[string]corev1.ResourceList, ns string, after, before corev1.ResourceList) {
+	total := totals[ns]
+	if total == nil {
+		total = corev1.ResourceList{}
+		totals[ns] = total
+	}
+	for rn, q := range after {
+		add(total, rn, q)
+	}
+	for rn, q := range before {
+		q.Neg()
+		add(total, rn, q)
+	}
+}
+
+// add accumulates a quantity into a resource list
+func add(rl corev1.ResourceList, rn corev1.ResourceName, q resource.Quantity) {
+	cur := rl[rn]
+	cur.Add(q)
+	rl[rn] = cur
+}
+
+func sortedNamespaces(fp *Footprint) []string {
+	namespaces := make([]string, 0, len(fp.Namespaces))
+	for ns := range fp.Namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+func sortedResourceNames(rl corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(rl))
+	for rn := range rl {
+		names = append(names, rn)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
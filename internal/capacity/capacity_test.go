@@ -0,0 +1,192 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheck(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "web",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("500m"),
+				}},
+			}}}},
+		},
+	}
+
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Patches: []optimizev1beta2.PatchTemplate{{
+				TargetRef: &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+				Patch:     "spec:\n  template:\n    spec:\n      containers:\n      - name: web\n        resources:\n          requests:\n            cpu: {{ .Values.cpu }}m\n          limits:\n            cpu: {{ .Values.cpu }}m\n",
+			}},
+		},
+	}
+
+	node := func(cpu string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-" + cpu},
+			Status:     corev1.NodeStatus{Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+		}
+	}
+
+	quota := func(rn corev1.ResourceName, hard, used string) *corev1.ResourceQuota {
+		return &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "default"},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{rn: resource.MustParse(hard)},
+				Used: corev1.ResourceList{rn: resource.MustParse(used)},
+			},
+		}
+	}
+
+	testCases := []struct {
+		desc       string
+		cpu        int
+		objs       []runtime.Object
+		infeasible bool
+		message    string
+	}{
+		{
+			desc: "fits",
+			cpu:  1000,
+			objs: []runtime.Object{node("4"), quota(corev1.ResourceRequestsCPU, "4", "1")},
+		},
+		{
+			desc: "decrease",
+			cpu:  250,
+			objs: []runtime.Object{node("4"), quota(corev1.ResourceRequestsCPU, "1", "1")},
+		},
+		{
+			desc:       "pod too large",
+			cpu:        3000,
+			objs:       []runtime.Object{node("2")},
+			infeasible: true,
+			message:    "No node has enough allocatable cpu for a pod requesting 3",
+		},
+		{
+			desc: "nodes busy",
+			cpu:  1500,
+			objs: []runtime.Object{node("2"), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+				Spec: corev1.PodSpec{NodeName: "node-2", Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+				}}},
+			}},
+			message: "Trial requires 2 of cpu but only 1500m is available on the cluster nodes",
+		},
+		{
+			desc: "nodes busy limits",
+			cpu:  1500,
+			objs: []runtime.Object{node("2"), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+				Spec: corev1.PodSpec{NodeName: "node-2", Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+				}}},
+			}},
+			message: "Trial requires 2 of cpu but only 1500m is available on the cluster nodes",
+		},
+		{
+			desc:       "quota too small",
+			cpu:        2000,
+			objs:       []runtime.Object{node("4"), quota(corev1.ResourceRequestsCPU, "2", "1")},
+			infeasible: true,
+			message:    "Trial requires 3 of requests.cpu which exceeds ResourceQuota default/quota",
+		},
+		{
+			desc:    "quota used",
+			cpu:     1000,
+			objs:    []runtime.Object{node("4"), quota(corev1.ResourceRequestsCPU, "2", "1500m")},
+			message: "Trial requires 1 of requests.cpu but only 500m is available in ResourceQuota default/quota",
+		},
+		{
+			desc:       "limits quota too small",
+			cpu:        1500,
+			objs:       []runtime.Object{node("4"), quota(corev1.ResourceLimitsCPU, "2", "0")},
+			infeasible: true,
+			message:    "Trial requires 3 of limits.cpu which exceeds ResourceQuota default/quota",
+		},
+		{
+			desc:    "limits quota used",
+			cpu:     1000,
+			objs:    []runtime.Object{node("4"), quota(corev1.ResourceLimitsCPU, "4", "3")},
+			message: "Trial requires 2 of limits.cpu but only 1 is available in ResourceQuota default/quota",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.TODO()
+			c := fake.NewFakeClientWithScheme(clientgoscheme.Scheme, append(tc.objs, deployment.DeepCopy())...)
+
+			trial := &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{{Name: "cpu", Value: intstr.FromInt(tc.cpu)}},
+				},
+			}
+
+			fp, err := Estimate(ctx, c, exp, trial)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = Check(ctx, c, fp)
+			if tc.message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if ice, ok := err.(*InsufficientCapacityError); assert.True(t, ok, "expected insufficient capacity error: %v", err) {
+				assert.Equal(t, tc.infeasible, ice.Infeasible)
+				assert.Equal(t, tc.message, ice.Message)
+			}
+		})
+	}
+}
+
+func TestContainerRequests(t *testing.T) {
+	rr := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	defaults := corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi"), corev1.ResourceEphemeralStorage: resource.MustParse("1Gi")}
+
+	assert.Equal(t, corev1.ResourceList{
+		corev1.ResourceCPU:              resource.MustParse("100m"),
+		corev1.ResourceMemory:           resource.MustParse("1Gi"),
+		corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+	}, ContainerRequests(rr, defaults))
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Monday, 2021-03-01 10:07 UTC
+	now := time.Date(2021, time.March, 1, 10, 7, 30, 0, time.UTC)
+
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "* * * * *", expected: time.Date(2021, time.March, 1, 10, 8, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2021, time.March, 1, 10, 15, 0, 0, time.UTC)},
+		{spec: "0 9 * * *", expected: time.Date(2021, time.March, 2, 9, 0, 0, 0, time.UTC)},
+		{spec: "30 8-17/4 * * *", expected: time.Date(2021, time.March, 1, 12, 30, 0, 0, time.UTC)},
+		{spec: "0 0 * * 0", expected: time.Date(2021, time.March, 7, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expected: time.Date(2021, time.March, 7, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1,15 * *", expected: time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 15 * 3", expected: time.Date(2021, time.March, 3, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1 1 *", expected: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 31 2 *", expected: time.Time{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, s.Next(now))
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.Error(t, err)
+		})
+	}
+}
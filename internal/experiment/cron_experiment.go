@@ -0,0 +1,144 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/cron"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MaxCronExperimentNameLength is the longest cron experiment name, leaving room for the scheduled time suffix of
+	// the experiment names (which are also used as label values)
+	MaxCronExperimentNameLength = 52
+
+	// maxMissedSchedules is the number of missed schedule times considered before giving up
+	maxMissedSchedules = 100
+)
+
+// ErrTooManyMissedSchedules indicates the cron experiment missed too many scheduled times to determine the last one.
+var ErrTooManyMissedSchedules = errors.New("too many missed schedules")
+
+// CheckName verifies the cron experiment name is short enough to generate experiment names from.
+func CheckName(ce *optimizev1beta2.CronExperiment) error {
+	if len(ce.Name) > MaxCronExperimentNameLength {
+		return fmt.Errorf("cron experiment name must be no more than %d characters", MaxCronExperimentNameLength)
+	}
+	return nil
+}
+
+// ScheduledTimes returns the most recent time the cron experiment was scheduled to run since it last ran (or a zero
+// time if there were no scheduled times) and the next time it is scheduled to run. If more than 100 scheduled times
+// were missed (e.g. after a long controller outage), ErrTooManyMissedSchedules is returned.
+func ScheduledTimes(ce *optimizev1beta2.CronExperiment, now time.Time) (time.Time, time.Time, error) {
+	sched, err := cron.Parse(ce.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	loc, err := time.LoadLocation(ce.Spec.TimeZone)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid time zone: %w", err)
+	}
+
+	earliest := ce.CreationTimestamp.Time
+	if ce.Status.LastScheduleTime != nil {
+		earliest = ce.Status.LastScheduleTime.Time
+	}
+
+	var last time.Time
+	next := sched.Next(earliest.In(loc))
+	for missed := 0; !next.IsZero() && !next.After(now); missed++ {
+		if missed >= maxMissedSchedules {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w (more than %d since %s)", ErrTooManyMissedSchedules, maxMissedSchedules, earliest.Format(time.RFC3339))
+		}
+		last = next
+		next = sched.Next(next)
+	}
+	return last, next, nil
+}
+
+// RunName returns the name of the experiment created for the supplied scheduled time.
+func RunName(ce *optimizev1beta2.CronExperiment, scheduledTime time.Time) string {
+	return fmt.Sprintf("%s-%d", ce.Name, scheduledTime.Unix()/60)
+}
+
+// CronExperimentRuns groups the experiments created by a cron experiment, each group is ordered from oldest to newest.
+func CronExperimentRuns(exps []optimizev1beta2.Experiment) (active, successful, failed []*optimizev1beta2.Experiment) {
+	sorted := make([]*optimizev1beta2.Experiment, 0, len(exps))
+	for i := range exps {
+		sorted = append(sorted, &exps[i])
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+	})
+
+	for _, exp := range sorted {
+		switch {
+		case checkCondition(exp, optimizev1beta2.ExperimentFailed):
+			failed = append(failed, exp)
+		case checkCondition(exp, optimizev1beta2.ExperimentComplete):
+			successful = append(successful, exp)
+		default:
+			active = append(active, exp)
+		}
+	}
+	return active, successful, failed
+}
+
+// ExpiredRuns returns the finished experiments exceeding the history limits of the cron experiment.
+func ExpiredRuns(ce *optimizev1beta2.CronExperiment, successful, failed []*optimizev1beta2.Experiment) []*optimizev1beta2.Experiment {
+	var expired []*optimizev1beta2.Experiment
+	expired = append(expired, oldest(successful, historyLimit(ce.Spec.SuccessfulExperimentsHistoryLimit, 3))...)
+	expired = append(expired, oldest(failed, historyLimit(ce.Spec.FailedExperimentsHistoryLimit, 1))...)
+	return expired
+}
+
+// checkCondition checks to see if the experiment has a true condition of the supplied type
+func checkCondition(exp *optimizev1beta2.Experiment, conditionType optimizev1beta2.ExperimentConditionType) bool {
+	for _, c := range exp.Status.Conditions {
+		if c.Type == conditionType {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// historyLimit returns the effective history limit
+func historyLimit(limit *int32, defaultLimit int) int {
+	if limit == nil {
+		return defaultLimit
+	}
+	if *limit < 0 {
+		return 0
+	}
+	return int(*limit)
+}
+
+// oldest returns the items of the sorted list beyond the supplied limit
+func oldest(exps []*optimizev1beta2.Experiment, limit int) []*optimizev1beta2.Experiment {
+	if len(exps) <= limit {
+		return nil
+	}
+	return exps[:len(exps)-limit]
+}
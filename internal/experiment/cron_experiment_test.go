@@ -0,0 +1,237 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewFromTemplate(t *testing.T) {
+	tmpl := &optimizev1beta2.ExperimentTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "release"},
+		Variables: []optimizev1beta2.TemplateVariable{
+			{Name: "IMAGE_TAG", Value: "latest"},
+			{Name: "RELEASE", Value: "dev"},
+		},
+		Template: optimizev1beta2.ExperimentTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"release": "$(RELEASE)"}},
+			Spec: optimizev1beta2.ExperimentSpec{
+				Patches: []optimizev1beta2.PatchTemplate{
+					{Patch: `{"spec":{"template":{"spec":{"containers":[{"name":"app","image":"app:$(IMAGE_TAG)","resources":{"limits":{"cpu":"{{ .Values.cpu }}m"}}}]}}}}`},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc          string
+		variables     []optimizev1beta2.TemplateVariable
+		expectedLabel string
+		expectedPatch string
+		expectedErr   string
+	}{
+		{
+			desc:          "defaults",
+			expectedLabel: "dev",
+			expectedPatch: `{"spec":{"template":{"spec":{"containers":[{"name":"app","image":"app:latest","resources":{"limits":{"cpu":"{{ .Values.cpu }}m"}}}]}}}}`,
+		},
+		{
+			desc: "override",
+			variables: []optimizev1beta2.TemplateVariable{
+				{Name: "IMAGE_TAG", Value: `1.2."3"`},
+				{Name: "RELEASE", Value: "v1.2.3"},
+			},
+			expectedLabel: "v1.2.3",
+			expectedPatch: `{"spec":{"template":{"spec":{"containers":[{"name":"app","image":"app:1.2."3"","resources":{"limits":{"cpu":"{{ .Values.cpu }}m"}}}]}}}}`,
+		},
+		{
+			desc:        "undeclared",
+			variables:   []optimizev1beta2.TemplateVariable{{Name: "OTHER", Value: "x"}},
+			expectedErr: `variable "OTHER" is not declared by experiment template "release"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			exp, err := NewFromTemplate(tmpl, tc.variables)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedLabel, exp.Labels["release"])
+				assert.Equal(t, tc.expectedPatch, exp.Spec.Patches[0].Patch)
+			}
+		})
+	}
+
+	// The template itself must not be modified
+	assert.Equal(t, "$(RELEASE)", tmpl.Template.Labels["release"])
+}
+
+func TestScheduledTimes(t *testing.T) {
+	// Monday, 2021-03-01 22:30 UTC
+	now := time.Date(2021, time.March, 1, 22, 30, 0, 0, time.UTC)
+	created := metav1.NewTime(time.Date(2021, time.February, 27, 12, 0, 0, 0, time.UTC))
+
+	testCases := []struct {
+		desc             string
+		schedule         string
+		timeZone         string
+		lastScheduleTime *metav1.Time
+		expectedLast     time.Time
+		expectedNext     time.Time
+		expectedErr      bool
+	}{
+		{
+			desc:         "missed runs",
+			schedule:     "0 22 * * *",
+			expectedLast: time.Date(2021, time.March, 1, 22, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2021, time.March, 2, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:             "already ran",
+			schedule:         "0 22 * * *",
+			lastScheduleTime: &metav1.Time{Time: time.Date(2021, time.March, 1, 22, 0, 0, 0, time.UTC)},
+			expectedNext:     time.Date(2021, time.March, 2, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:         "time zone",
+			schedule:     "0 9 * * 1",
+			timeZone:     "America/New_York",
+			expectedLast: time.Date(2021, time.March, 1, 14, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2021, time.March, 8, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:        "invalid schedule",
+			schedule:    "0 22 * *",
+			expectedErr: true,
+		},
+		{
+			desc:             "too many missed",
+			schedule:         "* * * * *",
+			lastScheduleTime: &metav1.Time{Time: time.Date(2021, time.March, 1, 20, 0, 0, 0, time.UTC)},
+			expectedErr:      true,
+		},
+		{
+			desc:             "missed limit",
+			schedule:         "* * * * *",
+			lastScheduleTime: &metav1.Time{Time: time.Date(2021, time.March, 1, 20, 50, 0, 0, time.UTC)},
+			expectedLast:     time.Date(2021, time.March, 1, 22, 30, 0, 0, time.UTC),
+			expectedNext:     time.Date(2021, time.March, 1, 22, 31, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ce := &optimizev1beta2.CronExperiment{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+				Spec:       optimizev1beta2.CronExperimentSpec{Schedule: tc.schedule, TimeZone: tc.timeZone},
+				Status:     optimizev1beta2.CronExperimentStatus{LastScheduleTime: tc.lastScheduleTime},
+			}
+			last, next, err := ScheduledTimes(ce, now)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.True(t, tc.expectedLast.Equal(last), "expected last %s, got %s", tc.expectedLast, last)
+				assert.True(t, tc.expectedNext.Equal(next), "expected next %s, got %s", tc.expectedNext, next)
+			}
+		})
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		name        string
+		expectedErr bool
+	}{
+		{
+			desc: "short",
+			name: "nightly",
+		},
+		{
+			desc: "max length",
+			name: strings.Repeat("a", MaxCronExperimentNameLength),
+		},
+		{
+			desc:        "too long",
+			name:        strings.Repeat("a", MaxCronExperimentNameLength+1),
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := CheckName(&optimizev1beta2.CronExperiment{ObjectMeta: metav1.ObjectMeta{Name: tc.name}})
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExpiredRuns(t *testing.T) {
+	run := func(name string, day int, conditionType optimizev1beta2.ExperimentConditionType) optimizev1beta2.Experiment {
+		exp := optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Date(2021, time.March, day, 0, 0, 0, 0, time.UTC)),
+		}}
+		if conditionType != "" {
+			exp.Status.Conditions = []optimizev1beta2.ExperimentCondition{{Type: conditionType, Status: corev1.ConditionTrue}}
+		}
+		return exp
+	}
+
+	exps := []optimizev1beta2.Experiment{
+		run("six", 6, ""),
+		run("five", 5, optimizev1beta2.ExperimentComplete),
+		run("one", 1, optimizev1beta2.ExperimentComplete),
+		run("four", 4, optimizev1beta2.ExperimentFailed),
+		run("two", 2, optimizev1beta2.ExperimentFailed),
+		run("three", 3, optimizev1beta2.ExperimentComplete),
+	}
+
+	active, successful, failed := CronExperimentRuns(exps)
+	assert.Equal(t, []string{"six"}, names(active))
+	assert.Equal(t, []string{"one", "three", "five"}, names(successful))
+	assert.Equal(t, []string{"two", "four"}, names(failed))
+
+	ce := &optimizev1beta2.CronExperiment{}
+	assert.Equal(t, []string{"two"}, names(ExpiredRuns(ce, successful, failed)))
+
+	one, zero := int32(1), int32(0)
+	ce.Spec.SuccessfulExperimentsHistoryLimit = &one
+	ce.Spec.FailedExperimentsHistoryLimit = &zero
+	assert.Equal(t, []string{"one", "three", "two", "four"}, names(ExpiredRuns(ce, successful, failed)))
+}
+
+func names(exps []*optimizev1beta2.Experiment) []string {
+	var result []string
+	for _, exp := range exps {
+		result = append(result, exp.Name)
+	}
+	return result
+}
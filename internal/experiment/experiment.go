@@ -49,6 +49,17 @@ const (
 	PhaseFailed = "Failed"
 	// PhaseDeleted indicates that the experiment has been deleted and is waiting for trials to be cleaned up
 	PhaseDeleted = "Deleted"
+	// PhaseQueued indicates that the experiment is waiting for other trials to finish before it can start a trial
+	PhaseQueued = "Queued"
+	// PhaseWaitingForSchedule indicates that the experiment is waiting for a schedule window to start a trial
+	PhaseWaitingForSchedule = "Waiting for schedule"
+)
+
+const (
+	// ReasonQueued indicates an experiment is waiting for the cluster-wide concurrent trial limit
+	ReasonQueued = "Queued"
+	// ReasonSchedule indicates an experiment is waiting for a schedule window
+	ReasonSchedule = "Schedule"
 )
 
 // UpdateStatus will ensure the experiment's status matches what is in the supplied trial list; returns true only if
@@ -112,6 +123,10 @@ func summarize(exp *optimizev1beta2.Experiment, activeTrials int32, totalTrials
 		return PhasePaused
 	}
 
+	if phase := waitingPhase(exp); phase != "" {
+		return phase
+	}
+
 	if totalTrials == 0 {
 		if exp.Annotations[optimizev1beta2.AnnotationExperimentURL] != "" {
 			return PhaseCreated
@@ -122,6 +137,68 @@ func summarize(exp *optimizev1beta2.Experiment, activeTrials int32, totalTrials
 	return PhaseIdle
 }
 
+// waitingPhase returns the phase corresponding to the reason an experiment is waiting to start trials
+func waitingPhase(exp *optimizev1beta2.Experiment) string {
+	for _, c := range exp.Status.Conditions {
+		if c.Type != optimizev1beta2.ExperimentWaiting || c.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch c.Reason {
+		case ReasonQueued:
+			return PhaseQueued
+		case ReasonSchedule:
+			return PhaseWaitingForSchedule
+		}
+	}
+	return ""
+}
+
+// ApplyWaiting records the reason an experiment is waiting to start new trials (an empty reason indicates the
+// experiment is not waiting), returning true only if the status changed
+func ApplyWaiting(exp *optimizev1beta2.Experiment, reason, message string) bool {
+	status := corev1.ConditionTrue
+	if reason == "" {
+		status = corev1.ConditionFalse
+	}
+
+	for i := range exp.Status.Conditions {
+		c := &exp.Status.Conditions[i]
+		if c.Type != optimizev1beta2.ExperimentWaiting {
+			continue
+		}
+
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return false
+		}
+		if c.Status == status {
+			// Switching between waiting for the schedule and the queue starts a new wait
+			if c.Reason != reason {
+				c.LastTransitionTime = metav1.Now()
+			}
+			c.Reason, c.Message = reason, message
+			return true
+		}
+	}
+
+	// Do not add the condition if the experiment was never waiting
+	if reason == "" && !hasCondition(exp, optimizev1beta2.ExperimentWaiting) {
+		return false
+	}
+
+	ApplyCondition(&exp.Status, optimizev1beta2.ExperimentWaiting, status, reason, message, nil)
+	return true
+}
+
+func hasCondition(exp *optimizev1beta2.Experiment, conditionType optimizev1beta2.ExperimentConditionType) bool {
+	for _, c := range exp.Status.Conditions {
+		if c.Type == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
 func IsFinished(exp *optimizev1beta2.Experiment) bool {
 	for _, c := range exp.Status.Conditions {
 		if c.Status == corev1.ConditionTrue {
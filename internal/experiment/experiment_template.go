@@ -0,0 +1,73 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+// NewFromTemplate returns a new experiment from the supplied template. Variable references of the form "$(NAME)"
+// are replaced using the supplied variables, falling back to the defaults of the template; only variables declared
+// by the template can be supplied. Note that the "{{ }}" syntax used by patch templates is left untouched.
+func NewFromTemplate(tmpl *optimizev1beta2.ExperimentTemplate, variables []optimizev1beta2.TemplateVariable) (*optimizev1beta2.Experiment, error) {
+	values := make(map[string]string, len(tmpl.Variables))
+	for _, v := range tmpl.Variables {
+		values[v.Name] = v.Value
+	}
+	for _, v := range variables {
+		if _, ok := values[v.Name]; !ok {
+			return nil, fmt.Errorf("variable %q is not declared by experiment template %q", v.Name, tmpl.Name)
+		}
+		values[v.Name] = v.Value
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Substitute the JSON encoded form of the values so they are properly escaped
+	oldnew := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		value, err := json.Marshal(values[name])
+		if err != nil {
+			return nil, err
+		}
+		oldnew = append(oldnew, "$("+name+")", string(value[1:len(value)-1]))
+	}
+
+	data, err := json.Marshal(&tmpl.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &optimizev1beta2.ExperimentTemplateSpec{}
+	if err := json.Unmarshal([]byte(strings.NewReplacer(oldnew...).Replace(string(data))), t); err != nil {
+		return nil, fmt.Errorf("unable to render experiment template %q: %w", tmpl.Name, err)
+	}
+
+	exp := &optimizev1beta2.Experiment{}
+	t.ObjectMeta.DeepCopyInto(&exp.ObjectMeta)
+	t.Spec.DeepCopyInto(&exp.Spec)
+	return exp, nil
+}
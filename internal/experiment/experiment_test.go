@@ -181,6 +181,53 @@ func TestSummarize(t *testing.T) {
 			},
 			expectedPhase: PhaseFailed,
 		},
+		{
+			desc: "queued",
+			experiment: &optimizev1beta2.Experiment{
+				Status: optimizev1beta2.ExperimentStatus{
+					Conditions: []optimizev1beta2.ExperimentCondition{
+						{
+							Type:   optimizev1beta2.ExperimentWaiting,
+							Status: corev1.ConditionTrue,
+							Reason: ReasonQueued,
+						},
+					},
+				},
+			},
+			totalTrials:   1,
+			expectedPhase: PhaseQueued,
+		},
+		{
+			desc: "queued active trials",
+			experiment: &optimizev1beta2.Experiment{
+				Status: optimizev1beta2.ExperimentStatus{
+					Conditions: []optimizev1beta2.ExperimentCondition{
+						{
+							Type:   optimizev1beta2.ExperimentWaiting,
+							Status: corev1.ConditionTrue,
+							Reason: ReasonQueued,
+						},
+					},
+				},
+			},
+			activeTrials:  1,
+			expectedPhase: PhaseRunning,
+		},
+		{
+			desc: "waiting for schedule",
+			experiment: &optimizev1beta2.Experiment{
+				Status: optimizev1beta2.ExperimentStatus{
+					Conditions: []optimizev1beta2.ExperimentCondition{
+						{
+							Type:   optimizev1beta2.ExperimentWaiting,
+							Status: corev1.ConditionTrue,
+							Reason: ReasonSchedule,
+						},
+					},
+				},
+			},
+			expectedPhase: PhaseWaitingForSchedule,
+		},
 	}
 
 	for _, tc := range testCases {
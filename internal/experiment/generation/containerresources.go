@@ -21,6 +21,7 @@ import (
 
 	"github.com/thestormforge/konjure/pkg/filters"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/resources"
 	"github.com/thestormforge/optimize-controller/v2/internal/scan"
 	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
 	corev1 "k8s.io/api/core/v1"
@@ -38,6 +39,10 @@ type ContainerResourcesSelector struct {
 	Path string `json:"path,omitempty"`
 	// Names of the resources to select, defaults to ["cpu", "memory"].
 	Resources []corev1.ResourceName `json:"resources,omitempty"`
+	// Granularity of the resources, generated parameters only allow multiples of the step.
+	Step corev1.ResourceList `json:"step,omitempty"`
+	// Use separate parameters for the limits, constrained to be at least the requests.
+	SeparateLimits bool `json:"separateLimits,omitempty"`
 	// Create container resource requirements even if the original object does not contain them.
 	CreateIfNotPresent bool `json:"create,omitempty"`
 	// Per-namespace limit ranges for containers.
@@ -124,8 +129,10 @@ func (s *ContainerResourcesSelector) Map(node *yaml.RNode, meta yaml.ResourceMet
 					fieldPath: node.FieldPath(),
 					value:     node.YNode(),
 				},
-				resources:  s.Resources,
-				limitRange: s.ContainerLimitRange[meta.Namespace],
+				resources:      s.Resources,
+				step:           s.Step,
+				separateLimits: s.SeparateLimits,
+				limitRange:     s.ContainerLimitRange[meta.Namespace],
 			})
 			return node, nil
 		}),
@@ -160,12 +167,15 @@ func (s *ContainerResourcesSelector) saveContainerLimitRange(namespace string, n
 // found by the selector during scanning.
 type containerResourcesParameter struct {
 	pnode
-	resources  []corev1.ResourceName
-	limitRange corev1.LimitRangeItem
+	resources      []corev1.ResourceName
+	step           corev1.ResourceList
+	separateLimits bool
+	limitRange     corev1.LimitRangeItem
 }
 
 var _ PatchSource = &containerResourcesParameter{}
 var _ ParameterSource = &containerResourcesParameter{}
+var _ ConstraintSource = &containerResourcesParameter{}
 
 // Patch produces a YAML filter for updating a strategic merge patch with a parameterized
 // container resources specification.
@@ -186,8 +196,14 @@ func (p *containerResourcesParameter) Patch(name ParameterNamer) (yaml.Filter, e
 		patch := fmt.Sprintf("{{ .Values.%s }}%s", parameterName, ind[rn].Suffix())
 		patchFilter := yaml.SetField(string(rn), yaml.NewStringRNode(patch))
 
-		// Apply the same patch filter to both limits and requests
-		if err := limitsPatch.Value.PipeE(patchFilter); err != nil {
+		// Apply the same patch filter to both limits and requests unless the limits are separate
+		limitsPatchFilter := patchFilter
+		if p.separateLimits {
+			patch := fmt.Sprintf("{{ .Values.%s }}%s", name(p.meta, p.fieldPath, limitName(rn)), ind[rn].Suffix())
+			limitsPatchFilter = yaml.SetField(string(rn), yaml.NewStringRNode(patch))
+		}
+
+		if err := limitsPatch.Value.PipeE(limitsPatchFilter); err != nil {
 			return nil, err
 		}
 		if err := requestsPatch.Value.PipeE(patchFilter); err != nil {
@@ -208,17 +224,76 @@ func (p *containerResourcesParameter) Parameters(name ParameterNamer) ([]optimiz
 
 	var result []optimizev1beta2.Parameter
 	for _, rn := range p.resources {
-		result = append(result, optimizev1beta2.Parameter{
+		param := optimizev1beta2.Parameter{
 			Name:     name(p.meta, p.fieldPath, string(rn)),
 			Max:      ind[rn].Max(),
 			Min:      ind[rn].Min(),
+			Step:     ind[rn].Step(),
 			Baseline: ind[rn].Baseline(),
+		}
+
+		result = append(result, snapParameter(param))
+
+		if p.separateLimits {
+			result = append(result, snapParameter(optimizev1beta2.Parameter{
+				Name:     name(p.meta, p.fieldPath, limitName(rn)),
+				Max:      ind[rn].LimitMax(),
+				Min:      ind[rn].Min(),
+				Step:     ind[rn].Step(),
+				Baseline: ind[rn].LimitBaseline(),
+			}))
+		}
+	}
+
+	return result, nil
+}
+
+// Constraints lists the constraints between the parameters used by the patch.
+func (p *containerResourcesParameter) Constraints(name ParameterNamer) ([]optimizev1beta2.Constraint, error) {
+	if !p.separateLimits {
+		return nil, nil
+	}
+
+	var result []optimizev1beta2.Constraint
+	for _, rn := range p.resources {
+		requestParameter := name(p.meta, p.fieldPath, string(rn))
+		limitParameter := name(p.meta, p.fieldPath, limitName(rn))
+
+		// Limits must be at least the requests, and no more then the limit range allows
+		ratio := &optimizev1beta2.RatioConstraint{
+			Numerator:   limitParameter,
+			Denominator: requestParameter,
+			Min:         resource.NewQuantity(1, resource.DecimalSI),
+		}
+		if q, ok := p.limitRange.MaxLimitRequestRatio[rn]; ok {
+			ratio.Max = &q
+		}
+
+		result = append(result, optimizev1beta2.Constraint{
+			Name:  limitParameter + "-ratio",
+			Ratio: ratio,
 		})
 	}
 
 	return result, nil
 }
 
+// snapParameter moves the range and baseline of the parameter onto multiples of the step.
+func snapParameter(param optimizev1beta2.Parameter) optimizev1beta2.Parameter {
+	if param.Step > 1 {
+		param.Min, param.Max = param.StepBounds()
+		if param.Baseline != nil {
+			*param.Baseline = intstr.FromInt(int(param.Snap(int64(param.Baseline.IntVal))))
+		}
+	}
+	return param
+}
+
+// limitName returns the name suffix used for the limit of a resource.
+func limitName(rn corev1.ResourceName) string {
+	return string(rn) + "_limit"
+}
+
 // indexContainerResources collects the container resources for this parameter.
 func (p *containerResourcesParameter) indexContainerResources() (map[corev1.ResourceName]containerResources, error) {
 	// Decode the resource requirements we found during the scan
@@ -231,9 +306,11 @@ func (p *containerResourcesParameter) indexContainerResources() (map[corev1.Reso
 	result := make(map[corev1.ResourceName]containerResources, len(p.resources))
 	for _, rn := range p.resources {
 		result[rn] = containerResources{
-			max:          lookupQuantity(rn, p.limitRange.Max, defaultLimitRange.Max),
-			min:          lookupQuantity(rn, p.limitRange.Min, defaultLimitRange.Min),
-			baseline:     lookupQuantity(rn, scannedValue.Requests, p.limitRange.DefaultRequest, defaultLimitRange.DefaultRequest),
+			max:          resources.LookupQuantity(rn, p.limitRange.Max, defaultLimitRange.Max),
+			min:          resources.LookupQuantity(rn, p.limitRange.Min, defaultLimitRange.Min),
+			baseline:     resources.LookupQuantity(rn, scannedValue.Requests, p.limitRange.DefaultRequest, defaultLimitRange.DefaultRequest),
+			limit:        resources.LookupQuantity(rn, scannedValue.Limits, p.limitRange.Default),
+			step:         resources.LookupQuantity(rn, p.step),
 			defaultScale: defaultScale[rn],
 		}
 	}
@@ -241,16 +318,6 @@ func (p *containerResourcesParameter) indexContainerResources() (map[corev1.Reso
 	return result, nil
 }
 
-// lookupQuantity returns a quantity from the first resource list that has it.
-func lookupQuantity(rn corev1.ResourceName, rl ...corev1.ResourceList) resource.Quantity {
-	for i := range rl {
-		if q, ok := rl[i][rn]; ok {
-			return q
-		}
-	}
-	return *resource.NewQuantity(0, resource.DecimalExponent)
-}
-
 var (
 	// defaultLimitRange acts as a backstop for finding per-resource values.
 	defaultLimitRange = corev1.LimitRangeItem{
@@ -288,6 +355,8 @@ type containerResources struct {
 	max          resource.Quantity
 	min          resource.Quantity
 	baseline     resource.Quantity
+	limit        resource.Quantity
+	step         resource.Quantity
 	defaultScale resource.Scale
 }
 
@@ -328,6 +397,36 @@ func (cr containerResources) Baseline() *intstr.IntOrString {
 	}
 }
 
+// LimitMax returns twice the maximum, or the limit baseline if it is larger.
+func (cr containerResources) LimitMax() int32 {
+	max := cr.Max() * 2
+	if b := cr.LimitBaseline(); b != nil && b.IntVal > max {
+		return b.IntVal
+	}
+	return max
+}
+
+// LimitBaseline returns the non-zero limit or the request baseline, the limit baseline is nil if the request baseline is nil.
+func (cr containerResources) LimitBaseline() *intstr.IntOrString {
+	if cr.limit.IsZero() || cr.baseline.IsZero() {
+		return cr.Baseline()
+	}
+
+	limit := cr.limit
+	limit.Format = cr.baseline.Format
+	return &intstr.IntOrString{
+		Type:   intstr.Int,
+		IntVal: AsScaledInt(limit, cr.scale()),
+	}
+}
+
+// Step returns the configured step, or zero if the step is not configured.
+func (cr containerResources) Step() int32 {
+	step := cr.step
+	step.Format = cr.baseline.Format
+	return AsScaledInt(step, cr.scale())
+}
+
 // Suffix returns the appropriate suffix based on the scale and format. For example,
 // a mega-binary is "Mi" and giga-decimal is "G".
 func (cr containerResources) Suffix() string {
@@ -35,8 +35,9 @@ func TestContainerResourcesParameter(t *testing.T) {
 	cases := []struct {
 		desc string
 		containerResourcesParameter
-		expectedParameters []optimizev1beta2.Parameter
-		expectedPatch      string
+		expectedParameters  []optimizev1beta2.Parameter
+		expectedConstraints []optimizev1beta2.Constraint
+		expectedPatch       string
 	}{
 		{
 			desc: "binary memory",
@@ -197,6 +198,111 @@ func TestContainerResourcesParameter(t *testing.T) {
                   requests:
                     cpu: "{{ .Values.cpu }}m"`),
 		},
+
+		{
+			desc: "step",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("300Mi"),
+							corev1.ResourceCPU:    resource.MustParse("330m"),
+						},
+					}),
+				},
+				resources: []corev1.ResourceName{corev1.ResourceMemory, corev1.ResourceCPU},
+				step: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+					corev1.ResourceCPU:    resource.MustParse("50m"),
+				},
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "memory",
+					Baseline: newInt(320),
+					Min:      192,
+					Max:      576,
+					Step:     64,
+				},
+				{
+					Name:     "cpu",
+					Baseline: newInt(350),
+					Min:      200,
+					Max:      2000,
+					Step:     50,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  limits:
+                    cpu: "{{ .Values.cpu }}m"
+                    memory: "{{ .Values.memory }}Mi"
+                  requests:
+                    cpu: "{{ .Values.cpu }}m"
+                    memory: "{{ .Values.memory }}Mi"`),
+		},
+
+		{
+			desc: "separate limits",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("1536Mi"),
+						},
+					}),
+				},
+				resources:      []corev1.ResourceName{corev1.ResourceMemory},
+				separateLimits: true,
+				limitRange: corev1.LimitRangeItem{
+					MaxLimitRequestRatio: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("2"),
+					},
+				},
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "memory",
+					Baseline: newInt(1024),
+					Min:      512,
+					Max:      2048,
+				},
+				{
+					Name:     "memory_limit",
+					Baseline: newInt(1536),
+					Min:      512,
+					Max:      4096,
+				},
+			},
+			expectedConstraints: []optimizev1beta2.Constraint{
+				{
+					Name: "memory_limit-ratio",
+					Ratio: &optimizev1beta2.RatioConstraint{
+						Numerator:   "memory_limit",
+						Denominator: "memory",
+						Min:         resource.NewQuantity(1, resource.DecimalSI),
+						Max:         mustQuantity("2"),
+					},
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  limits:
+                    memory: "{{ .Values.memory_limit }}Mi"
+                  requests:
+                    memory: "{{ .Values.memory }}Mi"`),
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -207,6 +313,13 @@ func TestContainerResourcesParameter(t *testing.T) {
 				}
 			})
 
+			t.Run("constraints", func(t *testing.T) {
+				constraints, err := c.containerResourcesParameter.Constraints(ignoreMetaForName)
+				if assert.NoError(t, err) {
+					assert.Equal(t, c.expectedConstraints, constraints)
+				}
+			})
+
 			t.Run("patch", func(t *testing.T) {
 				filter, err := c.containerResourcesParameter.Patch(ignoreMetaForName)
 				if assert.NoError(t, err) {
@@ -232,6 +345,12 @@ func encodeResourceRequirements(rr corev1.ResourceRequirements) *yaml.Node {
 	return yaml.MustParse(string(data)).YNode()
 }
 
+// mustQuantity returns a pointer to a new quantity parsed from the supplied string.
+func mustQuantity(str string) *resource.Quantity {
+	q := resource.MustParse(str)
+	return &q
+}
+
 // newInt returns a pointer to a new IntOrString from the supplied int.
 func newInt(val int) *intstr.IntOrString {
 	result := intstr.FromInt(val)
@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"strings"
 
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/scan"
 	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
@@ -44,6 +45,8 @@ type EnvironmentVariablesSelector struct {
 	ValueSuffix string `json:"valueSuffix,omitempty"`
 	// Allowed values for categorical parameters.
 	Values []string `json:"values,omitempty"`
+	// Condition on another environment variable of the same container.
+	ActiveWhen *optimizeappsv1alpha1.EnvironmentVariableCondition `json:"activeWhen,omitempty"`
 }
 
 var _ scan.Selector = &EnvironmentVariablesSelector{}
@@ -76,9 +79,10 @@ func (s *EnvironmentVariablesSelector) Map(node *yaml.RNode, meta yaml.ResourceM
 					fieldPath: node.FieldPath(),
 					value:     node.YNode(),
 				},
-				prefix: s.ValuePrefix,
-				suffix: s.ValueSuffix,
-				values: s.Values,
+				prefix:     s.ValuePrefix,
+				suffix:     s.ValueSuffix,
+				values:     s.Values,
+				activeWhen: s.ActiveWhen,
 			})
 			return node, nil
 		}),
@@ -89,9 +93,10 @@ func (s *EnvironmentVariablesSelector) Map(node *yaml.RNode, meta yaml.ResourceM
 // found by the selector during scanning.
 type environmentVariablesParameter struct {
 	pnode
-	prefix string
-	suffix string
-	values []string
+	prefix     string
+	suffix     string
+	values     []string
+	activeWhen *optimizeappsv1alpha1.EnvironmentVariableCondition
 }
 
 var _ PatchSource = &environmentVariablesParameter{}
@@ -101,6 +106,12 @@ func (p *environmentVariablesParameter) Patch(name ParameterNamer) (yaml.Filter,
 	// Since the field path will contain a "[name=ENV_VAR]" we can just leave the name blank
 	parameterName := name(p.meta, p.fieldPath, "")
 	patch := fmt.Sprintf("%s{{ .Values.%s }}%s", p.prefix, parameterName, p.suffix)
+
+	// Inactive parameters are not assigned, keep the original value instead
+	if p.activeWhen != nil {
+		patch = fmt.Sprintf(`{{ if hasKey .Values "%s" }}%s{{ else }}%s{{ end }}`, parameterName, patch, p.value.Value)
+	}
+
 	value := yaml.NewScalarRNode(patch)
 
 	return yaml.Tee(
@@ -115,6 +126,13 @@ func (p *environmentVariablesParameter) Parameters(name ParameterNamer) ([]optim
 		Baseline: new(intstr.IntOrString),
 	}
 
+	if p.activeWhen != nil {
+		param.ActiveWhen = &optimizev1beta2.ParameterCondition{
+			Parameter: name(p.meta, p.conditionPath(), ""),
+			Values:    p.activeWhen.Values,
+		}
+	}
+
 	value := strings.TrimPrefix(strings.TrimSuffix(p.value.Value, p.suffix), p.prefix)
 	if len(p.values) > 0 {
 		if value == "" {
@@ -135,6 +153,18 @@ func (p *environmentVariablesParameter) Parameters(name ParameterNamer) ([]optim
 	return []optimizev1beta2.Parameter{param}, nil
 }
 
+// conditionPath returns the field path of the environment variable the activation condition refers to.
+func (p *environmentVariablesParameter) conditionPath() []string {
+	path := append([]string(nil), p.fieldPath...)
+	for i := len(path) - 1; i >= 0; i-- {
+		if yaml.IsListIndex(path[i]) {
+			path[i] = fmt.Sprintf("[name=%s]", p.activeWhen.Name)
+			break
+		}
+	}
+	return path
+}
+
 func appendMissing(slice []string, elem string) []string {
 	for _, s := range slice {
 		if s == elem {
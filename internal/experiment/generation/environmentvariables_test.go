@@ -0,0 +1,133 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestEnvironmentVariablesParameter(t *testing.T) {
+	g1 := intstr.FromString("G1")
+	cases := []struct {
+		desc string
+		environmentVariablesParameter
+		expectedParameters []optimizev1beta2.Parameter
+		expectedPatch      string
+	}{
+		{
+			desc: "categorical",
+
+			environmentVariablesParameter: environmentVariablesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "containers", "[name=app]", "env", "[name=GC]", "value"},
+					value:     yaml.NewScalarRNode("G1").YNode(),
+				},
+				values: []string{"G1", "Parallel"},
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "gc",
+					Baseline: &g1,
+					Values:   []string{"G1", "Parallel"},
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                containers:
+                - name: app
+                  env:
+                  - name: GC
+                    value: "{{ .Values.gc }}"`),
+		},
+
+		{
+			desc: "conditional",
+
+			environmentVariablesParameter: environmentVariablesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "containers", "[name=app]", "env", "[name=PARALLEL_THREADS]", "value"},
+					value:     yaml.NewScalarRNode("4").YNode(),
+				},
+				activeWhen: &optimizeappsv1alpha1.EnvironmentVariableCondition{
+					Name:   "GC",
+					Values: []string{"Parallel"},
+				},
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "parallel_threads",
+					Baseline: newInt(4),
+					Min:      2,
+					Max:      8,
+					ActiveWhen: &optimizev1beta2.ParameterCondition{
+						Parameter: "gc",
+						Values:    []string{"Parallel"},
+					},
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                containers:
+                - name: app
+                  env:
+                  - name: PARALLEL_THREADS
+                    value: '{{ if hasKey .Values "parallel_threads" }}{{ .Values.parallel_threads }}{{ else }}4{{ end }}'`),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			t.Run("parameters", func(t *testing.T) {
+				parameters, err := c.environmentVariablesParameter.Parameters(variableNameForName)
+				if assert.NoError(t, err) {
+					assert.Equal(t, c.expectedParameters, parameters)
+				}
+			})
+
+			t.Run("patch", func(t *testing.T) {
+				filter, err := c.environmentVariablesParameter.Patch(variableNameForName)
+				if assert.NoError(t, err) {
+					patch, err := yaml.NewMapRNode(nil).Pipe(filter)
+					if assert.NoError(t, err) {
+						actual, err := yaml.String(patch.YNode())
+						require.NoError(t, err)
+						assert.YAMLEq(t, c.expectedPatch, actual)
+					}
+				}
+			})
+		})
+	}
+}
+
+// variableNameForName uses the lower case name of the last indexed path element as the name.
+func variableNameForName(_ yaml.ResourceMeta, path []string, _ string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if _, value, _ := yaml.SplitIndexNameValue(path[i]); value != "" {
+			return strings.ToLower(value)
+		}
+	}
+	return ""
+}
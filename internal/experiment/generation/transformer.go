@@ -48,6 +48,12 @@ type ParameterSource interface {
 	Parameters(name ParameterNamer) ([]optimizev1beta2.Parameter, error)
 }
 
+// ConstraintSource allows selectors to add constraints to an experiment. The
+// constraints should only reference parameters from a ParameterSource.
+type ConstraintSource interface {
+	Constraints(name ParameterNamer) ([]optimizev1beta2.Constraint, error)
+}
+
 // PatchSource allows selectors to contribute changes to the patch of a
 // particular resource. In general, ParameterSource should also be implemented
 // to add any parameters referenced by the generated patches.
@@ -97,6 +103,14 @@ func (t *Transformer) Transform(nodes []*yaml.RNode, selected []interface{}) ([]
 			exp.Spec.Parameters = append(exp.Spec.Parameters, params...)
 		}
 
+		if cs, ok := sel.(ConstraintSource); ok {
+			constraints, err := cs.Constraints(name)
+			if err != nil {
+				return nil, err
+			}
+			exp.Spec.Constraints = append(exp.Spec.Constraints, constraints...)
+		}
+
 		if ps, ok := sel.(PatchSource); ok {
 			ref := ps.TargetRef()
 			f, err := ps.Patch(name)
@@ -125,6 +125,8 @@ func (g *Generator) selectors() []scan.Selector {
 					LabelSelector: g.Application.Parameters[i].ContainerResources.Selector,
 				},
 				Resources:          g.Application.Parameters[i].ContainerResources.Resources,
+				Step:               g.Application.Parameters[i].ContainerResources.Step,
+				SeparateLimits:     g.Application.Parameters[i].ContainerResources.SeparateLimits,
 				CreateIfNotPresent: true,
 			})
 
@@ -145,6 +147,7 @@ func (g *Generator) selectors() []scan.Selector {
 				ValuePrefix:  g.Application.Parameters[i].EnvironmentVariable.Prefix,
 				ValueSuffix:  g.Application.Parameters[i].EnvironmentVariable.Suffix,
 				Values:       g.Application.Parameters[i].EnvironmentVariable.Values,
+				ActiveWhen:   g.Application.Parameters[i].EnvironmentVariable.ActiveWhen,
 			})
 		}
 
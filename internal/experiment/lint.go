@@ -0,0 +1,276 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	"github.com/thestormforge/optimize-controller/v2/internal/validation"
+	"go.uber.org/zap/zapcore"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Define linter log levels
+// NOTE: It is unclear why zapr is reversing the sign of the level.
+const (
+	// LintError is the verbosity level used to report errors
+	LintError = int(-zapcore.ErrorLevel)
+	// LintWarn is the verbosity level used to report warnings
+	LintWarn = int(-zapcore.WarnLevel)
+)
+
+// Define linter severities
+const (
+	// LintSeverity is the key of the value used to classify the severity of an error
+	LintSeverity = "severity"
+	// LintInvalid is the severity of errors which indicate the experiment can never run successfully, other errors may
+	// be an artifact of linting without trial data (e.g. templates which fail to render)
+	LintInvalid = "invalid"
+)
+
+// Linter is a visitor that reports problems with an experiment. Errors are logged using the `LintError` verbosity
+// level and warnings are logged using the `LintWarn` verbosity level. Errors which make the experiment invalid
+// include the `LintSeverity` key with a value of `LintInvalid`.
+type Linter struct {
+	// Log receives the problems found while walking the experiment.
+	Log logr.Logger
+	// MinExperimentBudget is the minimum recommended value for the experiment budget optimization parameter.
+	MinExperimentBudget int
+}
+
+// NewLinter returns a linter for the supplied experiment which recommends a budget of 20x the number of parameters
+// up to 400 trials.
+func NewLinter(log logr.Logger, exp *optimizev1beta2.Experiment) *Linter {
+	l := &Linter{Log: log, MinExperimentBudget: 20 * len(exp.Spec.Parameters)}
+	if l.MinExperimentBudget > 400 {
+		l.MinExperimentBudget = 400
+	}
+	return l
+}
+
+// Visit inspects the supplied object and logs any problems found.
+func (l *Linter) Visit(ctx context.Context, obj interface{}) Visitor {
+	// Add the current path to the logger
+	lint := l.Log.WithValues("path", strings.Join(WalkPath(ctx), "/"))
+	invalid := lint.WithValues(LintSeverity, LintInvalid)
+
+	switch o := obj.(type) {
+
+	case *optimizev1beta2.Experiment:
+		if _, err := WarmStartExperimentName(o); err != nil {
+			invalid.V(LintError).Info("Warm start experiment must be in the same namespace", "error", err.Error())
+		}
+
+	case *optimizev1beta2.Optimization:
+		switch o.Name {
+		case "experimentBudget":
+			if eb, err := strconv.Atoi(o.Value); err != nil {
+				invalid.Error(err, "Optimization parameter value must be an integer", "value", o.Value)
+			} else if l.MinExperimentBudget > 0 && l.MinExperimentBudget > eb {
+				lint.V(LintWarn).Info("Experiment budget should be increased", "experimentBudget", eb, "recommended", l.MinExperimentBudget)
+			}
+		}
+
+	case *optimizev1beta2.ExperimentSpec:
+		for i := range o.Constraints {
+			if err := validation.CheckConstraintScale(o.Parameters, &o.Constraints[i]); err != nil {
+				invalid.V(LintError).Info("Constraint cannot use the log scale of a parameter", "constraint", o.Constraints[i].Name, "error", err.Error())
+			}
+			if err := validation.CheckConstraintTerms(o.Parameters, &o.Constraints[i]); err != nil {
+				invalid.V(LintError).Info("Constraint must be a linear combination or ratio of parameters", "constraint", o.Constraints[i].Name, "error", err.Error())
+			}
+		}
+
+	case []optimizev1beta2.Parameter:
+		if l := len(o); l == 0 {
+			invalid.V(LintError).Info("Parameters are required")
+		} else if b := countBaselines(o); b > 0 && b != l {
+			invalid.V(LintError).Info("Baseline must be specified on all parameters")
+		}
+		for i := range o {
+			if err := validation.CheckParameterCondition(o, &o[i]); err != nil {
+				invalid.V(LintError).Info("Parameter activation condition is invalid", "parameter", o[i].Name, "error", err.Error())
+			}
+		}
+
+	case []optimizev1beta2.Metric:
+		if len(o) == 0 {
+			invalid.V(LintError).Info("Metrics are required")
+		}
+
+	case *optimizev1beta2.Parameter:
+		if o.Float != nil {
+			checkFloatParameter(lint, o)
+		} else if len(o.Values) > 0 && (o.Min != 0 || o.Max != 0) {
+			// NOTE: This won't hit on v1alpha1 converted experiments because min/max get reset
+			lint.V(LintWarn).Info("Parameter has both a numeric and string range defined")
+		} else if o.Max <= o.Min && (o.Max != 0 || o.Min != 0) {
+			lint.V(LintError).Info("Parameter minimum must be strictly less then maximum", "min", o.Min, "max", o.Max)
+		} else if o.Step < 0 {
+			invalid.V(LintError).Info("Parameter step must not be negative", "step", o.Step)
+		} else if o.Step > 1 && len(o.Values) > 0 {
+			lint.V(LintWarn).Info("Parameter step is ignored for string values", "step", o.Step)
+		} else if min, max := o.StepBounds(); min > max {
+			invalid.V(LintError).Info("Parameter range must contain a multiple of the step", "min", o.Min, "max", o.Max, "step", o.Step)
+		} else if o.Baseline != nil {
+			checkBaseline(lint, o)
+		}
+
+	case *optimizev1beta2.Metric:
+		switch o.Type {
+		case
+			optimizev1beta2.MetricKubernetes,
+			optimizev1beta2.MetricPrometheus,
+			optimizev1beta2.MetricJSONPath,
+			optimizev1beta2.MetricDatadog,
+			"": // Type is valid
+		default:
+			invalid.V(LintError).Info("Metric type is invalid", "type", o.Type)
+		}
+
+		if o.Query == "" {
+			invalid.V(LintError).Info("Metric query is required")
+		} else {
+			q, _, err := metricQueryDryRun(o)
+			if err != nil {
+				lint.Error(err, "Metric query failed to render", "query", o.Query)
+			}
+
+			switch o.Type {
+			case optimizev1beta2.MetricJSONPath:
+				if !strings.Contains(q, "{") {
+					lint.V(LintWarn).Info("JSON Path query should contain an {} expression", "query", o.Query)
+				}
+			case optimizev1beta2.MetricPrometheus:
+				if !strings.Contains(q, "scalar") {
+					lint.V(LintWarn).Info("Prometheus query may require explicit scalar conversion", "query", o.Query)
+				}
+			}
+		}
+
+		if o.Min != nil && o.Max != nil && o.Min.Cmp(*o.Max) >= 0 {
+			invalid.V(LintError).Info("Metric minimum must be strictly less then maximum")
+		}
+
+		if u, err := url.Parse(o.URL); err != nil {
+			lint.V(LintError).Info("Metric has invalid URL")
+		} else if u.Hostname() == "redskyops.dev" {
+			lint.V(LintError).Info("Metric requires manual conversion to latest version for URL")
+		}
+
+	case *optimizev1beta2.PatchTemplate:
+		if o.TargetRef != nil {
+			if o.TargetRef.Kind == "" {
+				// TODO Is kind required? Can you just have the namespace and the rest of the ref in the patch?
+				lint.V(LintError).Info("Patch target kind is required")
+			} else if _, ok := scheme.Scheme.AllKnownTypes()[o.TargetRef.GroupVersionKind()]; !ok {
+				if o.TargetRef.APIVersion == "" {
+					lint.V(LintError).Info("Patch target apiVersion is required")
+				}
+				if o.Type == optimizev1beta2.PatchStrategic || o.Type == "" {
+					lint.V(LintWarn).Info("Strategic merge patch may not work with custom resources")
+				}
+			}
+		}
+
+		if _, err := template.New().RenderPatch(o, &optimizev1beta2.Trial{}); err != nil {
+			lint.Error(err, "Patch is not valid")
+		}
+
+	case *batchv1beta1.JobTemplateSpec:
+		if o.Spec.BackoffLimit != nil && *o.Spec.BackoffLimit != 0 {
+			lint.V(LintWarn).Info("Job backoffLimit should be 0", "backoffLimit", *o.Spec.BackoffLimit)
+		}
+
+	case *optimizev1beta2.WarmStart:
+		if (o.ExperimentRef == nil) == (o.ServerExperimentName == "") {
+			invalid.V(LintError).Info("Warm start must reference exactly one of an experiment or a server experiment name")
+		}
+
+	}
+
+	// Return the linter to continue walking through the experiment
+	return l
+}
+
+func countBaselines(params []optimizev1beta2.Parameter) int {
+	var b int
+	for i := range params {
+		if params[i].Baseline != nil {
+			b++
+		}
+	}
+	return b
+}
+
+func checkBaseline(lint logr.Logger, p *optimizev1beta2.Parameter) {
+	invalid := lint.WithValues(LintSeverity, LintInvalid)
+	switch p.Baseline.Type {
+	case intstr.String:
+		if p.Min != 0 || p.Max != 0 {
+			lint.V(LintError).Info("Parameter defines a numeric range but has a string baseline value")
+		} else if len(p.Values) == 0 {
+			lint.V(LintError).Info("Parameter has a string baseline but no values")
+		} else if !validation.CheckParameterValue(p, *p.Baseline) {
+			invalid.V(LintError).Info("Parameter baseline is not in range", "values", strings.Join(p.Values, ","), "baseline", p.Baseline.StrVal)
+		}
+
+	case intstr.Int:
+		if len(p.Values) > 0 {
+			lint.V(LintError).Info("Parameter defines a string range but has a numeric baseline value")
+		} else if p.Min == 0 && p.Max == 0 {
+			lint.V(LintError).Info("Parameter has a numeric baseline but no min or max")
+		} else if p.Min != p.Max && !validation.CheckParameterValue(p, *p.Baseline) {
+			invalid.V(LintError).Info("Parameter baseline is not in range", "min", p.Min, "max", p.Max, "baseline", p.Baseline.IntVal)
+		}
+	}
+}
+
+func checkFloatParameter(lint logr.Logger, p *optimizev1beta2.Parameter) {
+	invalid := lint.WithValues(LintSeverity, LintInvalid)
+	min, max := p.Float.Bounds()
+	if len(p.Values) > 0 || p.Min != 0 || p.Max != 0 {
+		lint.V(LintWarn).Info("Parameter has both a floating point and an integer or string range defined")
+	} else if max < min {
+		lint.V(LintError).Info("Parameter minimum must be less then maximum", "min", p.Float.Min.String(), "max", p.Float.Max.String())
+	} else if p.Float.LogScale && min <= 0 {
+		invalid.V(LintError).Info("Log scale parameter minimum must be positive", "min", p.Float.Min.String())
+	} else if p.Float.Precision != nil && *p.Float.Precision < 0 {
+		invalid.V(LintError).Info("Parameter precision must not be negative", "precision", *p.Float.Precision)
+	} else if p.Baseline != nil && !validation.CheckParameterValue(p, *p.Baseline) {
+		invalid.V(LintError).Info("Parameter baseline is not in range", "min", p.Float.Min.String(), "max", p.Float.Max.String(), "baseline", p.Baseline.String())
+	}
+}
+
+func metricQueryDryRun(m *optimizev1beta2.Metric) (string, string, error) {
+	// Try to dummy out the target object to avoid failures
+	target := &unstructured.Unstructured{}
+	if m.Target != nil {
+		target.SetGroupVersionKind(m.Target.GroupVersionKind())
+	}
+
+	return template.New().RenderMetricQueries(m, &optimizev1beta2.Trial{}, target)
+}
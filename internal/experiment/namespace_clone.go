@@ -0,0 +1,131 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// cloneObject is a resource that can be copied into a new namespace
+type cloneObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// cloneNamespace copies the matching resources from the clone source namespace into the supplied namespace, the
+// resources are read using the supplied reader to avoid caching every secret (or config map, etc.) in the cluster
+func cloneNamespace(ctx context.Context, c client.Client, r client.Reader, exp *optimizev1beta2.Experiment, namespace string) error {
+	clone := exp.Spec.NamespaceTemplate.Clone
+	source := clone.Namespace
+	if source == "" {
+		source = exp.Namespace
+	}
+
+	sel, err := metav1.LabelSelectorAsSelector(clone.Selector)
+	if err != nil {
+		return err
+	}
+	opts := []client.ListOption{client.InNamespace(source), client.MatchingLabelsSelector{Selector: sel}}
+
+	// RBAC: We assume that we have "list" and "create" permission from a customer defined role
+	var objs []cloneObject
+
+	configMapList := &corev1.ConfigMapList{}
+	if err := r.List(ctx, configMapList, opts...); err != nil {
+		return err
+	}
+	for i := range configMapList.Items {
+		objs = append(objs, &configMapList.Items[i])
+	}
+
+	if clone.Secrets {
+		secretList := &corev1.SecretList{}
+		if err := r.List(ctx, secretList, opts...); err != nil {
+			return err
+		}
+		for i := range secretList.Items {
+			// Service account tokens are generated for the new namespace
+			if secretList.Items[i].Type == corev1.SecretTypeServiceAccountToken {
+				continue
+			}
+			objs = append(objs, &secretList.Items[i])
+		}
+	}
+
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, opts...); err != nil {
+		return err
+	}
+	for i := range serviceList.Items {
+		// Allocated addresses and ports cannot be shared with the original service
+		svc := &serviceList.Items[i]
+		if svc.Spec.ClusterIP != corev1.ClusterIPNone {
+			svc.Spec.ClusterIP = ""
+		}
+		for j := range svc.Spec.Ports {
+			svc.Spec.Ports[j].NodePort = 0
+		}
+		svc.Spec.HealthCheckNodePort = 0
+		svc.Status = corev1.ServiceStatus{}
+		objs = append(objs, svc)
+	}
+
+	deploymentList := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deploymentList, opts...); err != nil {
+		return err
+	}
+	for i := range deploymentList.Items {
+		deploymentList.Items[i].Status = appsv1.DeploymentStatus{}
+		objs = append(objs, &deploymentList.Items[i])
+	}
+
+	for _, obj := range objs {
+		resetObjectMeta(obj, exp, namespace)
+		if err := c.Create(ctx, obj); err != nil && !apierrs.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetObjectMeta clears the server populated metadata so an object can be created in a different namespace
+func resetObjectMeta(obj metav1.Object, exp *optimizev1beta2.Experiment, namespace string) {
+	obj.SetNamespace(namespace)
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetSelfLink("")
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetOwnerReferences(nil)
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[optimizev1beta2.LabelExperiment] = exp.Name
+	obj.SetLabels(labels)
+}
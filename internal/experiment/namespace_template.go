@@ -20,21 +20,30 @@ import (
 	"context"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/recommendation"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// NextTrialNamespace searches for or creates a new namespace to run a new trial in, returning an empty string if no such namespace can be found
-func NextTrialNamespace(ctx context.Context, c client.Client, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (string, error) {
+// NextTrialNamespace searches for or creates a new namespace to run a new trial in, returning an empty string if no such namespace can be found.
+// The supplied reader is used to fetch the application resources cloned into a created namespace.
+func NextTrialNamespace(ctx context.Context, c client.Client, r client.Reader, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (string, error) {
 	// Determine which namespaces have an active trial
 	activeNamespaces := make(map[string]bool, len(trialList.Items))
+	usedNamespaces := make(map[string]bool, len(trialList.Items))
 	activeTrials := int32(0)
 	for i := range trialList.Items {
 		t := &trialList.Items[i]
+		usedNamespaces[t.Namespace] = true
 		if trial.IsActive(t) {
 			activeNamespaces[t.Namespace] = true
 			activeTrials++
@@ -69,14 +78,19 @@ func NextTrialNamespace(ctx context.Context, c client.Client, exp *optimizev1bet
 		return "", err
 	}
 	for i := range namespaceList.Items {
-		if n := namespaceList.Items[i].Name; !activeNamespaces[n] {
-			return n, nil
+		n := &namespaceList.Items[i]
+
+		// Created namespaces are only used for a single trial
+		if activeNamespaces[n.Name] || !n.DeletionTimestamp.IsZero() || (usedNamespaces[n.Name] && isCreatedNamespace(n)) {
+			continue
 		}
+
+		return n.Name, nil
 	}
 
 	// If we could not find a namespace, we may be able to create it
 	if exp.Spec.NamespaceTemplate != nil {
-		return createNamespaceFromTemplate(ctx, c, exp)
+		return createNamespaceFromTemplate(ctx, c, r, exp)
 	}
 
 	// No namespace is available
@@ -93,7 +107,7 @@ func ignorePermissions(err error) error {
 	return err
 }
 
-func createNamespaceFromTemplate(ctx context.Context, c client.Client, exp *optimizev1beta2.Experiment) (string, error) {
+func createNamespaceFromTemplate(ctx context.Context, c client.Client, r client.Reader, exp *optimizev1beta2.Experiment) (string, error) {
 	// Use the template to populate a new namespace
 	n := &corev1.Namespace{}
 	exp.Spec.NamespaceTemplate.ObjectMeta.DeepCopyInto(&n.ObjectMeta)
@@ -107,7 +121,11 @@ func createNamespaceFromTemplate(ctx context.Context, c client.Client, exp *opti
 	n.Labels[optimizev1beta2.LabelExperiment] = exp.Name
 	n.Labels[optimizev1beta2.LabelTrialRole] = "trialSetup"
 
-	// TODO We should also record the fact that we created the namespace for possible clean up later
+	// Record the fact that we created the namespace so it can be cleaned up later
+	if n.Annotations == nil {
+		n.Annotations = map[string]string{}
+	}
+	n.Annotations[optimizev1beta2.AnnotationCreatedNamespace] = "true"
 
 	// NOTE: The ignorePermission call is in different places for the namespace and supporting objects because
 	// if the namespace creation fails we cannot continue creating the supporting objects
@@ -138,9 +156,113 @@ func createNamespaceFromTemplate(ctx context.Context, c client.Client, exp *opti
 		}
 	}
 
+	// Copy the application resources into the new namespace
+	if exp.Spec.NamespaceTemplate.Clone != nil {
+		if err := cloneNamespace(ctx, c, r, exp, n.Name); err != nil {
+			return "", err
+		}
+	}
+
 	return n.Name, nil
 }
 
+// CleanupNamespaces deletes the namespaces created for trials once the trials running in them are finished. Since
+// deleting a namespace also deletes the trials in it, the namespaces are kept until the experiment is finished and the
+// recommendation (which is produced from the trials) is ready; cloned deployments are deleted as soon as the trial
+// is finished so they do not continue to consume cluster resources.
+func CleanupNamespaces(ctx context.Context, c client.Client, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) error {
+	if exp.Spec.NamespaceTemplate == nil && exp.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := c.List(ctx, namespaceList, client.MatchingLabels{optimizev1beta2.LabelExperiment: exp.Name}); err != nil {
+		return err
+	}
+
+	var retain *bool
+	for i := range namespaceList.Items {
+		n := &namespaceList.Items[i]
+		if !isCreatedNamespace(n) || !n.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		// Wait for every trial (including the setup deletion) to finish, unless the experiment itself is deleted
+		finished := !exp.GetDeletionTimestamp().IsZero()
+		for j := range trialList.Items {
+			t := &trialList.Items[j]
+			if t.Namespace != n.Name {
+				continue
+			}
+			if !trial.IsFinished(t) || meta.HasFinalizer(t, setup.Finalizer) {
+				finished = false
+				break
+			}
+			finished = true
+		}
+		if !finished {
+			continue
+		}
+
+		// Only check for the recommendation once
+		if retain == nil {
+			r, err := retainTrials(ctx, c, exp)
+			if err != nil {
+				return err
+			}
+			retain = &r
+		}
+
+		if *retain {
+			// RBAC: We assume that we have "deletecollection" permission on deployments from a customer defined role
+			if exp.Spec.NamespaceTemplate != nil && exp.Spec.NamespaceTemplate.Clone != nil {
+				err := c.DeleteAllOf(ctx, &appsv1.Deployment{}, client.InNamespace(n.Name), client.MatchingLabels{optimizev1beta2.LabelExperiment: exp.Name})
+				if ignorePermissions(err) != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// RBAC: We assume that we have "delete" permission on namespaces from a customer defined role
+		if err := c.Delete(ctx, n, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			if apierrs.IsNotFound(err) || ignorePermissions(err) == nil {
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retainTrials checks to see if the trials of the experiment are still needed to produce a recommendation
+func retainTrials(ctx context.Context, c client.Client, exp *optimizev1beta2.Experiment) (bool, error) {
+	// Nothing is needed once the experiment is deleted
+	if !exp.GetDeletionTimestamp().IsZero() {
+		return false, nil
+	}
+
+	// Recommendations are only produced for successfully completed experiments
+	if !IsFinished(exp) {
+		return true, nil
+	}
+	if !checkCondition(exp, optimizev1beta2.ExperimentComplete) {
+		return false, nil
+	}
+
+	rec := &optimizev1beta2.Recommendation{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: exp.Namespace, Name: exp.Name}, rec); err != nil {
+		return true, controller.IgnoreNotFound(err)
+	}
+	return !recommendation.IsPopulated(rec), nil
+}
+
+// isCreatedNamespace checks to see if the supplied namespace was created from a namespace template
+func isCreatedNamespace(n *corev1.Namespace) bool {
+	return n.Annotations[optimizev1beta2.AnnotationCreatedNamespace] == "true"
+}
+
 // trialNamespace represents the supporting resources for a trial namespace
 type trialNamespace struct {
 	ServiceAccount *corev1.ServiceAccount
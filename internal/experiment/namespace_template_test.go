@@ -0,0 +1,160 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNextTrialNamespace_Clone(t *testing.T) {
+	ctx := context.TODO()
+	appLabels := map[string]string{"app": "web"}
+
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.ExperimentSpec{
+			NamespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-trial"},
+				Clone: &optimizev1beta2.NamespaceCloneSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: appLabels},
+					Secrets:  true,
+				},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(clientgoscheme.Scheme,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: appLabels, ResourceVersion: "10"}},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: appLabels},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.0.0.10",
+				Ports:     []corev1.ServicePort{{Port: 80, NodePort: 30080}},
+			},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: appLabels}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}},
+	)
+
+	namespace, err := NextTrialNamespace(ctx, c, c, exp, &optimizev1beta2.TrialList{})
+	if !assert.NoError(t, err) || !assert.Equal(t, "test-trial", namespace) {
+		return
+	}
+
+	n := &corev1.Namespace{}
+	if assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: namespace}, n)) {
+		assert.True(t, isCreatedNamespace(n))
+	}
+
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "web"}, &appsv1.Deployment{}))
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "web"}, &corev1.Secret{}))
+	svc := &corev1.Service{}
+	if assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "web"}, svc)) {
+		assert.Empty(t, svc.Spec.ClusterIP)
+		assert.Zero(t, svc.Spec.Ports[0].NodePort)
+	}
+	err = c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "other"}, &corev1.ConfigMap{})
+	assert.True(t, apierrs.IsNotFound(err))
+}
+
+func TestCleanupNamespaces(t *testing.T) {
+	ctx := context.TODO()
+	now := metav1.Now()
+
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.ExperimentSpec{
+			NamespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{
+				Clone: &optimizev1beta2.NamespaceCloneSpec{},
+			},
+		},
+	}
+
+	newNamespace := func(name string, created bool) *corev1.Namespace {
+		n := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{optimizev1beta2.LabelExperiment: exp.Name},
+		}}
+		if created {
+			n.Annotations = map[string]string{optimizev1beta2.AnnotationCreatedNamespace: "true"}
+		}
+		return n
+	}
+
+	newTrial := func(namespace string, finished bool) optimizev1beta2.Trial {
+		t := optimizev1beta2.Trial{ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace}}
+		if finished {
+			t.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue, LastProbeTime: now, LastTransitionTime: now}}
+		}
+		return t
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = optimizev1beta2.AddToScheme(scheme)
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "finished", Labels: map[string]string{optimizev1beta2.LabelExperiment: exp.Name}}},
+		newNamespace("finished", true),
+		newNamespace("active", true),
+		newNamespace("unused", true),
+		newNamespace("existing", false),
+	)
+
+	trialList := &optimizev1beta2.TrialList{Items: []optimizev1beta2.Trial{
+		newTrial("finished", true),
+		newTrial("active", false),
+		newTrial("existing", true),
+	}}
+
+	// The trials are needed until the experiment is finished, but the cloned application is not
+	if assert.NoError(t, CleanupNamespaces(ctx, c, exp, trialList)) {
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "finished"}, &corev1.Namespace{}))
+		err := c.Get(ctx, types.NamespacedName{Namespace: "finished", Name: "app"}, &appsv1.Deployment{})
+		assert.True(t, apierrs.IsNotFound(err))
+	}
+
+	// The trials are needed until the recommendation is ready
+	exp.Status.Conditions = []optimizev1beta2.ExperimentCondition{{Type: optimizev1beta2.ExperimentComplete, Status: corev1.ConditionTrue}}
+	if assert.NoError(t, CleanupNamespaces(ctx, c, exp, trialList)) {
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "finished"}, &corev1.Namespace{}))
+	}
+
+	rec := &optimizev1beta2.Recommendation{ObjectMeta: metav1.ObjectMeta{Name: exp.Name, Namespace: exp.Namespace}}
+	rec.Status.Conditions = []optimizev1beta2.RecommendationCondition{{Type: optimizev1beta2.RecommendationReady, Status: corev1.ConditionTrue}}
+	assert.NoError(t, c.Create(ctx, rec))
+
+	if assert.NoError(t, CleanupNamespaces(ctx, c, exp, trialList)) {
+		err := c.Get(ctx, types.NamespacedName{Name: "finished"}, &corev1.Namespace{})
+		assert.True(t, apierrs.IsNotFound(err))
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "active"}, &corev1.Namespace{}))
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "unused"}, &corev1.Namespace{}))
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "existing"}, &corev1.Namespace{}))
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"sort"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CheckConcurrency determines if an experiment can start a new trial without exceeding the cluster-wide limit on
+// concurrent trials. Waiting experiments are admitted in order of priority, then by the fewest active trials (so each
+// experiment gets a fair share of the cluster), and finally by how long they have been waiting. If the experiment
+// cannot start a trial, a message describing why is returned.
+func CheckConcurrency(exp *optimizev1beta2.Experiment, experimentList *optimizev1beta2.ExperimentList, trialList *optimizev1beta2.TrialList, priorities map[string]int32, limit int32) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	// Count the active trials for each experiment
+	var activeTrials int32
+	active := make(map[types.NamespacedName]int32, len(experimentList.Items))
+	for i := range trialList.Items {
+		t := &trialList.Items[i]
+		if trial.IsActive(t) && !trial.IsAbandoned(t) {
+			active[t.ExperimentNamespacedName()]++
+			activeTrials++
+		}
+	}
+
+	if activeTrials >= limit {
+		return fmt.Sprintf("Waiting for one of %d active trials to finish (limit %d)", activeTrials, limit)
+	}
+
+	// Build the queue of experiments that want to start a trial
+	key := types.NamespacedName{Namespace: exp.Namespace, Name: exp.Name}
+	queue := []*optimizev1beta2.Experiment{exp}
+	for i := range experimentList.Items {
+		e := &experimentList.Items[i]
+		k := types.NamespacedName{Namespace: e.Namespace, Name: e.Name}
+		if k != key && isWaitingForTrial(e, active[k]) {
+			queue = append(queue, e)
+		}
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		ei, ej := queue[i], queue[j]
+		if pi, pj := priorities[ei.Spec.PriorityClassName], priorities[ej.Spec.PriorityClassName]; pi != pj {
+			return pi > pj
+		}
+
+		ai := active[types.NamespacedName{Namespace: ei.Namespace, Name: ei.Name}]
+		aj := active[types.NamespacedName{Namespace: ej.Namespace, Name: ej.Name}]
+		if ai != aj {
+			return ai < aj
+		}
+
+		// Experiments that are already waiting come first
+		wi, oki := waitingSince(ei)
+		wj, okj := waitingSince(ej)
+		if oki != okj {
+			return oki
+		}
+		if !wi.Equal(&wj) {
+			return wi.Before(&wj)
+		}
+
+		if ei.Namespace != ej.Namespace {
+			return ei.Namespace < ej.Namespace
+		}
+		return ei.Name < ej.Name
+	})
+
+	// Only the experiments at the front of the queue can use the available slots
+	available := int(limit - activeTrials)
+	for i := range queue {
+		if queue[i] == exp {
+			if i < available {
+				return ""
+			}
+			return fmt.Sprintf("Queued behind %d other experiments for %d available trial slots", i, available)
+		}
+	}
+	return ""
+}
+
+// isWaitingForTrial checks to see if an experiment is trying to start a new trial, experiments outside of their
+// schedule window are not trying to start trials and must not hold slots other experiments could use
+func isWaitingForTrial(exp *optimizev1beta2.Experiment, activeTrials int32) bool {
+	return exp.GetAnnotations()[optimizev1beta2.AnnotationNextTrialURL] != "" &&
+		!IsFinished(exp) &&
+		waitingPhase(exp) != PhaseWaitingForSchedule &&
+		activeTrials < exp.Replicas()
+}
+
+// waitingSince returns the time an experiment started waiting to start trials, falling back to the creation time
+// if the experiment is not waiting
+func waitingSince(exp *optimizev1beta2.Experiment) (metav1.Time, bool) {
+	for _, c := range exp.Status.Conditions {
+		if c.Type == optimizev1beta2.ExperimentWaiting && c.Status == corev1.ConditionTrue {
+			return c.LastTransitionTime, true
+		}
+	}
+	return exp.CreationTimestamp, false
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckConcurrency(t *testing.T) {
+	now := metav1.Now()
+	earlier := metav1.NewTime(now.Add(-time.Minute))
+	replicas := int32(2)
+
+	newExperiment := func(name, priorityClassName string, waitingSince *metav1.Time) optimizev1beta2.Experiment {
+		exp := optimizev1beta2.Experiment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: earlier,
+				Annotations:       map[string]string{optimizev1beta2.AnnotationNextTrialURL: "http://example.com/" + name + "/next"},
+			},
+			Spec: optimizev1beta2.ExperimentSpec{
+				Replicas:          &replicas,
+				PriorityClassName: priorityClassName,
+			},
+		}
+		if waitingSince != nil {
+			exp.Status.Conditions = []optimizev1beta2.ExperimentCondition{{
+				Type:               optimizev1beta2.ExperimentWaiting,
+				Status:             corev1.ConditionTrue,
+				Reason:             ReasonQueued,
+				LastTransitionTime: *waitingSince,
+			}}
+		}
+		return exp
+	}
+
+	outsideSchedule := func(exp optimizev1beta2.Experiment) optimizev1beta2.Experiment {
+		exp.Status.Conditions = []optimizev1beta2.ExperimentCondition{{
+			Type:               optimizev1beta2.ExperimentWaiting,
+			Status:             corev1.ConditionTrue,
+			Reason:             ReasonSchedule,
+			LastTransitionTime: earlier,
+		}}
+		return exp
+	}
+
+	newTrial := func(experimentName string) optimizev1beta2.Trial {
+		return optimizev1beta2.Trial{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Labels: map[string]string{optimizev1beta2.LabelExperiment: experimentName}},
+			Spec:       optimizev1beta2.TrialSpec{ExperimentRef: &corev1.ObjectReference{Name: experimentName}},
+		}
+	}
+
+	priorities := map[string]int32{"high": 1000}
+
+	testCases := []struct {
+		desc        string
+		experiments []optimizev1beta2.Experiment
+		trials      []optimizev1beta2.Trial
+		limit       int32
+		expected    map[string]string
+	}{
+		{
+			desc:        "no limit",
+			experiments: []optimizev1beta2.Experiment{newExperiment("a", "", nil), newExperiment("b", "", nil)},
+			trials:      []optimizev1beta2.Trial{newTrial("a"), newTrial("b")},
+			expected:    map[string]string{"a": "", "b": ""},
+		},
+		{
+			desc:        "limit reached",
+			experiments: []optimizev1beta2.Experiment{newExperiment("a", "", nil), newExperiment("b", "", nil)},
+			trials:      []optimizev1beta2.Trial{newTrial("a"), newTrial("b")},
+			limit:       2,
+			expected: map[string]string{
+				"a": "Waiting for one of 2 active trials to finish (limit 2)",
+				"b": "Waiting for one of 2 active trials to finish (limit 2)",
+			},
+		},
+		{
+			desc:        "fair share",
+			experiments: []optimizev1beta2.Experiment{newExperiment("a", "", nil), newExperiment("b", "", nil)},
+			trials:      []optimizev1beta2.Trial{newTrial("a")},
+			limit:       2,
+			expected: map[string]string{
+				"a": "Queued behind 1 other experiments for 1 available trial slots",
+				"b": "",
+			},
+		},
+		{
+			desc:        "priority",
+			experiments: []optimizev1beta2.Experiment{newExperiment("a", "high", nil), newExperiment("b", "", nil)},
+			trials:      []optimizev1beta2.Trial{newTrial("a")},
+			limit:       2,
+			expected: map[string]string{
+				"a": "",
+				"b": "Queued behind 1 other experiments for 1 available trial slots",
+			},
+		},
+		{
+			desc:        "longest waiting",
+			experiments: []optimizev1beta2.Experiment{newExperiment("a", "", &now), newExperiment("b", "", &earlier), newExperiment("c", "", nil)},
+			limit:       1,
+			expected: map[string]string{
+				"a": "Queued behind 1 other experiments for 1 available trial slots",
+				"b": "",
+				"c": "Queued behind 2 other experiments for 1 available trial slots",
+			},
+		},
+		{
+			desc:        "outside schedule",
+			experiments: []optimizev1beta2.Experiment{outsideSchedule(newExperiment("a", "high", nil)), newExperiment("b", "", nil)},
+			limit:       1,
+			expected: map[string]string{
+				"a": "",
+				"b": "",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			experimentList := &optimizev1beta2.ExperimentList{Items: tc.experiments}
+			trialList := &optimizev1beta2.TrialList{Items: tc.trials}
+			for i := range experimentList.Items {
+				exp := &experimentList.Items[i]
+				assert.Equal(t, tc.expected[exp.Name], CheckConcurrency(exp, experimentList, trialList, priorities, tc.limit), exp.Name)
+			}
+		})
+	}
+}
+
+func TestApplyWaiting(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{}
+
+	assert.False(t, ApplyWaiting(exp, "", ""), "not waiting")
+	assert.Empty(t, exp.Status.Conditions)
+
+	assert.True(t, ApplyWaiting(exp, ReasonQueued, "Queued behind 1 other experiments"))
+	assert.False(t, ApplyWaiting(exp, ReasonQueued, "Queued behind 1 other experiments"), "unchanged")
+	assert.True(t, ApplyWaiting(exp, ReasonQueued, "Queued behind 2 other experiments"), "message changed")
+	assert.Equal(t, PhaseQueued, waitingPhase(exp))
+
+	// Changing the message keeps the wait time, changing the reason starts a new wait
+	since := metav1.NewTime(time.Now().Add(-time.Hour))
+	exp.Status.Conditions[0].LastTransitionTime = since
+	assert.True(t, ApplyWaiting(exp, ReasonQueued, "Queued behind 1 other experiments"))
+	assert.Equal(t, since, exp.Status.Conditions[0].LastTransitionTime)
+	assert.True(t, ApplyWaiting(exp, ReasonSchedule, "Waiting for the schedule window"), "reason changed")
+	assert.True(t, exp.Status.Conditions[0].LastTransitionTime.After(since.Time))
+	assert.Equal(t, PhaseWaitingForSchedule, waitingPhase(exp))
+
+	assert.True(t, ApplyWaiting(exp, "", ""), "no longer waiting")
+	assert.Equal(t, "", waitingPhase(exp))
+}
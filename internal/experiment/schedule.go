@@ -0,0 +1,94 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/cron"
+)
+
+// CheckSchedule determines if a new trial can start at the supplied time given the schedule windows of the
+// experiment. A trial can only start if the window will remain open for the approximate runtime of the trial. If the
+// trial cannot start, a message and the next time a trial could start are returned.
+func CheckSchedule(exp *optimizev1beta2.Experiment, now time.Time) (string, time.Time, error) {
+	s := exp.Spec.Schedule
+	if s == nil || len(s.Windows) == 0 {
+		return "", time.Time{}, nil
+	}
+
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid schedule time zone: %w", err)
+	}
+	now = now.In(loc)
+	runtime := trialRuntime(&exp.Spec.TrialTemplate.Spec)
+
+	var closes, next time.Time
+	for i, w := range s.Windows {
+		sched, err := cron.Parse(w.Start)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("invalid schedule window %d: %w", i, err)
+		}
+		d := w.Duration.Duration
+		if d <= 0 {
+			return "", time.Time{}, fmt.Errorf("invalid schedule window %d: duration must be positive", i)
+		}
+
+		// Find the latest close time of any occurrence of this window which is currently open
+		for start := sched.Next(now.Add(-d)); !start.IsZero() && !start.After(now); start = sched.Next(start) {
+			if c := start.Add(d); c.After(closes) {
+				closes = c
+			}
+		}
+
+		// Find the next occurrence of this window that is long enough to run a trial
+		if d >= runtime {
+			if start := sched.Next(now); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+
+	// Trials can start as long as they finish before the window closes
+	if !closes.IsZero() && !now.Add(runtime).After(closes) {
+		return "", time.Time{}, nil
+	}
+
+	if next.IsZero() {
+		return "", time.Time{}, fmt.Errorf("no schedule window is long enough for a trial runtime of %s", runtime)
+	}
+	if !closes.IsZero() {
+		return fmt.Sprintf("Not enough time left before the schedule window closes at %s, waiting until %s",
+			closes.Format(time.RFC3339), next.Format(time.RFC3339)), next, nil
+	}
+	return fmt.Sprintf("Waiting for the schedule window opening at %s", next.Format(time.RFC3339)), next, nil
+}
+
+// trialRuntime returns the expected amount of time required to run a trial
+func trialRuntime(spec *optimizev1beta2.TrialSpec) time.Duration {
+	d := time.Duration(spec.InitialDelaySeconds) * time.Second
+	if spec.StartTimeOffset != nil {
+		d += spec.StartTimeOffset.Duration
+	}
+	if spec.ApproximateRuntime != nil {
+		d += spec.ApproximateRuntime.Duration
+	}
+	return d
+}
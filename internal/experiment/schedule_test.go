@@ -0,0 +1,113 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckSchedule(t *testing.T) {
+	// Monday, 2021-03-01 22:30 UTC
+	now := time.Date(2021, time.March, 1, 22, 30, 0, 0, time.UTC)
+	nightly := optimizev1beta2.ScheduleWindow{Start: "0 22 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}}
+
+	testCases := []struct {
+		desc         string
+		schedule     *optimizev1beta2.TrialSchedule
+		runtime      time.Duration
+		expectedMsg  bool
+		expectedNext time.Time
+		expectedErr  bool
+	}{
+		{
+			desc: "no schedule",
+		},
+		{
+			desc:     "inside window",
+			schedule: &optimizev1beta2.TrialSchedule{Windows: []optimizev1beta2.ScheduleWindow{nightly}},
+			runtime:  time.Hour,
+		},
+		{
+			desc:         "not enough time",
+			schedule:     &optimizev1beta2.TrialSchedule{Windows: []optimizev1beta2.ScheduleWindow{nightly}},
+			runtime:      100 * time.Minute,
+			expectedMsg:  true,
+			expectedNext: time.Date(2021, time.March, 2, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			desc: "outside window",
+			schedule: &optimizev1beta2.TrialSchedule{Windows: []optimizev1beta2.ScheduleWindow{
+				{Start: "0 8 * * 1-5", Duration: metav1.Duration{Duration: 8 * time.Hour}},
+			}},
+			expectedMsg:  true,
+			expectedNext: time.Date(2021, time.March, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			desc: "time zone",
+			schedule: &optimizev1beta2.TrialSchedule{
+				TimeZone: "America/New_York",
+				Windows:  []optimizev1beta2.ScheduleWindow{{Start: "0 17 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}}},
+			},
+			runtime: time.Hour,
+		},
+		{
+			desc: "window too short",
+			schedule: &optimizev1beta2.TrialSchedule{Windows: []optimizev1beta2.ScheduleWindow{
+				{Start: "0 8 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}},
+			runtime:     2 * time.Hour,
+			expectedErr: true,
+		},
+		{
+			desc: "invalid expression",
+			schedule: &optimizev1beta2.TrialSchedule{Windows: []optimizev1beta2.ScheduleWindow{
+				{Start: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}},
+			expectedErr: true,
+		},
+		{
+			desc: "invalid time zone",
+			schedule: &optimizev1beta2.TrialSchedule{
+				TimeZone: "Nowhere/Special",
+				Windows:  []optimizev1beta2.ScheduleWindow{nightly},
+			},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{}
+			exp.Spec.Schedule = tc.schedule
+			exp.Spec.TrialTemplate.Spec.ApproximateRuntime = &metav1.Duration{Duration: tc.runtime}
+
+			msg, next, err := CheckSchedule(exp, now)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedMsg, msg != "", msg)
+				assert.True(t, tc.expectedNext.Equal(next), "expected %s, got %s", tc.expectedNext, next)
+			}
+		})
+	}
+}
@@ -49,6 +49,9 @@ func Walk(ctx context.Context, v Visitor, obj interface{}) {
 		Walk(withPath(ctx, "metrics"), v, o.Metrics)
 		Walk(withPath(ctx, "patches"), v, o.Patches)
 		Walk(withPath(ctx, "trialTemplate"), v, &o.TrialTemplate)
+		if o.WarmStart != nil {
+			Walk(withPath(ctx, "warmStart"), v, o.WarmStart)
+		}
 
 	case []optimizev1beta2.Optimization:
 		for i := range o {
@@ -90,6 +93,9 @@ func Walk(ctx context.Context, v Visitor, obj interface{}) {
 	case *batchv1beta1.JobTemplateSpec:
 		// Do nothing
 
+	case *optimizev1beta2.WarmStart:
+		// Do nothing
+
 	default:
 		panic(fmt.Sprintf("experiment.Walk: unexpected type %T", obj))
 	}
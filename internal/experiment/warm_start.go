@@ -0,0 +1,155 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"strconv"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	"github.com/thestormforge/optimize-controller/v2/internal/validation"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MaxWarmStartTrials is the maximum number of trials imported from a previous experiment
+const MaxWarmStartTrials = 50
+
+// WarmStartExperimentName returns the namespaced name of the previous in-cluster experiment to warm start from, or
+// an empty name if the warm start does not reference an in-cluster experiment. The previous experiment must be in the
+// same namespace, otherwise the trials of any namespace could be read using the privileges of the controller.
+func WarmStartExperimentName(exp *optimizev1beta2.Experiment) (types.NamespacedName, error) {
+	ws := exp.Spec.WarmStart
+	if ws == nil || ws.ExperimentRef == nil {
+		return types.NamespacedName{}, nil
+	}
+
+	if ns := ws.ExperimentRef.Namespace; ns != "" && ns != exp.Namespace {
+		return types.NamespacedName{}, fmt.Errorf("warm start experiment namespace %q must be the experiment namespace", ns)
+	}
+	return types.NamespacedName{Namespace: exp.Namespace, Name: ws.ExperimentRef.Name}, nil
+}
+
+// WarmStartParameters returns the parameters of the experiment using the names of the previous experiment. This can
+// be used to interpret the assignments of a previous experiment when only the server representation is available;
+// the values will be decoded incorrectly if the previous experiment used a different log scale.
+func WarmStartParameters(exp *optimizev1beta2.Experiment) []optimizev1beta2.Parameter {
+	names := make(map[string]string)
+	if exp.Spec.WarmStart != nil {
+		for _, m := range exp.Spec.WarmStart.ParameterMappings {
+			names[m.To] = m.From
+		}
+	}
+
+	params := make([]optimizev1beta2.Parameter, 0, len(exp.Spec.Parameters))
+	for i := range exp.Spec.Parameters {
+		p := exp.Spec.Parameters[i].DeepCopy()
+		if name, ok := names[p.Name]; ok {
+			p.Name = name
+		}
+		params = append(params, *p)
+	}
+	return params
+}
+
+// WarmStartTrials returns the completed trials of a previous experiment which are compatible with the supplied
+// experiment. Assignments are renamed using the parameter mappings of the warm start and assignments of parameters
+// that no longer exist are dropped. New parameters are assigned their baseline value. Trials with assignments which
+// are out of range or missing values for any of the optimized metrics are skipped. At most `MaxWarmStartTrials` trials
+// are returned.
+func WarmStartTrials(exp *optimizev1beta2.Experiment, trials []optimizev1beta2.Trial) []optimizev1beta2.Trial {
+	names := make(map[string]string)
+	if exp.Spec.WarmStart != nil {
+		for _, m := range exp.Spec.WarmStart.ParameterMappings {
+			names[m.From] = m.To
+		}
+	}
+
+	params := make(map[string]*optimizev1beta2.Parameter, len(exp.Spec.Parameters))
+	for i := range exp.Spec.Parameters {
+		params[exp.Spec.Parameters[i].Name] = &exp.Spec.Parameters[i]
+	}
+
+	var result []optimizev1beta2.Trial
+	for i := range trials {
+		t := &trials[i]
+		if !trial.CheckCondition(&t.Status, optimizev1beta2.TrialComplete, corev1.ConditionTrue) ||
+			trial.CheckCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue) {
+			continue
+		}
+
+		wt := optimizev1beta2.Trial{}
+		wt.Name = t.Name
+		wt.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+
+		assigned := make(map[string]bool, len(t.Spec.Assignments))
+		for _, a := range t.Spec.Assignments {
+			if name, ok := names[a.Name]; ok {
+				a.Name = name
+			}
+			if _, ok := params[a.Name]; ok {
+				wt.Spec.Assignments = append(wt.Spec.Assignments, a)
+				assigned[a.Name] = true
+			}
+		}
+		for _, p := range exp.Spec.Parameters {
+			if !assigned[p.Name] && p.Baseline != nil {
+				wt.Spec.Assignments = append(wt.Spec.Assignments, optimizev1beta2.Assignment{Name: p.Name, Value: *p.Baseline, Float: p.Float != nil})
+			}
+		}
+		if err := validation.CheckAssignments(&wt, exp); err != nil {
+			continue
+		}
+
+		if !warmStartValues(exp, t, &wt) {
+			continue
+		}
+
+		result = append(result, wt)
+		if len(result) >= MaxWarmStartTrials {
+			break
+		}
+	}
+	return result
+}
+
+// warmStartValues copies the values of the metrics which are still part of the experiment, returning false if any
+// optimized metric does not have a value.
+func warmStartValues(exp *optimizev1beta2.Experiment, from, to *optimizev1beta2.Trial) bool {
+	for i := range exp.Spec.Metrics {
+		m := &exp.Spec.Metrics[i]
+
+		var found bool
+		for _, v := range from.Spec.Values {
+			if v.Name != m.Name || v.AttemptsRemaining != 0 {
+				continue
+			}
+			if _, err := strconv.ParseFloat(v.Value, 64); err != nil {
+				continue
+			}
+			to.Spec.Values = append(to.Spec.Values, optimizev1beta2.Value{Name: v.Name, Value: v.Value, Error: v.Error})
+			found = true
+			break
+		}
+
+		if !found && (m.Optimize == nil || *m.Optimize) {
+			return false
+		}
+	}
+	return true
+}
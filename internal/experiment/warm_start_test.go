@@ -0,0 +1,107 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestWarmStartTrials(t *testing.T) {
+	notOptimized := false
+	two := intstr.FromInt(2)
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "v2", Namespace: "default"},
+		Spec: optimizev1beta2.ExperimentSpec{
+			WarmStart: &optimizev1beta2.WarmStart{
+				ExperimentRef:     &corev1.ObjectReference{Name: "v1"},
+				ParameterMappings: []optimizev1beta2.ParameterMapping{{From: "cpu_millis", To: "cpu"}},
+			},
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "cpu", Min: 100, Max: 1000},
+				{Name: "replicas", Min: 1, Max: 5, Baseline: &two},
+			},
+			Metrics: []optimizev1beta2.Metric{
+				{Name: "cost", Minimize: true},
+				{Name: "reported", Optimize: &notOptimized},
+			},
+		},
+	}
+
+	warmStartTrial := func(name string, cpu int, values ...optimizev1beta2.Value) optimizev1beta2.Trial {
+		wt := optimizev1beta2.Trial{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		wt.Spec.Assignments = []optimizev1beta2.Assignment{
+			{Name: "cpu_millis", Value: intstr.FromInt(cpu)},
+			{Name: "memory", Value: intstr.FromInt(512)},
+		}
+		wt.Spec.Values = values
+		wt.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+		return wt
+	}
+
+	unfinished := warmStartTrial("unfinished", 500)
+	unfinished.Status.Conditions = nil
+	failed := warmStartTrial("failed", 500, optimizev1beta2.Value{Name: "cost", Value: "1"})
+	failed.Status.Conditions = append(failed.Status.Conditions, optimizev1beta2.TrialCondition{Type: optimizev1beta2.TrialFailed, Status: corev1.ConditionTrue})
+
+	trials := []optimizev1beta2.Trial{
+		warmStartTrial("compatible", 500, optimizev1beta2.Value{Name: "cost", Value: "1.5"}, optimizev1beta2.Value{Name: "removed", Value: "3"}),
+		warmStartTrial("out-of-range", 2000, optimizev1beta2.Value{Name: "cost", Value: "1"}),
+		warmStartTrial("missing-metric", 500, optimizev1beta2.Value{Name: "reported", Value: "1"}),
+		warmStartTrial("pending-metric", 500, optimizev1beta2.Value{Name: "cost", Value: "1", AttemptsRemaining: 1}),
+		unfinished,
+		failed,
+	}
+
+	many := make([]optimizev1beta2.Trial, MaxWarmStartTrials+10)
+	for i := range many {
+		many[i] = warmStartTrial("compatible", 100+i, optimizev1beta2.Value{Name: "cost", Value: "1"})
+	}
+	assert.Len(t, WarmStartTrials(exp, many), MaxWarmStartTrials)
+
+	actual := WarmStartTrials(exp, trials)
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, "compatible", actual[0].Name)
+		assert.Equal(t, []optimizev1beta2.Assignment{
+			{Name: "cpu", Value: intstr.FromInt(500)},
+			{Name: "replicas", Value: intstr.FromInt(2)},
+		}, actual[0].Spec.Assignments)
+		assert.Equal(t, []optimizev1beta2.Value{{Name: "cost", Value: "1.5"}}, actual[0].Spec.Values)
+	}
+
+	nn, err := WarmStartExperimentName(exp)
+	if assert.NoError(t, err) {
+		assert.Equal(t, types.NamespacedName{Namespace: "default", Name: "v1"}, nn)
+	}
+
+	exp.Spec.WarmStart.ExperimentRef.Namespace = "other"
+	_, err = WarmStartExperimentName(exp)
+	assert.Error(t, err)
+
+	params := WarmStartParameters(exp)
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, "cpu_millis", params[0].Name)
+		assert.Equal(t, "replicas", params[1].Name)
+		assert.Equal(t, "cpu", exp.Spec.Parameters[0].Name)
+	}
+}
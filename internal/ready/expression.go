@@ -0,0 +1,227 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// expressionOperators are the supported comparison operators, longer operators must come first
+var expressionOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// expression evaluates a comparison between JSONPath expressions or literals against the full object
+func (r *ReadinessChecker) expression(obj *unstructured.Unstructured, conditionType string) (string, corev1.ConditionStatus, error) {
+	// In this case the condition type is "stormforge.io/expression-<LHS> [<OP> <RHS>]"
+	expr := strings.TrimSpace(strings.TrimPrefix(conditionType, ConditionTypeExpression))
+	lhs, op, rhs, err := splitExpression(expr)
+	if err != nil {
+		return "", corev1.ConditionFalse, invalidExpression(expr, err)
+	}
+
+	// Evaluate both sides of the expression
+	lv, lok, err := evaluateOperand(obj, lhs)
+	if err != nil {
+		return "", corev1.ConditionFalse, invalidExpression(expr, err)
+	}
+	if op == "" {
+		if lok && isTruthy(lv) {
+			return "", corev1.ConditionTrue, nil
+		}
+		return fmt.Sprintf("Expression %q evaluated to %q", expr, lv), corev1.ConditionFalse, nil
+	}
+	rv, rok, err := evaluateOperand(obj, rhs)
+	if err != nil {
+		return "", corev1.ConditionFalse, invalidExpression(expr, err)
+	}
+
+	// Use "unknown" if either side of the expression could not be found
+	msg := fmt.Sprintf("Expression %q evaluated to %q", expr, strings.Join([]string{lv, op, rv}, " "))
+	if !lok || !rok {
+		return msg, corev1.ConditionUnknown, nil
+	}
+
+	ok, err := compareOperands(lv, op, rv)
+	if err != nil {
+		return "", corev1.ConditionFalse, invalidExpression(expr, err)
+	}
+	if ok {
+		return "", corev1.ConditionTrue, nil
+	}
+	return msg, corev1.ConditionFalse, nil
+}
+
+// invalidExpression returns a readiness error for an expression that can never be evaluated
+func invalidExpression(expr string, err error) error {
+	return &ReadinessError{
+		error:   "invalid expression",
+		Reason:  "InvalidExpression",
+		Message: fmt.Sprintf("invalid expression condition %q: %s", expr, err.Error()),
+	}
+}
+
+// splitExpression splits an expression on the first comparison operator that is not nested in a JSONPath filter
+// or quoted string literal.
+func splitExpression(expr string) (string, string, string, error) {
+	if expr == "" {
+		return "", "", "", fmt.Errorf("expression is empty")
+	}
+
+	var lhs, op, rhs string
+	depth := 0
+	var quote rune
+	for i, c := range expr {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+			continue
+		case c == '[' || c == '(' || c == '{':
+			depth++
+			continue
+		case c == ']' || c == ')' || c == '}':
+			depth--
+			continue
+		case depth > 0 || op != "":
+			continue
+		}
+
+		for _, o := range expressionOperators {
+			if strings.HasPrefix(expr[i:], o) {
+				lhs, op, rhs = expr[:i], o, expr[i+len(o):]
+				break
+			}
+		}
+	}
+
+	switch {
+	case quote != 0:
+		return "", "", "", fmt.Errorf("unterminated string literal")
+	case depth != 0:
+		return "", "", "", fmt.Errorf("unbalanced brackets")
+	case op == "":
+		return expr, "", "", nil
+	}
+
+	lhs, rhs = strings.TrimSpace(lhs), strings.TrimSpace(rhs)
+	if lhs == "" || rhs == "" {
+		return "", "", "", fmt.Errorf("operator %s requires two operands", op)
+	}
+	return lhs, op, rhs, nil
+}
+
+// evaluateOperand returns the string representation of an operand and a flag indicating if the value was found.
+// Operands may be quoted strings, numbers, booleans or JSONPath expressions (with or without the enclosing braces).
+func evaluateOperand(obj *unstructured.Unstructured, operand string) (string, bool, error) {
+	if s, err := strconv.Unquote(operand); err == nil {
+		return s, true, nil
+	}
+	if len(operand) > 1 && strings.HasPrefix(operand, "'") && strings.HasSuffix(operand, "'") {
+		return operand[1 : len(operand)-1], true, nil
+	}
+	if _, err := strconv.ParseFloat(operand, 64); err == nil {
+		return operand, true, nil
+	}
+	if _, err := strconv.ParseBool(operand); err == nil {
+		return operand, true, nil
+	}
+
+	// Allow the shorthand "status.phase" in place of "{.status.phase}"
+	if !strings.HasPrefix(operand, "{") {
+		operand = "{." + strings.TrimPrefix(operand, ".") + "}"
+	}
+
+	jp := jsonpath.New("expression").AllowMissingKeys(true)
+	if err := jp.Parse(operand); err != nil {
+		return "", false, err
+	}
+	results, err := jp.FindResults(obj.UnstructuredContent())
+	if err != nil {
+		return "", false, err
+	}
+
+	var values []string
+	for _, r := range results {
+		for _, v := range r {
+			switch vv := v.Interface().(type) {
+			case string:
+				values = append(values, vv)
+			case map[string]interface{}, []interface{}:
+				data, err := json.Marshal(vv)
+				if err != nil {
+					return "", false, err
+				}
+				values = append(values, string(data))
+			default:
+				values = append(values, fmt.Sprint(vv))
+			}
+		}
+	}
+	if len(values) == 0 {
+		return "<none>", false, nil
+	}
+	return strings.Join(values, " "), true, nil
+}
+
+// compareOperands compares two values, numerically if possible; strings are compared exactly
+func compareOperands(lhs, op, rhs string) (bool, error) {
+	lf, lerr := strconv.ParseFloat(lhs, 64)
+	rf, rerr := strconv.ParseFloat(rhs, 64)
+	if lerr == nil && rerr == nil {
+		switch op {
+		case "==":
+			return lf == rf, nil
+		case "!=":
+			return lf != rf, nil
+		case "<":
+			return lf < rf, nil
+		case "<=":
+			return lf <= rf, nil
+		case ">":
+			return lf > rf, nil
+		case ">=":
+			return lf >= rf, nil
+		}
+	}
+
+	switch op {
+	case "==":
+		return lhs == rhs, nil
+	case "!=":
+		return lhs != rhs, nil
+	}
+	return false, fmt.Errorf("operator %s requires numeric values", op)
+}
+
+// isTruthy checks if a value should be considered "true"
+func isTruthy(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "false", "0", "<none>":
+		return false
+	}
+	return true
+}
@@ -19,13 +19,20 @@ package ready
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/scale/scheme/extensionsv1beta1"
 	"k8s.io/kubectl/pkg/polymorphichelpers"
@@ -46,16 +53,43 @@ const (
 	// ConditionTypeAppReady is a special condition type that combines the efficiency of the rollout status check,
 	// the compatibility of the pod ready check.
 	ConditionTypeAppReady = "stormforge.io/app-ready"
+	// ConditionTypeAppSettled is a special condition type that extends the "app ready" check to also wait for any
+	// horizontal pod autoscalers targeting the object to stabilize and for all of the pods to be scheduled. An
+	// optional stabilization window and unschedulable deadline may be appended to this constant, e.g.
+	// `"stormforge.io/app-settled-2m-15m"` to require the autoscaler go two minutes without scaling and to fail
+	// if any pod remains unschedulable for more then fifteen minutes (e.g. waiting for the cluster autoscaler).
+	ConditionTypeAppSettled = "stormforge.io/app-settled"
 	// ConditionTypeStatus is a special condition type that can be used to check an arbitrary string on the status
 	// of the target object. The name of the status field and the expected value (indicating a ready state) should
 	// be appended to this constant, e.g. `"stormforge.io/status-phase-running"` to check for a running pod.
 	ConditionTypeStatus = "stormforge.io/status-"
+	// ConditionTypeHTTP is a special condition type that issues an HTTP GET request against the target object. The
+	// port, (optional) path and (optional) expected status code should be appended to this constant, e.g.
+	// `"stormforge.io/http-8080-healthz-204"` to request `/healthz` on port 8080 and expect a 204 response. Services
+	// are requested using their cluster DNS name, pods (including those selected by a workload) are requested using
+	// their IP address; the condition is "True" when every request returns the expected status code (or any status
+	// code greater than or equal to 200 and less than 400 if no status code is specified). A workload without pods
+	// is never "True".
+	ConditionTypeHTTP = "stormforge.io/http-"
+	// ConditionTypeExpression is a special condition type that evaluates a comparison against the full target object.
+	// The expression should be appended to this constant, e.g. `"stormforge.io/expression-status.readyReplicas == spec.replicas"`.
+	// Operands may be JSONPath expressions (the enclosing braces and leading dot are optional) or quoted string,
+	// numeric or boolean literals; supported operators are `==`, `!=`, `<`, `<=`, `>` and `>=` (non-numeric values
+	// only support `==` and `!=`, which are case sensitive). An expression
+	// without an operator is "True" if the value is present and not empty, "false" or "0".
+	ConditionTypeExpression = "stormforge.io/expression-"
 )
 
 // ReadinessChecker is used to check the conditions of runtime objects
 type ReadinessChecker struct {
 	// Reader is used to fetch information about objects related to the object whose conditions are being checked
 	Reader client.Reader
+	// HTTPClient is used to issue the requests of HTTP conditions, it should have a timeout configured (if nil, the
+	// default client is used)
+	HTTPClient *http.Client
+	// Started is the time the readiness check started, it is used in place of the last scale time of autoscalers
+	// that have not scaled yet (if zero, autoscalers that have not scaled are considered stable)
+	Started time.Time
 }
 
 // ReadinessError is an error that occurs while testing for readiness, it indicates a "hard failure" and is not just
@@ -99,6 +133,12 @@ func (r *ReadinessChecker) CheckConditions(ctx context.Context, obj *unstructure
 		default:
 			if strings.HasPrefix(c, ConditionTypeStatus) {
 				msg, s, err = r.statusField(obj, c)
+			} else if strings.HasPrefix(c, ConditionTypeAppSettled) {
+				msg, s, err = r.appSettled(ctx, obj, c)
+			} else if strings.HasPrefix(c, ConditionTypeExpression) {
+				msg, s, err = r.expression(obj, c)
+			} else if strings.HasPrefix(c, ConditionTypeHTTP) {
+				msg, s, err = r.httpGet(ctx, obj, c)
 			} else {
 				msg, s, err = r.unstructuredConditionStatus(obj, c)
 			}
@@ -114,8 +154,14 @@ func (r *ReadinessChecker) CheckConditions(ctx context.Context, obj *unstructure
 			continue
 		}
 
+		// Allow pods to be unschedulable for a while if we are waiting for things to settle
+		var unschedulableDeadline time.Duration
+		if strings.HasPrefix(c, ConditionTypeAppSettled) {
+			_, unschedulableDeadline, _ = appSettledDurations(c)
+		}
+
 		// Make sure it's not a hard fail
-		if err := r.podFailed(ctx, obj); err != nil {
+		if err := r.podFailed(ctx, obj, unschedulableDeadline); err != nil {
 			return "", false, err
 		}
 
@@ -193,6 +239,84 @@ func (r *ReadinessChecker) statusField(obj *unstructured.Unstructured, condition
 	return msg, corev1.ConditionFalse, nil
 }
 
+// httpGet issues HTTP requests against the endpoints of the target object
+func (r *ReadinessChecker) httpGet(ctx context.Context, obj *unstructured.Unstructured, conditionType string) (string, corev1.ConditionStatus, error) {
+	// In this case the condition type is "stormforge.io/http-<PORT>[-<PATH>][-<CODE>]" so we must parse out the
+	// port, path and expected status code (a trailing three digit number is always the status code)
+	pp := strings.SplitN(strings.TrimPrefix(conditionType, ConditionTypeHTTP), "-", 2)
+	port, err := strconv.ParseInt(pp[0], 10, 32)
+	if err != nil || port <= 0 {
+		return "", corev1.ConditionFalse, fmt.Errorf("invalid HTTP condition: %s", conditionType)
+	}
+	var urlPath string
+	if len(pp) > 1 {
+		urlPath = pp[1]
+	}
+	var statusCode int
+	if pos := strings.LastIndex(urlPath, "-") + 1; len(urlPath)-pos == 3 {
+		if code, err := strconv.Atoi(urlPath[pos:]); err == nil && code >= 100 && code < 600 {
+			statusCode = code
+			urlPath = strings.TrimSuffix(urlPath[:pos], "-")
+		}
+	}
+
+	// Determine the hosts to send requests to
+	var hosts []string
+	switch obj.GetObjectKind().GroupVersionKind().GroupKind() {
+	case corev1.SchemeGroupVersion.WithKind("Service").GroupKind():
+		hosts = append(hosts, fmt.Sprintf("%s.%s", obj.GetName(), obj.GetNamespace()))
+	case corev1.SchemeGroupVersion.WithKind("Pod").GroupKind():
+		podIP, _, _ := unstructured.NestedString(obj.UnstructuredContent(), "status", "podIP")
+		if podIP == "" {
+			return "Pod has not been assigned an IP address", corev1.ConditionFalse, nil
+		}
+		hosts = append(hosts, podIP)
+	default:
+		list, err := r.listPods(ctx, obj)
+		if err != nil {
+			return "", corev1.ConditionFalse, err
+		}
+		for i := range list.Items {
+			if list.Items[i].Status.PodIP == "" {
+				return "Pod has not been assigned an IP address", corev1.ConditionFalse, nil
+			}
+			hosts = append(hosts, list.Items[i].Status.PodIP)
+		}
+		if len(hosts) == 0 {
+			return "No pods", corev1.ConditionFalse, nil
+		}
+	}
+
+	hc := r.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	// Every host must respond successfully
+	for _, host := range hosts {
+		u := url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.FormatInt(port, 10)), Path: "/" + strings.TrimPrefix(urlPath, "/")}
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return "", corev1.ConditionFalse, err
+		}
+
+		// Connection failures are expected while the application is starting, do not treat them as hard failures
+		resp, err := hc.Do(req.WithContext(ctx))
+		if err != nil {
+			return err.Error(), corev1.ConditionFalse, nil
+		}
+		_ = resp.Body.Close()
+
+		if statusCode != 0 && resp.StatusCode != statusCode {
+			return fmt.Sprintf("GET %s returned %s, expected %d", u.String(), resp.Status, statusCode), corev1.ConditionFalse, nil
+		} else if statusCode == 0 && (resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest) {
+			return fmt.Sprintf("GET %s returned %s", u.String(), resp.Status), corev1.ConditionFalse, nil
+		}
+	}
+
+	return "", corev1.ConditionTrue, nil
+}
+
 // appReady performs a rollout status check and falls back to a pod ready check
 func (r *ReadinessChecker) appReady(ctx context.Context, obj *unstructured.Unstructured) (string, corev1.ConditionStatus, error) {
 	// Get the kubectl status viewer for the object, if no status viewer is available, fall back to pod ready
@@ -215,6 +339,91 @@ func (r *ReadinessChecker) appReady(ctx context.Context, obj *unstructured.Unstr
 
 }
 
+// appSettled performs an app ready check and then waits for autoscaling to stabilize
+func (r *ReadinessChecker) appSettled(ctx context.Context, obj *unstructured.Unstructured, conditionType string) (string, corev1.ConditionStatus, error) {
+	window, _, err := appSettledDurations(conditionType)
+	if err != nil {
+		return "", corev1.ConditionFalse, err
+	}
+
+	// The application must be ready before it can settle
+	if msg, s, err := r.appReady(ctx, obj); s != corev1.ConditionTrue || err != nil {
+		return msg, s, err
+	}
+
+	// Wait for the horizontal pod autoscalers targeting this object to stop scaling
+	hpaList := &autoscalingv1.HorizontalPodAutoscalerList{}
+	if err := r.Reader.List(ctx, hpaList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return "", corev1.ConditionFalse, err
+	}
+	for i := range hpaList.Items {
+		hpa := &hpaList.Items[i]
+		if !isScaleTarget(hpa, obj) {
+			continue
+		}
+
+		if hpa.Status.CurrentReplicas != hpa.Status.DesiredReplicas {
+			return fmt.Sprintf("Waiting for HorizontalPodAutoscaler %s to scale from %d to %d replicas",
+				hpa.Name, hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas), corev1.ConditionFalse, nil
+		}
+
+		lastScaleTime := r.Started
+		if hpa.Status.LastScaleTime != nil {
+			lastScaleTime = hpa.Status.LastScaleTime.Time
+		}
+		if !lastScaleTime.IsZero() && time.Since(lastScaleTime) < window {
+			return fmt.Sprintf("Waiting for HorizontalPodAutoscaler %s to stabilize", hpa.Name), corev1.ConditionFalse, nil
+		}
+	}
+
+	// Wait for all of the pods to be scheduled (e.g. the cluster autoscaler may still be adding nodes)
+	list, err := r.listPods(ctx, obj)
+	if err != nil {
+		return "", corev1.ConditionFalse, err
+	}
+	for i := range list.Items {
+		if list.Items[i].Status.Phase == corev1.PodPending {
+			return fmt.Sprintf("Waiting for pod %s to be scheduled", list.Items[i].Name), corev1.ConditionFalse, nil
+		}
+	}
+
+	return "", corev1.ConditionTrue, nil
+}
+
+// isScaleTarget checks to see if the supplied object is the scale target of an autoscaler
+func isScaleTarget(hpa *autoscalingv1.HorizontalPodAutoscaler, obj *unstructured.Unstructured) bool {
+	ref := hpa.Spec.ScaleTargetRef
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return false
+	}
+	gvk := obj.GroupVersionKind()
+	return gv.Group == gvk.Group && ref.Kind == gvk.Kind && ref.Name == obj.GetName()
+}
+
+// appSettledDurations returns the stabilization window and unschedulable deadline of an "app settled" condition
+func appSettledDurations(conditionType string) (time.Duration, time.Duration, error) {
+	// In this case the condition type is "stormforge.io/app-settled[-<WINDOW>[-<DEADLINE>]]"
+	window, deadline := 1*time.Minute, 10*time.Minute
+	dd := strings.Split(strings.TrimPrefix(conditionType, ConditionTypeAppSettled), "-")
+	if dd[0] != "" || len(dd) > 3 {
+		return 0, 0, fmt.Errorf("invalid app settled condition: %s", conditionType)
+	}
+
+	var err error
+	if len(dd) > 1 {
+		if window, err = time.ParseDuration(dd[1]); err != nil {
+			return 0, 0, fmt.Errorf("invalid app settled condition: %s", conditionType)
+		}
+	}
+	if len(dd) > 2 {
+		if deadline, err = time.ParseDuration(dd[2]); err != nil {
+			return 0, 0, fmt.Errorf("invalid app settled condition: %s", conditionType)
+		}
+	}
+	return window, deadline, nil
+}
+
 // rolloutStatus uses the kubectl implementation of rollout status to get the status of an object
 func (r *ReadinessChecker) rolloutStatus(obj *unstructured.Unstructured) (string, corev1.ConditionStatus, error) {
 	// Get the kubectl status viewer for the object
@@ -258,8 +467,9 @@ func (r *ReadinessChecker) podReady(ctx context.Context, obj *unstructured.Unstr
 	return "", corev1.ConditionTrue, nil
 }
 
-// podFailed looks for pods that are obviously in a failed state and are unlikely to recover
-func (r *ReadinessChecker) podFailed(ctx context.Context, obj *unstructured.Unstructured) error {
+// podFailed looks for pods that are obviously in a failed state and are unlikely to recover, pods are only considered
+// failed for being unschedulable once they have been unschedulable for longer then the supplied deadline
+func (r *ReadinessChecker) podFailed(ctx context.Context, obj *unstructured.Unstructured, unschedulableDeadline time.Duration) error {
 	// Get the list of pods for the object
 	list, err := r.listPods(ctx, obj)
 	if err != nil {
@@ -272,7 +482,8 @@ func (r *ReadinessChecker) podFailed(ctx context.Context, obj *unstructured.Unst
 
 		for _, c := range p.Status.Conditions {
 			// Check for unschedulable pods
-			if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
+			if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable &&
+				time.Since(c.LastTransitionTime.Time) >= unschedulableDeadline {
 				return &ReadinessError{error: "pod unschedulable", Reason: c.Reason, Message: c.Message}
 			}
 		}
@@ -18,10 +18,15 @@ package ready
 
 import (
 	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -31,10 +36,36 @@ import (
 )
 
 func TestReadinessChecker_CheckConditions(t *testing.T) {
+	httpTest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer httpTest.Close()
+	httpTestHost, httpTestPort, _ := net.SplitHostPort(httpTest.Listener.Addr().String())
+
+	replicas := int32(3)
+	settledDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"test": "test"},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          3,
+			UpdatedReplicas:   3,
+			AvailableReplicas: 3,
+			ReadyReplicas:     3,
+		},
+	}
+
 	cases := []struct {
 		desc           string
 		objs           []runtime.Object
 		conditionTypes []string
+		started        time.Duration
 		msg            string
 		ready          bool
 		err            error
@@ -202,6 +233,341 @@ func TestReadinessChecker_CheckConditions(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:           "app-settled",
+			conditionTypes: []string{ConditionTypeAppSettled},
+			ready:          true,
+
+			objs: []runtime.Object{
+				settledDeployment.DeepCopy(),
+				&autoscalingv1.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Name: "test"},
+					Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "test"},
+					},
+					Status: autoscalingv1.HorizontalPodAutoscalerStatus{
+						CurrentReplicas: 3,
+						DesiredReplicas: 3,
+						LastScaleTime:   &metav1.Time{Time: time.Now().Add(-5 * time.Minute)},
+					},
+				},
+			},
+		},
+		{
+			desc:           "app-settled-scaling",
+			conditionTypes: []string{ConditionTypeAppSettled},
+			ready:          false,
+			msg:            "Waiting for HorizontalPodAutoscaler test to scale from 3 to 5 replicas",
+
+			objs: []runtime.Object{
+				settledDeployment.DeepCopy(),
+				&autoscalingv1.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Name: "test"},
+					Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "test"},
+					},
+					Status: autoscalingv1.HorizontalPodAutoscalerStatus{
+						CurrentReplicas: 3,
+						DesiredReplicas: 5,
+					},
+				},
+			},
+		},
+		{
+			desc:           "app-settled-window",
+			conditionTypes: []string{ConditionTypeAppSettled + "-10m"},
+			ready:          false,
+			msg:            "Waiting for HorizontalPodAutoscaler test to stabilize",
+
+			objs: []runtime.Object{
+				settledDeployment.DeepCopy(),
+				&autoscalingv1.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Name: "test"},
+					Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "test"},
+					},
+					Status: autoscalingv1.HorizontalPodAutoscalerStatus{
+						CurrentReplicas: 3,
+						DesiredReplicas: 3,
+						LastScaleTime:   &metav1.Time{Time: time.Now().Add(-5 * time.Minute)},
+					},
+				},
+			},
+		},
+		{
+			desc:           "app-settled-unscaled",
+			conditionTypes: []string{ConditionTypeAppSettled + "-10m"},
+			started:        5 * time.Minute,
+			ready:          false,
+			msg:            "Waiting for HorizontalPodAutoscaler test to stabilize",
+
+			objs: []runtime.Object{
+				settledDeployment.DeepCopy(),
+				&autoscalingv1.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Name: "test"},
+					Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "test"},
+					},
+					Status: autoscalingv1.HorizontalPodAutoscalerStatus{
+						CurrentReplicas: 3,
+						DesiredReplicas: 3,
+					},
+				},
+			},
+		},
+		{
+			desc:           "app-settled-other-group",
+			conditionTypes: []string{ConditionTypeAppSettled},
+			ready:          true,
+
+			objs: []runtime.Object{
+				settledDeployment.DeepCopy(),
+				&autoscalingv1.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Name: "test"},
+					Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "example.com/v1", Kind: "Deployment", Name: "test"},
+					},
+					Status: autoscalingv1.HorizontalPodAutoscalerStatus{
+						CurrentReplicas: 3,
+						DesiredReplicas: 5,
+					},
+				},
+			},
+		},
+		{
+			desc:           "app-settled-pending",
+			conditionTypes: []string{ConditionTypeAppSettled},
+			ready:          false,
+			msg:            "Waiting for pod test to be scheduled",
+
+			objs: []runtime.Object{
+				settledDeployment.DeepCopy(),
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"test": "test"}},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+						Conditions: []corev1.PodCondition{{
+							Type:               corev1.PodScheduled,
+							Status:             corev1.ConditionFalse,
+							Reason:             corev1.PodReasonUnschedulable,
+							LastTransitionTime: metav1.Time{Time: time.Now().Add(-5 * time.Minute)},
+						}},
+					},
+				},
+			},
+		},
+		{
+			desc:           "app-settled-unschedulable",
+			conditionTypes: []string{ConditionTypeAppSettled + "-1m-2m"},
+			err: &ReadinessError{
+				Reason: corev1.PodReasonUnschedulable,
+				error:  "pod unschedulable",
+			},
+
+			objs: []runtime.Object{
+				settledDeployment.DeepCopy(),
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"test": "test"}},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+						Conditions: []corev1.PodCondition{{
+							Type:               corev1.PodScheduled,
+							Status:             corev1.ConditionFalse,
+							Reason:             corev1.PodReasonUnschedulable,
+							LastTransitionTime: metav1.Time{Time: time.Now().Add(-5 * time.Minute)},
+						}},
+					},
+				},
+			},
+		},
+		{
+			desc:           "expression-replicas",
+			conditionTypes: []string{ConditionTypeExpression + "status.readyReplicas == spec.replicas"},
+			ready:          true,
+
+			objs: []runtime.Object{
+				&appsv1.Deployment{
+					Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+					Status: appsv1.DeploymentStatus{ReadyReplicas: 3},
+				},
+			},
+		},
+		{
+			desc:           "expression-replicas-not-ready",
+			conditionTypes: []string{ConditionTypeExpression + "{.status.readyReplicas} >= {.spec.replicas}"},
+			ready:          false,
+			msg:            `Expression "{.status.readyReplicas} >= {.spec.replicas}" evaluated to "1 >= 3"`,
+
+			objs: []runtime.Object{
+				&appsv1.Deployment{
+					Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+					Status: appsv1.DeploymentStatus{ReadyReplicas: 1},
+				},
+			},
+		},
+		{
+			desc:           "expression-condition-filter",
+			conditionTypes: []string{ConditionTypeExpression + `status.conditions[?(@.type=="Available")].status == "True"`},
+			ready:          true,
+
+			objs: []runtime.Object{
+				&appsv1.Deployment{
+					Status: appsv1.DeploymentStatus{
+						Conditions: []appsv1.DeploymentCondition{
+							{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse},
+							{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:           "expression-case-sensitive",
+			conditionTypes: []string{ConditionTypeExpression + "status.phase == 'running'"},
+			ready:          false,
+			msg:            `Expression "status.phase == 'running'" evaluated to "Running == running"`,
+
+			objs: []runtime.Object{
+				&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+			},
+		},
+		{
+			desc:           "expression-missing",
+			conditionTypes: []string{ConditionTypeExpression + "status.phase == 'Synced'"},
+			ready:          false,
+			msg:            `Expression "status.phase == 'Synced'" evaluated to "<none> == Synced"`,
+
+			objs: []runtime.Object{
+				&corev1.Pod{},
+			},
+		},
+		{
+			desc:           "expression-truthy",
+			conditionTypes: []string{ConditionTypeExpression + "status.podIP"},
+			ready:          true,
+
+			objs: []runtime.Object{
+				&corev1.Pod{Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+			},
+		},
+		{
+			desc:           "expression-invalid",
+			conditionTypes: []string{ConditionTypeExpression + "status.phase < 'Running'"},
+			err: &ReadinessError{
+				error:   "invalid expression",
+				Reason:  "InvalidExpression",
+				Message: `invalid expression condition "status.phase < 'Running'": operator < requires numeric values`,
+			},
+
+			objs: []runtime.Object{
+				&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+			},
+		},
+		{
+			desc:           "expression-syntax",
+			conditionTypes: []string{ConditionTypeExpression + "status.phase == 'Running"},
+			err: &ReadinessError{
+				error:   "invalid expression",
+				Reason:  "InvalidExpression",
+				Message: `invalid expression condition "status.phase == 'Running": unterminated string literal`,
+			},
+
+			objs: []runtime.Object{
+				&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+			},
+		},
+		{
+			desc:           "expression-jsonpath",
+			conditionTypes: []string{ConditionTypeExpression + "{.status.phase"},
+			err: &ReadinessError{
+				error:   "invalid expression",
+				Reason:  "InvalidExpression",
+				Message: `invalid expression condition "{.status.phase": unbalanced brackets`,
+			},
+
+			objs: []runtime.Object{
+				&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+			},
+		},
+		{
+			desc:           "http-ok",
+			conditionTypes: []string{ConditionTypeHTTP + httpTestPort + "-healthz"},
+			ready:          true,
+
+			objs: []runtime.Object{
+				&corev1.Pod{
+					Status: corev1.PodStatus{
+						PodIP: httpTestHost,
+					},
+				},
+			},
+		},
+		{
+			desc:           "http-unavailable",
+			conditionTypes: []string{ConditionTypeHTTP + httpTestPort + "-readyz"},
+			ready:          false,
+			msg:            "GET http://" + httpTest.Listener.Addr().String() + "/readyz returned 503 Service Unavailable",
+
+			objs: []runtime.Object{
+				&corev1.Pod{
+					Status: corev1.PodStatus{
+						PodIP: httpTestHost,
+					},
+				},
+			},
+		},
+		{
+			desc:           "http-expected-status",
+			conditionTypes: []string{ConditionTypeHTTP + httpTestPort + "-readyz-503"},
+			ready:          true,
+
+			objs: []runtime.Object{
+				&corev1.Pod{
+					Status: corev1.PodStatus{
+						PodIP: httpTestHost,
+					},
+				},
+			},
+		},
+		{
+			desc:           "http-unexpected-status",
+			conditionTypes: []string{ConditionTypeHTTP + httpTestPort + "-healthz-204"},
+			ready:          false,
+			msg:            "GET http://" + httpTest.Listener.Addr().String() + "/healthz returned 200 OK, expected 204",
+
+			objs: []runtime.Object{
+				&corev1.Pod{
+					Status: corev1.PodStatus{
+						PodIP: httpTestHost,
+					},
+				},
+			},
+		},
+		{
+			desc:           "http-no-pods",
+			conditionTypes: []string{ConditionTypeHTTP + httpTestPort + "-healthz"},
+			ready:          false,
+			msg:            "No pods",
+
+			objs: []runtime.Object{
+				&appsv1.Deployment{
+					TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+					Spec: appsv1.DeploymentSpec{
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+					},
+				},
+			},
+		},
+		{
+			desc:           "http-no-pod-ip",
+			conditionTypes: []string{ConditionTypeHTTP + httpTestPort},
+			ready:          false,
+			msg:            "Pod has not been assigned an IP address",
+
+			objs: []runtime.Object{
+				&corev1.Pod{},
+			},
+		},
 	}
 
 	ctx := context.TODO()
@@ -217,7 +583,10 @@ func TestReadinessChecker_CheckConditions(t *testing.T) {
 				}
 				c.objs = c.objs[1:]
 			}
-			rc := &ReadinessChecker{Reader: fake.NewFakeClientWithScheme(scheme, c.objs...)}
+			rc := &ReadinessChecker{Reader: fake.NewFakeClientWithScheme(scheme, c.objs...), HTTPClient: httpTest.Client()}
+			if c.started > 0 {
+				rc.Started = time.Now().Add(-c.started)
+			}
 
 			// Verify the results
 			msg, ready, err := rc.CheckConditions(ctx, u, c.conditionTypes)
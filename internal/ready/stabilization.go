@@ -0,0 +1,120 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// DefaultStabilizationWindow is the amount of time metric values must be stable for when no window is specified
+	DefaultStabilizationWindow = 1 * time.Minute
+	// DefaultStabilizationTolerance is the relative tolerance used when no tolerance is specified
+	DefaultStabilizationTolerance = 0.05
+	// DefaultStabilizationTimeout is the amount of time to wait for metric values to stabilize when no timeout is specified
+	DefaultStabilizationTimeout = 10 * time.Minute
+)
+
+// StabilizationWindow returns the effective window for a metric stabilization
+func StabilizationWindow(s *optimizev1beta2.MetricStabilization) time.Duration {
+	if s.Window == nil || s.Window.Duration <= 0 {
+		return DefaultStabilizationWindow
+	}
+	return s.Window.Duration
+}
+
+// StabilizationTolerance returns the effective tolerance for a metric stabilization
+func StabilizationTolerance(s *optimizev1beta2.MetricStabilization) float64 {
+	if s.Tolerance == nil {
+		return DefaultStabilizationTolerance
+	}
+	return float64(s.Tolerance.ScaledValue(resource.Nano)) / 1000000000
+}
+
+// StabilizationTimeout returns the effective timeout for a metric stabilization
+func StabilizationTimeout(s *optimizev1beta2.MetricStabilization) time.Duration {
+	if s.Timeout == nil || s.Timeout.Duration <= 0 {
+		return DefaultStabilizationTimeout
+	}
+	return s.Timeout.Duration
+}
+
+// CheckStabilization inspects the observations of a readiness check to determine if the metric has stabilized. The
+// observations are trimmed so only the values from the current window (plus the one immediately preceding it) are
+// retained. The returned status is "Unknown" until a full window has been observed and while the metric is not stable;
+// if the metric is still not stable after the timeout (measured from the supplied start time) an error is returned.
+func CheckStabilization(c *optimizev1beta2.ReadinessCheck, started, now time.Time) (string, corev1.ConditionStatus, error) {
+	if c.Stabilization == nil {
+		return "", corev1.ConditionTrue, nil
+	}
+
+	// Discard everything before the last observation at or preceding the start of the window
+	cutoff := now.Add(-StabilizationWindow(c.Stabilization))
+	start := 0
+	for i := range c.Observations {
+		if !c.Observations[i].Time.Time.After(cutoff) {
+			start = i
+		}
+	}
+	c.Observations = c.Observations[start:]
+
+	// Compute the range of the observed values
+	min, max, sum := math.Inf(1), math.Inf(-1), 0.0
+	for i := range c.Observations {
+		v, err := strconv.ParseFloat(c.Observations[i].Value, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid observation for %s: %s", c.Stabilization.Metric.Name, c.Observations[i].Value), corev1.ConditionFalse, nil
+		}
+		min, max, sum = math.Min(min, v), math.Max(max, v), sum+v
+	}
+
+	// We need to observe an entire window before making a determination
+	if len(c.Observations) == 0 || c.Observations[0].Time.Time.After(cutoff) {
+		return fmt.Sprintf("Observing %s", c.Stabilization.Metric.Name), corev1.ConditionUnknown, nil
+	}
+
+	// Compare the spread of values against the tolerance, relative to the average when possible
+	spread := max - min
+	if avg := sum / float64(len(c.Observations)); avg != 0 {
+		spread = spread / math.Abs(avg)
+	}
+	if spread > StabilizationTolerance(c.Stabilization) {
+		msg := fmt.Sprintf("Metric %s is not stable (%s to %s)",
+			c.Stabilization.Metric.Name,
+			strconv.FormatFloat(min, 'f', -1, 64),
+			strconv.FormatFloat(max, 'f', -1, 64))
+
+		// Keep waiting for the metric to settle until the deadline
+		if timeout := StabilizationTimeout(c.Stabilization); now.After(started.Add(timeout)) {
+			return msg, corev1.ConditionFalse, &ReadinessError{
+				error:   "metric unstable",
+				Reason:  "StabilizationDeadlineExceeded",
+				Message: fmt.Sprintf("%s after %s", msg, timeout),
+			}
+		}
+		return msg, corev1.ConditionUnknown, nil
+	}
+
+	return "", corev1.ConditionTrue, nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckStabilization(t *testing.T) {
+	now := time.Now()
+	obs := func(ago time.Duration, value string) optimizev1beta2.ReadinessObservation {
+		return optimizev1beta2.ReadinessObservation{Time: metav1.NewTime(now.Add(-ago)), Value: value}
+	}
+	tolerance := resource.MustParse("0.1")
+
+	cases := []struct {
+		desc          string
+		stabilization optimizev1beta2.MetricStabilization
+		observations  []optimizev1beta2.ReadinessObservation
+		started       time.Duration
+		expectedObs   int
+		msg           string
+		status        corev1.ConditionStatus
+		err           string
+	}{
+		{
+			desc:         "partial-window",
+			observations: []optimizev1beta2.ReadinessObservation{obs(30*time.Second, "10"), obs(0, "10")},
+			expectedObs:  2,
+			msg:          "Observing test",
+			status:       corev1.ConditionUnknown,
+		},
+		{
+			desc:         "stable",
+			observations: []optimizev1beta2.ReadinessObservation{obs(90*time.Second, "1"), obs(60*time.Second, "10"), obs(30*time.Second, "10.2"), obs(0, "10.1")},
+			expectedObs:  3,
+			status:       corev1.ConditionTrue,
+		},
+		{
+			desc:         "unstable",
+			observations: []optimizev1beta2.ReadinessObservation{obs(60*time.Second, "8"), obs(30*time.Second, "10"), obs(0, "12")},
+			expectedObs:  3,
+			msg:          "Metric test is not stable (8 to 12)",
+			status:       corev1.ConditionUnknown,
+		},
+		{
+			desc:         "unstable-deadline",
+			started:      11 * time.Minute,
+			observations: []optimizev1beta2.ReadinessObservation{obs(60*time.Second, "8"), obs(30*time.Second, "10"), obs(0, "12")},
+			expectedObs:  3,
+			msg:          "Metric test is not stable (8 to 12)",
+			status:       corev1.ConditionFalse,
+			err:          "metric unstable",
+		},
+		{
+			desc:          "custom-timeout",
+			stabilization: optimizev1beta2.MetricStabilization{Timeout: &metav1.Duration{Duration: 5 * time.Minute}},
+			started:       6 * time.Minute,
+			observations:  []optimizev1beta2.ReadinessObservation{obs(60*time.Second, "8"), obs(0, "12")},
+			expectedObs:   2,
+			msg:           "Metric test is not stable (8 to 12)",
+			status:        corev1.ConditionFalse,
+			err:           "metric unstable",
+		},
+		{
+			desc:          "custom-tolerance",
+			stabilization: optimizev1beta2.MetricStabilization{Tolerance: &tolerance},
+			observations:  []optimizev1beta2.ReadinessObservation{obs(60*time.Second, "9.5"), obs(0, "10.5")},
+			expectedObs:   2,
+			status:        corev1.ConditionTrue,
+		},
+		{
+			desc:          "custom-window",
+			stabilization: optimizev1beta2.MetricStabilization{Window: &metav1.Duration{Duration: 2 * time.Minute}},
+			observations:  []optimizev1beta2.ReadinessObservation{obs(60*time.Second, "10"), obs(0, "10")},
+			expectedObs:   2,
+			msg:           "Observing test",
+			status:        corev1.ConditionUnknown,
+		},
+		{
+			desc:         "zero-average",
+			observations: []optimizev1beta2.ReadinessObservation{obs(60*time.Second, "-0.01"), obs(0, "0.01")},
+			expectedObs:  2,
+			status:       corev1.ConditionTrue,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rc := &optimizev1beta2.ReadinessCheck{
+				Stabilization: &c.stabilization,
+				Observations:  c.observations,
+			}
+			rc.Stabilization.Metric.Name = "test"
+
+			msg, status, err := CheckStabilization(rc, now.Add(-c.started), now)
+			assert.Equal(t, c.msg, msg)
+			assert.Equal(t, c.status, status)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, rc.Observations, c.expectedObs)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/ready"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+)
+
+// ApprovedBy returns the name of the approver of the recommendation. Approval is granted using either the approved
+// by annotation or by setting the approved condition to "True", in which case the condition message is the approver.
+// The approver is free text unless the recommendation admission webhook is enabled to record the requesting user.
+func ApprovedBy(rec *optimizev1beta2.Recommendation) (string, bool) {
+	if approver, ok := rec.Annotations[optimizev1beta2.AnnotationApprovedBy]; ok {
+		return approver, true
+	}
+	for i := range rec.Status.Conditions {
+		c := &rec.Status.Conditions[i]
+		if c.Type == optimizev1beta2.RecommendationApproved && c.Status == corev1.ConditionTrue {
+			return c.Message, true
+		}
+	}
+	return "", false
+}
+
+// NextChange returns the next recommended patch to record during promotion, or nil if all of the patches have been
+// recorded. The returned change does not include the original state of the object.
+func NextChange(rec *optimizev1beta2.Recommendation) *optimizev1beta2.PromotedChange {
+	var applied int
+	if rec.Status.Promotion != nil {
+		applied = len(rec.Status.Promotion.Changes)
+	}
+	if applied >= len(rec.Spec.Patches) {
+		return nil
+	}
+
+	p := &rec.Spec.Patches[applied]
+	pc := &optimizev1beta2.PromotedChange{
+		TargetRef: p.TargetRef,
+		PatchType: p.PatchType,
+		Data:      p.Data,
+	}
+	if rec.Spec.Promotion != nil && rec.Spec.Promotion.TargetNamespace != "" {
+		pc.TargetRef.Namespace = rec.Spec.Promotion.TargetNamespace
+	}
+	return pc
+}
+
+// CheckTarget verifies the promotion is allowed to modify the supplied target. Promoting to the cluster the controller
+// is running in is limited to the namespace of the recommendation (whether or not the target namespace is overridden),
+// otherwise anyone who can edit the recommendation could use the privileges of the controller in any namespace.
+// Promoting to other namespaces requires a kubeconfig, in which case the privileges of the supplied credentials apply.
+func CheckTarget(rec *optimizev1beta2.Recommendation, ref *corev1.ObjectReference) error {
+	if rec.Spec.Promotion != nil && rec.Spec.Promotion.KubeConfig != nil {
+		return nil
+	}
+	if ref.Namespace != rec.Namespace {
+		return fmt.Errorf("target namespace %q must be the recommendation namespace when promoting without a kubeconfig", ref.Namespace)
+	}
+	return nil
+}
+
+// PendingChange returns the most recently recorded change if it has not been applied yet.
+func PendingChange(rec *optimizev1beta2.Recommendation) *optimizev1beta2.PromotedChange {
+	if rec.Status.Promotion == nil || len(rec.Status.Promotion.Changes) == 0 {
+		return nil
+	}
+
+	pc := &rec.Status.Promotion.Changes[len(rec.Status.Promotion.Changes)-1]
+	if pc.Applied {
+		return nil
+	}
+	return pc
+}
+
+// NewReadinessCheck returns the readiness check for an object patched during promotion, or nil if there are no
+// conditions to check. The check settings are consistent with the readiness checks created for trial patches.
+func NewReadinessCheck(rec *optimizev1beta2.Recommendation, ref *corev1.ObjectReference) *optimizev1beta2.ReadinessCheck {
+	rc := &optimizev1beta2.ReadinessCheck{
+		TargetRef:         *ref,
+		PeriodSeconds:     5,
+		AttemptsRemaining: 36,
+	}
+
+	var readinessGates []optimizev1beta2.PatchReadinessGate
+	if rec.Spec.Promotion != nil {
+		readinessGates = rec.Spec.Promotion.ReadinessGates
+	}
+	for i := range readinessGates {
+		rc.ConditionTypes = append(rc.ConditionTypes, readinessGates[i].ConditionType)
+	}
+
+	// Only an explicitly empty list of readiness gates disables the default check
+	if readinessGates == nil {
+		rc.ConditionTypes = append(rc.ConditionTypes, ready.ConditionTypeAppReady)
+		rc.InitialDelaySeconds = 1
+	}
+
+	if len(rc.ConditionTypes) == 0 {
+		return nil
+	}
+	return rc
+}
+
+// OriginalContent returns the state of the object which can be restored during a rollback.
+func OriginalContent(u *unstructured.Unstructured) (string, error) {
+	o, err := rollbackContent(u)
+	if err != nil {
+		return "", err
+	}
+	return string(o), nil
+}
+
+// RollbackPatch returns a JSON merge patch that restores the modified object to the original state recorded on the
+// supplied change. Only the labels and annotations of the object metadata are considered, the status is ignored.
+func RollbackPatch(pc *optimizev1beta2.PromotedChange, modified *unstructured.Unstructured) ([]byte, error) {
+	original := &unstructured.Unstructured{}
+	if err := json.Unmarshal([]byte(pc.Original), &original.Object); err != nil {
+		return nil, err
+	}
+
+	o, err := rollbackContent(original)
+	if err != nil {
+		return nil, err
+	}
+	m, err := rollbackContent(modified)
+	if err != nil {
+		return nil, err
+	}
+
+	// The "original" of the three-way patch is the modified state since that is where deletions are computed from
+	return jsonmergepatch.CreateThreeWayJSONMergePatch(m, o, m)
+}
+
+// rollbackContent returns the JSON representation of the object which can be restored during a rollback.
+func rollbackContent(u *unstructured.Unstructured) ([]byte, error) {
+	content := make(map[string]interface{}, len(u.Object))
+	for k, v := range u.Object {
+		switch k {
+		case "metadata":
+			md := make(map[string]interface{}, 2)
+			if labels := u.GetLabels(); labels != nil {
+				md["labels"] = labels
+			}
+			if annotations := u.GetAnnotations(); annotations != nil {
+				md["annotations"] = annotations
+			}
+			content[k] = md
+		case "status":
+		default:
+			content[k] = v
+		}
+	}
+	return json.Marshal(content)
+}
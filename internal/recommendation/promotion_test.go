@@ -0,0 +1,288 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/ready"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApprovedBy(t *testing.T) {
+	cases := []struct {
+		desc       string
+		rec        optimizev1beta2.Recommendation
+		approvedBy string
+		approved   bool
+	}{
+		{
+			desc: "not approved",
+		},
+		{
+			desc: "annotation",
+			rec: optimizev1beta2.Recommendation{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{optimizev1beta2.AnnotationApprovedBy: "alice"}},
+			},
+			approvedBy: "alice",
+			approved:   true,
+		},
+		{
+			desc: "condition",
+			rec: optimizev1beta2.Recommendation{
+				Status: optimizev1beta2.RecommendationStatus{
+					Conditions: []optimizev1beta2.RecommendationCondition{
+						{Type: optimizev1beta2.RecommendationApproved, Status: corev1.ConditionTrue, Message: "bob"},
+					},
+				},
+			},
+			approvedBy: "bob",
+			approved:   true,
+		},
+		{
+			desc: "condition false",
+			rec: optimizev1beta2.Recommendation{
+				Status: optimizev1beta2.RecommendationStatus{
+					Conditions: []optimizev1beta2.RecommendationCondition{
+						{Type: optimizev1beta2.RecommendationApproved, Status: corev1.ConditionFalse, Message: "bob"},
+					},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			approvedBy, approved := ApprovedBy(&c.rec)
+			assert.Equal(t, c.approvedBy, approvedBy)
+			assert.Equal(t, c.approved, approved)
+		})
+	}
+}
+
+func TestNextChange(t *testing.T) {
+	rec := &optimizev1beta2.Recommendation{
+		Spec: optimizev1beta2.RecommendationSpec{
+			Patches: []optimizev1beta2.RecommendedPatch{
+				{
+					TargetRef: corev1.ObjectReference{Kind: "Deployment", APIVersion: "apps/v1", Name: "app", Namespace: "default"},
+					PatchType: types.StrategicMergePatchType,
+					Data:      `{"spec":{"replicas":2}}`,
+				},
+				{
+					TargetRef: corev1.ObjectReference{Kind: "ConfigMap", APIVersion: "v1", Name: "app", Namespace: "default"},
+					PatchType: types.MergePatchType,
+					Data:      `{"data":{"cpu":"500"}}`,
+				},
+			},
+			Promotion: &optimizev1beta2.RecommendationPromotion{TargetNamespace: "production"},
+		},
+	}
+
+	pc := NextChange(rec)
+	if assert.NotNil(t, pc) {
+		assert.Equal(t, "Deployment", pc.TargetRef.Kind)
+		assert.Equal(t, "production", pc.TargetRef.Namespace)
+		assert.Equal(t, "default", rec.Spec.Patches[0].TargetRef.Namespace)
+	}
+
+	rec.Status.Promotion = &optimizev1beta2.PromotionStatus{Changes: []optimizev1beta2.PromotedChange{*pc}}
+	if pending := PendingChange(rec); assert.NotNil(t, pending) {
+		assert.Equal(t, "Deployment", pending.TargetRef.Kind)
+		pending.Applied = true
+	}
+	assert.Nil(t, PendingChange(rec))
+
+	pc = NextChange(rec)
+	if assert.NotNil(t, pc) {
+		assert.Equal(t, "ConfigMap", pc.TargetRef.Kind)
+		assert.Equal(t, types.MergePatchType, pc.PatchType)
+	}
+
+	rec.Status.Promotion.Changes = append(rec.Status.Promotion.Changes, *pc)
+	assert.Nil(t, NextChange(rec))
+}
+
+func TestCheckTarget(t *testing.T) {
+	cases := []struct {
+		desc      string
+		namespace string
+		target    string
+		promotion *optimizev1beta2.RecommendationPromotion
+		expectErr bool
+	}{
+		{
+			desc:      "no promotion",
+			namespace: "default",
+			target:    "default",
+		},
+		{
+			desc:      "no promotion other namespace",
+			namespace: "default",
+			target:    "production",
+			expectErr: true,
+		},
+		{
+			desc:      "original namespace",
+			namespace: "default",
+			target:    "default",
+			promotion: &optimizev1beta2.RecommendationPromotion{},
+		},
+		{
+			desc:      "patch target other namespace",
+			namespace: "default",
+			target:    "kube-system",
+			promotion: &optimizev1beta2.RecommendationPromotion{},
+			expectErr: true,
+		},
+		{
+			desc:      "patch target without namespace",
+			namespace: "default",
+			promotion: &optimizev1beta2.RecommendationPromotion{},
+			expectErr: true,
+		},
+		{
+			desc:      "recommendation namespace",
+			namespace: "production",
+			target:    "production",
+			promotion: &optimizev1beta2.RecommendationPromotion{TargetNamespace: "production"},
+		},
+		{
+			desc:      "other namespace",
+			namespace: "default",
+			target:    "production",
+			promotion: &optimizev1beta2.RecommendationPromotion{TargetNamespace: "production"},
+			expectErr: true,
+		},
+		{
+			desc:      "other namespace kubeconfig",
+			namespace: "default",
+			target:    "production",
+			promotion: &optimizev1beta2.RecommendationPromotion{
+				TargetNamespace: "production",
+				KubeConfig:      &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "prod"}, Key: "kubeconfig"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rec := &optimizev1beta2.Recommendation{}
+			rec.Namespace = c.namespace
+			rec.Spec.Promotion = c.promotion
+			ref := &corev1.ObjectReference{Kind: "Deployment", APIVersion: "apps/v1", Name: "app", Namespace: c.target}
+			err := CheckTarget(rec, ref)
+			if c.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewReadinessCheck(t *testing.T) {
+	ref := &corev1.ObjectReference{Kind: "Deployment", APIVersion: "apps/v1", Name: "app", Namespace: "production"}
+
+	cases := []struct {
+		desc           string
+		promotion      *optimizev1beta2.RecommendationPromotion
+		conditionTypes []string
+	}{
+		{
+			desc:           "default",
+			conditionTypes: []string{ready.ConditionTypeAppReady},
+		},
+		{
+			desc: "explicit",
+			promotion: &optimizev1beta2.RecommendationPromotion{
+				ReadinessGates: []optimizev1beta2.PatchReadinessGate{{ConditionType: ready.ConditionTypePodReady}},
+			},
+			conditionTypes: []string{ready.ConditionTypePodReady},
+		},
+		{
+			desc: "disabled",
+			promotion: &optimizev1beta2.RecommendationPromotion{
+				ReadinessGates: []optimizev1beta2.PatchReadinessGate{},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rec := &optimizev1beta2.Recommendation{Spec: optimizev1beta2.RecommendationSpec{Promotion: c.promotion}}
+			rc := NewReadinessCheck(rec, ref)
+			if c.conditionTypes == nil {
+				assert.Nil(t, rc)
+			} else if assert.NotNil(t, rc) {
+				assert.Equal(t, *ref, rc.TargetRef)
+				assert.Equal(t, c.conditionTypes, rc.ConditionTypes)
+				assert.Equal(t, int32(36), rc.AttemptsRemaining)
+			}
+		})
+	}
+}
+
+func TestRollbackPatch(t *testing.T) {
+	original := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":            "app",
+			"resourceVersion": "1",
+			"labels":          map[string]interface{}{"app": "test"},
+		},
+		"data": map[string]interface{}{
+			"cpu":    "1000",
+			"memory": "1024",
+		},
+	}}
+
+	modified := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":            "app",
+			"resourceVersion": "2",
+			"labels":          map[string]interface{}{"app": "test", "tier": "production"},
+		},
+		"data": map[string]interface{}{
+			"cpu":    "500",
+			"memory": "1024",
+			"extra":  "true",
+		},
+	}}
+
+	pc := &optimizev1beta2.PromotedChange{}
+	var err error
+	pc.Original, err = OriginalContent(original)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, pc.Original, "resourceVersion")
+	}
+
+	rollback, err := RollbackPatch(pc, modified)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"metadata":{"labels":{"tier":null}},"data":{"cpu":"1000","extra":null}}`, string(rollback))
+	}
+
+	unchanged, err := RollbackPatch(pc, original)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{}`, string(unchanged))
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/patch"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NewRecommendation returns a new recommendation for the supplied experiment.
+func NewRecommendation(exp *optimizev1beta2.Experiment) *optimizev1beta2.Recommendation {
+	rec := &optimizev1beta2.Recommendation{}
+	rec.Name = exp.Name
+	rec.Namespace = exp.Namespace
+	rec.Labels = map[string]string{optimizev1beta2.LabelExperiment: exp.Name}
+	rec.Spec.ExperimentRef = &corev1.ObjectReference{Name: exp.Name, Namespace: exp.Namespace}
+	return rec
+}
+
+// IsPopulated checks to see if the recommendation has already been produced from the experiment.
+func IsPopulated(rec *optimizev1beta2.Recommendation) bool {
+	return CheckCondition(&rec.Status, optimizev1beta2.RecommendationReady, corev1.ConditionTrue)
+}
+
+// SelectTrial returns the recommended trial. If the recommendation names a trial, only that trial is considered,
+// otherwise the best completed trial is selected. Trials are compared using the optimized metrics in the order they
+// appear on the experiment: the first metric decides unless the values are equal, in which case the next metric is
+// used. This is a single point on the Pareto front of a multi-objective experiment, name a trial explicitly to
+// recommend a different trade-off.
+func SelectTrial(rec *optimizev1beta2.Recommendation, exp *optimizev1beta2.Experiment, trials []optimizev1beta2.Trial) (*optimizev1beta2.Trial, error) {
+	var best *optimizev1beta2.Trial
+	for i := range trials {
+		t := &trials[i]
+		if rec.Spec.TrialName != "" && t.Name != rec.Spec.TrialName {
+			continue
+		}
+
+		if !isObserved(t, exp) {
+			if rec.Spec.TrialName != "" {
+				return nil, fmt.Errorf("trial %q has not completed successfully", t.Name)
+			}
+			continue
+		}
+
+		if best == nil || isBetter(exp, t, best) {
+			best = t
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+	if rec.Spec.TrialName != "" {
+		return nil, fmt.Errorf("trial %q does not exist", rec.Spec.TrialName)
+	}
+	return nil, fmt.Errorf("experiment %q does not have any completed trials", exp.Name)
+}
+
+// MergeTrials combines the trials in the cluster with the trials reported to the server. Finished trials are
+// eventually deleted from the cluster while the server retains every observation, so server trials are used to fill
+// in the trials which are no longer available; cluster trials take precedence when both are present.
+func MergeTrials(exp *optimizev1beta2.Experiment, clusterTrials, serverTrials []optimizev1beta2.Trial) []optimizev1beta2.Trial {
+	if len(serverTrials) == 0 {
+		return clusterTrials
+	}
+
+	reported := make(map[string]bool, len(clusterTrials))
+	for i := range clusterTrials {
+		if u := clusterTrials[i].GetAnnotations()[optimizev1beta2.AnnotationReportTrialURL]; u != "" {
+			reported[u] = true
+		}
+	}
+
+	trials := append([]optimizev1beta2.Trial(nil), clusterTrials...)
+	for i := range serverTrials {
+		t := serverTrials[i].DeepCopy()
+		if reported[t.GetAnnotations()[optimizev1beta2.AnnotationReportTrialURL]] {
+			continue
+		}
+
+		// Use the same names the cluster trials would have had
+		t.Namespace = exp.Namespace
+		if num, err := strconv.ParseInt(t.Name, 10, 64); err == nil {
+			t.Name = fmt.Sprintf("%s-%03d", exp.Name, num)
+		}
+		trials = append(trials, *t)
+	}
+	return trials
+}
+
+// FindBaseline returns the completed baseline trial of the experiment, or nil if there is none.
+func FindBaseline(exp *optimizev1beta2.Experiment, trials []optimizev1beta2.Trial) *optimizev1beta2.Trial {
+	for i := range trials {
+		if trial.IsBaseline(&trials[i], exp) && isObserved(&trials[i], exp) {
+			return &trials[i]
+		}
+	}
+	return nil
+}
+
+// Populate fills in the recommendation using the assignments and values of the supplied trial. The patches of
+// the experiment are rendered for the recommended trial, patches targeting the trial job itself are omitted.
+func Populate(rec *optimizev1beta2.Recommendation, exp *optimizev1beta2.Experiment, t, baseline *optimizev1beta2.Trial) error {
+	var patches []optimizev1beta2.RecommendedPatch
+	te := template.New()
+	for i := range exp.Spec.Patches {
+		p := &exp.Spec.Patches[i]
+		ref, data, err := patch.RenderTemplate(te, t, p)
+		if err != nil {
+			return err
+		}
+
+		po, err := patch.CreatePatchOperation(t, p, ref, data)
+		if err != nil {
+			return err
+		}
+		if po == nil || trial.IsTrialJobReference(t, &po.TargetRef) {
+			continue
+		}
+
+		patches = append(patches, optimizev1beta2.RecommendedPatch{
+			TargetRef: po.TargetRef,
+			PatchType: po.PatchType,
+			Data:      string(po.Data),
+		})
+	}
+
+	var values []optimizev1beta2.RecommendedValue
+	for i := range exp.Spec.Metrics {
+		m := &exp.Spec.Metrics[i]
+		v, ok := value(t, m.Name)
+		if !ok {
+			continue
+		}
+
+		rv := optimizev1beta2.RecommendedValue{Name: m.Name, Value: v.Value}
+		if bv, ok := value(baseline, m.Name); ok {
+			rv.Baseline = bv.Value
+			rv.Improvement = improvement(m, v.Value, bv.Value)
+		}
+		values = append(values, rv)
+	}
+
+	rec.Spec.TrialName = t.Name
+	rec.Spec.Assignments = append([]optimizev1beta2.Assignment(nil), t.Spec.Assignments...)
+	rec.Spec.Patches = patches
+	rec.Spec.Values = values
+	return nil
+}
+
+// isObserved checks to see if the trial completed successfully with values for all of the optimized metrics.
+func isObserved(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment) bool {
+	if !trial.CheckCondition(&t.Status, optimizev1beta2.TrialComplete, corev1.ConditionTrue) {
+		return false
+	}
+	for i := range exp.Spec.Metrics {
+		if !isOptimized(&exp.Spec.Metrics[i]) {
+			continue
+		}
+		if _, ok := floatValue(t, exp.Spec.Metrics[i].Name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isBetter checks to see if trial "a" is better then trial "b".
+func isBetter(exp *optimizev1beta2.Experiment, a, b *optimizev1beta2.Trial) bool {
+	for i := range exp.Spec.Metrics {
+		m := &exp.Spec.Metrics[i]
+		if !isOptimized(m) {
+			continue
+		}
+
+		av, _ := floatValue(a, m.Name)
+		bv, _ := floatValue(b, m.Name)
+		if av == bv {
+			continue
+		}
+		if m.Minimize {
+			return av < bv
+		}
+		return av > bv
+	}
+	return false
+}
+
+// isOptimized checks to see if the metric is an optimization objective (as opposed to only being reported).
+func isOptimized(m *optimizev1beta2.Metric) bool {
+	return m.Optimize == nil || *m.Optimize
+}
+
+// improvement returns the relative improvement of a value over the baseline value.
+func improvement(m *optimizev1beta2.Metric, v, b string) string {
+	vf, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return ""
+	}
+	bf, err := strconv.ParseFloat(b, 64)
+	if err != nil || bf == 0 {
+		return ""
+	}
+
+	imp := (vf - bf) / math.Abs(bf)
+	if m.Minimize {
+		imp = -imp
+	}
+	return strconv.FormatFloat(imp, 'f', 4, 64)
+}
+
+func value(t *optimizev1beta2.Trial, name string) (*optimizev1beta2.Value, bool) {
+	if t == nil {
+		return nil, false
+	}
+	for i := range t.Spec.Values {
+		if t.Spec.Values[i].Name == name && t.Spec.Values[i].AttemptsRemaining == 0 {
+			return &t.Spec.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+func floatValue(t *optimizev1beta2.Trial, name string) (float64, bool) {
+	v, ok := value(t, name)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSelectTrial(t *testing.T) {
+	notOptimized := false
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: optimizev1beta2.ExperimentSpec{
+			Metrics: []optimizev1beta2.Metric{
+				{Name: "cost", Minimize: true},
+				{Name: "throughput"},
+				{Name: "reported", Optimize: &notOptimized},
+			},
+		},
+	}
+
+	trials := []optimizev1beta2.Trial{
+		completeTrial("one", "cost=10", "throughput=100"),
+		completeTrial("two", "cost=5", "throughput=50"),
+		completeTrial("three", "cost=5", "throughput=80"),
+		completeTrial("four", "cost=1"),
+		{ObjectMeta: metav1.ObjectMeta{Name: "five"}},
+	}
+
+	cases := []struct {
+		desc      string
+		trialName string
+		expected  string
+		err       string
+	}{
+		{
+			desc:     "best",
+			expected: "three",
+		},
+		{
+			desc:      "named",
+			trialName: "one",
+			expected:  "one",
+		},
+		{
+			desc:      "named missing values",
+			trialName: "four",
+			err:       `trial "four" has not completed successfully`,
+		},
+		{
+			desc:      "named unfinished",
+			trialName: "five",
+			err:       `trial "five" has not completed successfully`,
+		},
+		{
+			desc:      "named missing",
+			trialName: "six",
+			err:       `trial "six" does not exist`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rec := &optimizev1beta2.Recommendation{Spec: optimizev1beta2.RecommendationSpec{TrialName: c.trialName}}
+			actual, err := SelectTrial(rec, exp, trials)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, actual.Name)
+			}
+		})
+	}
+
+	_, err := SelectTrial(&optimizev1beta2.Recommendation{}, exp, trials[3:])
+	assert.EqualError(t, err, `experiment "test" does not have any completed trials`)
+}
+
+func TestMergeTrials(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+
+	reported := completeTrial("test-002", "cost=5")
+	reported.Namespace = "default"
+	reported.Annotations = map[string]string{optimizev1beta2.AnnotationReportTrialURL: "http://example.com/experiments/test/trials/2"}
+	clusterTrials := []optimizev1beta2.Trial{reported}
+
+	deleted := completeTrial("1", "cost=10")
+	deleted.Annotations = map[string]string{optimizev1beta2.AnnotationReportTrialURL: "http://example.com/experiments/test/trials/1"}
+	duplicate := completeTrial("2", "cost=1")
+	duplicate.Annotations = map[string]string{optimizev1beta2.AnnotationReportTrialURL: "http://example.com/experiments/test/trials/2"}
+	serverTrials := []optimizev1beta2.Trial{deleted, duplicate}
+
+	trials := MergeTrials(exp, clusterTrials, serverTrials)
+	if assert.Len(t, trials, 2) {
+		assert.Equal(t, "test-002", trials[0].Name)
+		assert.Equal(t, "5", trials[0].Spec.Values[0].Value)
+		assert.Equal(t, "test-001", trials[1].Name)
+		assert.Equal(t, "default", trials[1].Namespace)
+	}
+
+	assert.Equal(t, clusterTrials, MergeTrials(exp, clusterTrials, nil))
+}
+
+func TestPopulate(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.ExperimentSpec{
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "cpu", Min: 100, Max: 2000, Baseline: &intstr.IntOrString{IntVal: 1000}},
+			},
+			Metrics: []optimizev1beta2.Metric{
+				{Name: "cost", Minimize: true},
+				{Name: "throughput"},
+				{Name: "errors", Minimize: true},
+			},
+			Patches: []optimizev1beta2.PatchTemplate{
+				{
+					TargetRef: &corev1.ObjectReference{Kind: "Deployment", APIVersion: "apps/v1", Name: "app"},
+					Patch:     `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"{{ .Values.cpu }}m"}}}]}}}}`,
+				},
+				{
+					Type:      optimizev1beta2.PatchMerge,
+					TargetRef: &corev1.ObjectReference{Kind: "ConfigMap", APIVersion: "v1", Name: "app", Namespace: "app"},
+					Patch:     `{"data":{"cpu":"{{ .Values.cpu }}"}}`,
+				},
+				{
+					TargetRef: &corev1.ObjectReference{Kind: "Job", APIVersion: "batch/v1"},
+					Patch:     `{"spec":{"parallelism":1}}`,
+				},
+			},
+		},
+	}
+
+	best := completeTrial("test-002", "cost=5", "throughput=150", "errors=0")
+	best.Namespace = "default"
+	best.Spec.Assignments = []optimizev1beta2.Assignment{{Name: "cpu", Value: intstr.FromInt(500)}}
+
+	baseline := completeTrial("test-001", "cost=10", "throughput=100", "errors=0")
+	baseline.Namespace = "default"
+	baseline.Labels = map[string]string{"baseline": "true"}
+	baseline.Spec.Assignments = []optimizev1beta2.Assignment{{Name: "cpu", Value: intstr.FromInt(1000)}}
+
+	assert.Equal(t, &baseline, FindBaseline(exp, []optimizev1beta2.Trial{best, baseline}))
+
+	rec := NewRecommendation(exp)
+	if assert.NoError(t, Populate(rec, exp, &best, &baseline)) {
+		assert.Equal(t, types.NamespacedName{Namespace: "default", Name: "test"}, rec.ExperimentNamespacedName())
+		assert.Equal(t, "test-002", rec.Spec.TrialName)
+		assert.Equal(t, best.Spec.Assignments, rec.Spec.Assignments)
+		assert.Equal(t, []optimizev1beta2.RecommendedPatch{
+			{
+				TargetRef: corev1.ObjectReference{Kind: "Deployment", APIVersion: "apps/v1", Name: "app", Namespace: "default"},
+				PatchType: types.StrategicMergePatchType,
+				Data:      `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"500m"}}}]}}}}`,
+			},
+			{
+				TargetRef: corev1.ObjectReference{Kind: "ConfigMap", APIVersion: "v1", Name: "app", Namespace: "app"},
+				PatchType: types.MergePatchType,
+				Data:      `{"data":{"cpu":"500"}}`,
+			},
+		}, rec.Spec.Patches)
+		assert.Equal(t, []optimizev1beta2.RecommendedValue{
+			{Name: "cost", Value: "5", Baseline: "10", Improvement: "0.5000"},
+			{Name: "throughput", Value: "150", Baseline: "100", Improvement: "0.5000"},
+			{Name: "errors", Value: "0", Baseline: "0"},
+		}, rec.Spec.Values)
+	}
+}
+
+func completeTrial(name string, values ...string) optimizev1beta2.Trial {
+	t := optimizev1beta2.Trial{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for _, v := range values {
+		nv := strings.SplitN(v, "=", 2)
+		t.Spec.Values = append(t.Spec.Values, optimizev1beta2.Value{Name: nv[0], Value: nv[1]})
+	}
+	t.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+	return t
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PhasePending indicates that the recommendation has not been produced yet
+	PhasePending = "Pending"
+	// PhaseReady indicates that the recommendation has been produced and is available for review
+	PhaseReady = "Ready"
+	// PhaseFailed indicates that the recommendation could not be produced
+	PhaseFailed = "Failed"
+	// PhasePromoting indicates that the recommendation was approved and is being applied to the promotion target
+	PhasePromoting = "Promoting"
+	// PhasePromoted indicates that the recommendation was applied to the promotion target and is ready
+	PhasePromoted = "Promoted"
+	// PhaseRolledBack indicates that the promotion failed and the changes were reverted
+	PhaseRolledBack = "Rolled Back"
+)
+
+// UpdateStatus will ensure the recommendation's phase matches its conditions; returns true only if changes were necessary
+func UpdateStatus(rec *optimizev1beta2.Recommendation) bool {
+	phase := summarize(rec)
+	if rec.Status.Phase != phase {
+		rec.Status.Phase = phase
+		return true
+	}
+	return false
+}
+
+func summarize(rec *optimizev1beta2.Recommendation) string {
+	switch {
+	case CheckCondition(&rec.Status, optimizev1beta2.RecommendationRolledBack, corev1.ConditionTrue):
+		return PhaseRolledBack
+	case CheckCondition(&rec.Status, optimizev1beta2.RecommendationPromoted, corev1.ConditionTrue):
+		return PhasePromoted
+	case CheckCondition(&rec.Status, optimizev1beta2.RecommendationApproved, corev1.ConditionTrue):
+		return PhasePromoting
+	case CheckCondition(&rec.Status, optimizev1beta2.RecommendationReady, corev1.ConditionTrue):
+		return PhaseReady
+	case CheckCondition(&rec.Status, optimizev1beta2.RecommendationReady, corev1.ConditionFalse):
+		return PhaseFailed
+	default:
+		return PhasePending
+	}
+}
+
+// ApplyCondition updates a the status of an existing condition or adds it if it does not exist
+func ApplyCondition(status *optimizev1beta2.RecommendationStatus, conditionType optimizev1beta2.RecommendationConditionType, conditionStatus corev1.ConditionStatus, reason, message string, time *metav1.Time) {
+	// Make sure we have a time
+	if time == nil {
+		now := metav1.Now()
+		time = &now
+	}
+
+	// Update an existing condition
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			if status.Conditions[i].Status != conditionStatus {
+				// Status change, record the transition
+				status.Conditions[i].Status = conditionStatus
+				status.Conditions[i].Reason = reason
+				status.Conditions[i].Message = message
+				status.Conditions[i].LastTransitionTime = *time
+			} else {
+				// Status hasn't changed, update the probe time and reason/message (if necessary)
+				status.Conditions[i].LastProbeTime = *time
+				if status.Conditions[i].Reason != reason {
+					status.Conditions[i].Reason = reason
+					status.Conditions[i].Message = message
+				}
+			}
+			return
+		}
+	}
+
+	// Condition does not exist
+	status.Conditions = append(status.Conditions, optimizev1beta2.RecommendationCondition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		LastProbeTime:      *time,
+		LastTransitionTime: *time,
+	})
+}
+
+// CheckCondition checks to see if a condition has a specific status
+func CheckCondition(status *optimizev1beta2.RecommendationStatus, conditionType optimizev1beta2.RecommendationConditionType, conditionStatus corev1.ConditionStatus) bool {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return status.Conditions[i].Status == conditionStatus
+		}
+	}
+
+	// If the condition we are looking for *is* unknown, then we did "find" it
+	return conditionStatus == corev1.ConditionUnknown
+}
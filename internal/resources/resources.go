@@ -0,0 +1,33 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resources contains helpers for working with Kubernetes resource lists.
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// LookupQuantity returns a quantity from the first resource list that has it.
+func LookupQuantity(rn corev1.ResourceName, rl ...corev1.ResourceList) resource.Quantity {
+	for i := range rl {
+		if q, ok := rl[i][rn]; ok {
+			return q
+		}
+	}
+	return *resource.NewQuantity(0, resource.DecimalExponent)
+}
@@ -31,6 +31,7 @@ import (
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1/numstr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -66,6 +67,26 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 
 	out.Parameters = nil
 	for _, p := range in.Spec.Parameters {
+		// The server is not aware of activation conditions, it suggests values for every parameter
+		if err := validation.CheckParameterCondition(in.Spec.Parameters, &p); err != nil {
+			return nil, nil, nil, err
+		}
+
+		// Floating point parameters have their own bounds
+		if p.Float != nil {
+			fp, fb, err := fromClusterFloatParameter(&p)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if fp != nil {
+				out.Parameters = append(out.Parameters, *fp)
+			}
+			if fb != nil {
+				baseline.Assignments = append(baseline.Assignments, *fb)
+			}
+			continue
+		}
+
 		// This is a special case to omit parameters client side
 		if p.Min == p.Max && len(p.Values) == 0 {
 			continue
@@ -78,12 +99,17 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 				Values: p.Values,
 			})
 		} else {
+			// Only multiples of the step are suggested by the server
+			min, max := p.StepBounds()
+			if min > max {
+				return nil, nil, nil, fmt.Errorf("no multiple of the step in range for parameter '%s'", p.Name)
+			}
 			out.Parameters = append(out.Parameters, experimentsv1alpha1.Parameter{
 				Type: experimentsv1alpha1.ParameterTypeInteger,
 				Name: p.Name,
 				Bounds: &experimentsv1alpha1.Bounds{
-					Min: json.Number(strconv.FormatInt(int64(p.Min), 10)),
-					Max: json.Number(strconv.FormatInt(int64(p.Max), 10)),
+					Min: json.Number(strconv.FormatInt(int64(min), 10)),
+					Max: json.Number(strconv.FormatInt(int64(max), 10)),
 				},
 			})
 		}
@@ -98,7 +124,7 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 				v = numstr.FromString(vs)
 			} else {
 				vi := p.Baseline.IntVal
-				if vi < p.Min || vi > p.Max {
+				if vi < p.Min || vi > p.Max || p.Snap(int64(vi)) != vi {
 					return nil, nil, nil, fmt.Errorf("baseline out of range for parameter '%s'", p.Name)
 				}
 				v = numstr.FromInt64(int64(vi))
@@ -112,6 +138,14 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 
 	out.Constraints = nil
 	for _, c := range in.Spec.Constraints {
+		// The server only sees the logarithm of log scale parameters
+		if err := validation.CheckConstraintScale(in.Spec.Parameters, &c); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := validation.CheckConstraintTerms(in.Spec.Parameters, &c); err != nil {
+			return nil, nil, nil, err
+		}
+
 		switch {
 		case c.Order != nil:
 			out.Constraints = append(out.Constraints, experimentsv1alpha1.Constraint{
@@ -125,7 +159,7 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 		case c.Sum != nil:
 			sc := &experimentsv1alpha1.SumConstraint{
 				IsUpperBound: c.Sum.IsUpperBound,
-				Bound:        float64(c.Sum.Bound.MilliValue()) / 1000,
+				Bound:        quantityFloat(&c.Sum.Bound),
 			}
 			for _, p := range c.Sum.Parameters {
 				// This is a special case to omit parameters client side
@@ -135,7 +169,7 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 
 				sc.Parameters = append(sc.Parameters, experimentsv1alpha1.SumConstraintParameter{
 					ParameterName: p.Name,
-					Weight:        float64(p.Weight.MilliValue()) / 1000,
+					Weight:        quantityFloat(&p.Weight),
 				})
 			}
 
@@ -144,6 +178,39 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 				ConstraintType: experimentsv1alpha1.ConstraintSum,
 				SumConstraint:  sc,
 			})
+		case c.Linear != nil:
+			var params []experimentsv1alpha1.SumConstraintParameter
+			for _, t := range c.Linear.Terms {
+				// This is a special case to omit parameters client side
+				if t.Coefficient.IsZero() {
+					continue
+				}
+
+				params = append(params, experimentsv1alpha1.SumConstraintParameter{
+					ParameterName: t.Name,
+					Weight:        quantityFloat(&t.Coefficient),
+				})
+			}
+
+			cs, err := fromClusterBoundedConstraint(c.Name, c.Linear.Min, c.Linear.Max, func(bound float64) (float64, []experimentsv1alpha1.SumConstraintParameter) {
+				return bound, params
+			})
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			out.Constraints = append(out.Constraints, cs...)
+		case c.Ratio != nil:
+			// A bound on "numerator / denominator" is a bound of 0 on "numerator - bound * denominator"
+			cs, err := fromClusterBoundedConstraint(c.Name, c.Ratio.Min, c.Ratio.Max, func(bound float64) (float64, []experimentsv1alpha1.SumConstraintParameter) {
+				return 0, []experimentsv1alpha1.SumConstraintParameter{
+					{ParameterName: c.Ratio.Numerator, Weight: 1},
+					{ParameterName: c.Ratio.Denominator, Weight: -bound},
+				}
+			})
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			out.Constraints = append(out.Constraints, cs...)
 		}
 	}
 
@@ -169,6 +236,95 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 	return n, out, baseline, nil
 }
 
+// fromClusterBoundedConstraint converts inclusive bounds on a linear combination of parameters into one or two sum
+// constraints, the sum function returns the bound and weighted parameters of the sum for a specific bound
+func fromClusterBoundedConstraint(name string, min, max *resource.Quantity, sum func(float64) (float64, []experimentsv1alpha1.SumConstraintParameter)) ([]experimentsv1alpha1.Constraint, error) {
+	if min == nil && max == nil {
+		return nil, fmt.Errorf("constraint '%s' must have a minimum or maximum", name)
+	}
+
+	minName, maxName := name, name
+	if min != nil && max != nil && name != "" {
+		minName, maxName = name+"-min", name+"-max"
+	}
+
+	var result []experimentsv1alpha1.Constraint
+	if min != nil {
+		bound, params := sum(quantityFloat(min))
+		result = append(result, experimentsv1alpha1.Constraint{
+			Name:           minName,
+			ConstraintType: experimentsv1alpha1.ConstraintSum,
+			SumConstraint: &experimentsv1alpha1.SumConstraint{
+				Bound:      bound,
+				Parameters: params,
+			},
+		})
+	}
+	if max != nil {
+		bound, params := sum(quantityFloat(max))
+		result = append(result, experimentsv1alpha1.Constraint{
+			Name:           maxName,
+			ConstraintType: experimentsv1alpha1.ConstraintSum,
+			SumConstraint: &experimentsv1alpha1.SumConstraint{
+				IsUpperBound: true,
+				Bound:        bound,
+				Parameters:   params,
+			},
+		})
+	}
+	return result, nil
+}
+
+// quantityFloat returns the floating point value of a quantity without truncating it to milli-units
+func quantityFloat(q *resource.Quantity) float64 {
+	f, _ := strconv.ParseFloat(q.AsDec().String(), 64)
+	return f
+}
+
+// fromClusterFloatParameter converts a floating point parameter and its baseline, log scale parameters are
+// represented on the server using the base 10 logarithm of the values
+func fromClusterFloatParameter(p *optimizev1beta2.Parameter) (*experimentsv1alpha1.Parameter, *experimentsv1alpha1.Assignment, error) {
+	min, max := p.Float.Bounds()
+	if min > max {
+		return nil, nil, fmt.Errorf("invalid bounds for parameter '%s'", p.Name)
+	}
+	if p.Float.LogScale && min <= 0 {
+		return nil, nil, fmt.Errorf("log scale parameter '%s' must have a positive minimum", p.Name)
+	}
+
+	// This is a special case to omit parameters client side
+	if min == max {
+		return nil, nil, nil
+	}
+
+	toServer := func(v float64) float64 { return v }
+	if p.Float.LogScale {
+		toServer = math.Log10
+	}
+
+	param := &experimentsv1alpha1.Parameter{
+		Type: experimentsv1alpha1.ParameterTypeDouble,
+		Name: p.Name,
+		Bounds: &experimentsv1alpha1.Bounds{
+			Min: json.Number(strconv.FormatFloat(toServer(min), 'f', -1, 64)),
+			Max: json.Number(strconv.FormatFloat(toServer(max), 'f', -1, 64)),
+		},
+	}
+
+	if p.Baseline == nil {
+		return param, nil, nil
+	}
+
+	v, err := strconv.ParseFloat(p.Baseline.String(), 64)
+	if err != nil || v < min || v > max {
+		return nil, nil, fmt.Errorf("baseline out of range for parameter '%s'", p.Name)
+	}
+	return param, &experimentsv1alpha1.Assignment{
+		ParameterName: p.Name,
+		Value:         numstr.FromFloat64(toServer(v)),
+	}, nil
+}
+
 // ToCluster converts API state to cluster state
 func ToCluster(exp *optimizev1beta2.Experiment, ee *experimentsv1alpha1.Experiment) {
 	if exp.GetAnnotations() == nil {
@@ -189,8 +345,8 @@ func ToCluster(exp *optimizev1beta2.Experiment, ee *experimentsv1alpha1.Experime
 	controllerutil.AddFinalizer(exp, Finalizer)
 }
 
-// ToClusterTrial converts API state to cluster state
-func ToClusterTrial(t *optimizev1beta2.Trial, suggestion *experimentsv1alpha1.TrialAssignments) {
+// ToClusterTrial converts API state to cluster state, the experiment parameters are used to convert floating point values
+func ToClusterTrial(t *optimizev1beta2.Trial, parameters []optimizev1beta2.Parameter, suggestion *experimentsv1alpha1.TrialAssignments) {
 	t.GetAnnotations()[optimizev1beta2.AnnotationReportTrialURL] = suggestion.Location()
 
 	// Try to make the cluster trial names match what is on the server
@@ -203,10 +359,72 @@ func ToClusterTrial(t *optimizev1beta2.Trial, suggestion *experimentsv1alpha1.Tr
 		}
 	}
 
-	for _, a := range suggestion.Assignments {
+	t.Spec.Assignments = append(t.Spec.Assignments, toClusterAssignments(parameters, suggestion.Assignments, true)...)
+
+	if len(suggestion.Labels) > 0 {
+		if t.Labels == nil {
+			t.Labels = make(map[string]string, len(suggestion.Labels))
+		}
+		for k, v := range suggestion.Labels {
+			if v != "" {
+				t.Labels[k] = v
+			} else {
+				delete(t.Labels, k)
+			}
+		}
+	}
+
+	trial.UpdateStatus(t)
+
+	controllerutil.AddFinalizer(t, Finalizer)
+}
+
+// toClusterAssignments converts server assignments into cluster assignments, assignments for parameters which are not
+// active are omitted. When snapping, values are adjusted to fit the parameter step and bounds; otherwise the values
+// are preserved so assignments which are no longer valid for the parameters can be detected.
+func toClusterAssignments(parameters []optimizev1beta2.Parameter, assignments []experimentsv1alpha1.Assignment, snap bool) []optimizev1beta2.Assignment {
+	params := make(map[string]*optimizev1beta2.Parameter, len(parameters))
+	for i := range parameters {
+		params[parameters[i].Name] = &parameters[i]
+	}
+
+	values := make(map[string]string, len(assignments))
+	for _, a := range assignments {
+		values[a.ParameterName] = a.Value.String()
+	}
+
+	var result []optimizev1beta2.Assignment
+	for _, a := range assignments {
+		p := params[a.ParameterName]
+		if p != nil && !p.IsActive(values) {
+			// Inactive parameters are still suggested by the server, but they do not apply to the trial
+			continue
+		}
+		if p != nil && p.Float != nil {
+			val := a.Value.Float64Value()
+			if p.Float.LogScale {
+				val = math.Pow(10, val)
+			}
+
+			value := strconv.FormatFloat(val, 'f', -1, 64)
+			if snap {
+				value = p.Float.Format(val)
+			}
+
+			result = append(result, optimizev1beta2.Assignment{
+				Name:  a.ParameterName,
+				Value: intstr.FromString(value),
+				Float: true,
+			})
+			continue
+		}
+
 		var v intstr.IntOrString
 		if a.Value.IsString {
 			v = intstr.FromString(a.Value.StrVal)
+		} else if snap && p != nil && p.Step > 1 {
+			// Snap values that are not a multiple of the step
+			v = intstr.FromInt(int(p.Snap(a.Value.Int64Value())))
 		} else {
 			// While the server supports 64-bit integers, any parameters used for Kubernetes
 			// experiments will have been defined with 32-bit integer bounds.
@@ -221,28 +439,12 @@ func ToClusterTrial(t *optimizev1beta2.Trial, suggestion *experimentsv1alpha1.Tr
 			}
 		}
 
-		t.Spec.Assignments = append(t.Spec.Assignments, optimizev1beta2.Assignment{
+		result = append(result, optimizev1beta2.Assignment{
 			Name:  a.ParameterName,
 			Value: v,
 		})
 	}
-
-	if len(suggestion.Labels) > 0 {
-		if t.Labels == nil {
-			t.Labels = make(map[string]string, len(suggestion.Labels))
-		}
-		for k, v := range suggestion.Labels {
-			if v != "" {
-				t.Labels[k] = v
-			} else {
-				delete(t.Labels, k)
-			}
-		}
-	}
-
-	trial.UpdateStatus(t)
-
-	controllerutil.AddFinalizer(t, Finalizer)
+	return result
 }
 
 // FromClusterTrial converts cluster state to API state
@@ -286,6 +488,107 @@ func FromClusterTrial(t *optimizev1beta2.Trial) *experimentsv1alpha1.TrialValues
 	return out
 }
 
+// ToClusterTrialItem converts a completed server trial into a cluster trial, the parameters are used to interpret the
+// assignments and should match the parameters of the server experiment the trial belongs to. The assigned values are
+// preserved as recorded, they are not adjusted to fit the current parameter bounds or step.
+func ToClusterTrialItem(t *optimizev1beta2.Trial, parameters []optimizev1beta2.Parameter, item *experimentsv1alpha1.TrialItem) {
+	if l := item.Location(); l != "" {
+		t.Name = path.Base(l)
+		if t.Annotations == nil {
+			t.Annotations = make(map[string]string, 1)
+		}
+		t.Annotations[optimizev1beta2.AnnotationReportTrialURL] = l
+	}
+
+	t.Spec.Assignments = toClusterAssignments(parameters, item.Assignments, false)
+
+	for _, v := range item.Values {
+		value := optimizev1beta2.Value{
+			Name:  v.MetricName,
+			Value: strconv.FormatFloat(v.Value, 'f', -1, 64),
+		}
+		if v.Error != 0 {
+			value.Error = strconv.FormatFloat(v.Error, 'f', -1, 64)
+		}
+		t.Spec.Values = append(t.Spec.Values, value)
+	}
+
+	if item.Status == experimentsv1alpha1.TrialCompleted {
+		trial.ApplyCondition(&t.Status, optimizev1beta2.TrialComplete, corev1.ConditionTrue, "", "", nil)
+	}
+}
+
+// FromClusterTrialAssignments converts the assignments of a cluster trial into a server suggestion. The server
+// expects a value for every parameter, parameters which are not active for the trial are assigned their baseline
+// or the lower bound of the parameter.
+func FromClusterTrialAssignments(exp *optimizev1beta2.Experiment, t *optimizev1beta2.Trial) (*experimentsv1alpha1.TrialAssignments, error) {
+	assignments := make(map[string]intstr.IntOrString, len(t.Spec.Assignments))
+	for _, a := range t.Spec.Assignments {
+		assignments[a.Name] = a.Value
+	}
+
+	out := &experimentsv1alpha1.TrialAssignments{}
+	for i := range exp.Spec.Parameters {
+		p := &exp.Spec.Parameters[i]
+		v, ok := assignments[p.Name]
+		if !ok {
+			v = defaultAssignment(p)
+		}
+
+		if p.Float != nil {
+			// This is a special case to omit parameters client side
+			if min, max := p.Float.Bounds(); min == max {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(v.String(), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid assignment for parameter '%s'", p.Name)
+			}
+			if p.Float.LogScale {
+				f = math.Log10(f)
+			}
+			out.Assignments = append(out.Assignments, experimentsv1alpha1.Assignment{
+				ParameterName: p.Name,
+				Value:         numstr.FromFloat64(f),
+			})
+			continue
+		}
+
+		// This is a special case to omit parameters client side
+		if p.Min == p.Max && len(p.Values) == 0 {
+			continue
+		}
+
+		av := numstr.FromInt64(int64(v.IntVal))
+		if v.Type == intstr.String {
+			av = numstr.FromString(v.StrVal)
+		}
+		out.Assignments = append(out.Assignments, experimentsv1alpha1.Assignment{
+			ParameterName: p.Name,
+			Value:         av,
+		})
+	}
+
+	return out, nil
+}
+
+// defaultAssignment returns the value used for a parameter without an assignment
+func defaultAssignment(p *optimizev1beta2.Parameter) intstr.IntOrString {
+	switch {
+	case p.Baseline != nil:
+		return *p.Baseline
+	case p.Float != nil:
+		min, _ := p.Float.Bounds()
+		return intstr.FromString(p.Float.Format(min))
+	case len(p.Values) > 0:
+		return intstr.FromString(p.Values[0])
+	default:
+		min, _ := p.StepBounds()
+		return intstr.FromInt(int(min))
+	}
+}
+
 // IsServerSyncEnabled checks to see if server synchronization is enabled.
 func IsServerSyncEnabled(exp *optimizev1beta2.Experiment) bool {
 	switch strings.ToLower(exp.GetAnnotations()[optimizev1beta2.AnnotationServerSync]) {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/validation"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1/numstr"
@@ -39,6 +40,8 @@ func TestFromCluster(t *testing.T) {
 	one := intstr.FromInt(1)
 	two := intstr.FromInt(2)
 	three := intstr.FromString("three")
+	ratio := intstr.FromString("0.5")
+	rate := intstr.FromString("1")
 	now := time.Now()
 	cases := []struct {
 		desc     string
@@ -197,6 +200,128 @@ func TestFromCluster(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "linear and ratio constraints",
+			in: &optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Constraints: []optimizev1beta2.Constraint{
+						{
+							Name: "total",
+							Linear: &optimizev1beta2.LinearConstraint{
+								Terms: []optimizev1beta2.LinearConstraintTerm{
+									{Name: "one", Coefficient: resource.MustParse("2")},
+									{Name: "two", Coefficient: resource.MustParse("-0.5")},
+									{Name: "three", Coefficient: resource.MustParse("0")},
+								},
+								Min: resource.NewQuantity(1, resource.DecimalSI),
+								Max: resource.NewQuantity(10, resource.DecimalSI),
+							},
+						},
+						{
+							Name: "limit",
+							Ratio: &optimizev1beta2.RatioConstraint{
+								Numerator:   "limit",
+								Denominator: "request",
+								Min:         resource.NewMilliQuantity(1250, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			out: &experimentsv1alpha1.Experiment{
+				Constraints: []experimentsv1alpha1.Constraint{
+					{
+						Name:           "total-min",
+						ConstraintType: experimentsv1alpha1.ConstraintSum,
+						SumConstraint: &experimentsv1alpha1.SumConstraint{
+							Bound: 1,
+							Parameters: []experimentsv1alpha1.SumConstraintParameter{
+								{ParameterName: "one", Weight: 2},
+								{ParameterName: "two", Weight: -0.5},
+							},
+						},
+					},
+					{
+						Name:           "total-max",
+						ConstraintType: experimentsv1alpha1.ConstraintSum,
+						SumConstraint: &experimentsv1alpha1.SumConstraint{
+							IsUpperBound: true,
+							Bound:        10,
+							Parameters: []experimentsv1alpha1.SumConstraintParameter{
+								{ParameterName: "one", Weight: 2},
+								{ParameterName: "two", Weight: -0.5},
+							},
+						},
+					},
+					{
+						Name:           "limit",
+						ConstraintType: experimentsv1alpha1.ConstraintSum,
+						SumConstraint: &experimentsv1alpha1.SumConstraint{
+							Bound: 0,
+							Parameters: []experimentsv1alpha1.SumConstraintParameter{
+								{ParameterName: "limit", Weight: 1},
+								{ParameterName: "request", Weight: -1.25},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "fractional constraints",
+			in: &optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Constraints: []optimizev1beta2.Constraint{
+						{
+							Name: "weighted",
+							Linear: &optimizev1beta2.LinearConstraint{
+								Terms: []optimizev1beta2.LinearConstraintTerm{
+									{Name: "memory", Coefficient: resource.MustParse("0.00025")},
+									{Name: "cpu", Coefficient: resource.MustParse("-1")},
+								},
+								Max: resource.NewScaledQuantity(5, -4),
+							},
+						},
+						{
+							Name: "memory-cpu",
+							Ratio: &optimizev1beta2.RatioConstraint{
+								Numerator:   "cpu",
+								Denominator: "memory",
+								Max:         resource.NewScaledQuantity(25, -5),
+							},
+						},
+					},
+				},
+			},
+			out: &experimentsv1alpha1.Experiment{
+				Constraints: []experimentsv1alpha1.Constraint{
+					{
+						Name:           "weighted",
+						ConstraintType: experimentsv1alpha1.ConstraintSum,
+						SumConstraint: &experimentsv1alpha1.SumConstraint{
+							IsUpperBound: true,
+							Bound:        0.0005,
+							Parameters: []experimentsv1alpha1.SumConstraintParameter{
+								{ParameterName: "memory", Weight: 0.00025},
+								{ParameterName: "cpu", Weight: -1},
+							},
+						},
+					},
+					{
+						Name:           "memory-cpu",
+						ConstraintType: experimentsv1alpha1.ConstraintSum,
+						SumConstraint: &experimentsv1alpha1.SumConstraint{
+							IsUpperBound: true,
+							Bound:        0,
+							Parameters: []experimentsv1alpha1.SumConstraintParameter{
+								{ParameterName: "cpu", Weight: 1},
+								{ParameterName: "memory", Weight: -0.00025},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			desc: "metrics",
 			in: &optimizev1beta2.Experiment{
@@ -255,6 +380,39 @@ func TestFromCluster(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "float parameters",
+			in: &optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Parameters: []optimizev1beta2.Parameter{
+						{Name: "ratio", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.25"), Max: resource.MustParse("0.75")}, Baseline: &ratio},
+						{Name: "rate", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.001"), Max: resource.MustParse("10"), LogScale: true}, Baseline: &rate},
+						{Name: "fixed", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("1.5"), Max: resource.MustParse("1.5")}},
+					},
+				},
+			},
+			out: &experimentsv1alpha1.Experiment{
+				Parameters: []experimentsv1alpha1.Parameter{
+					{
+						Type:   experimentsv1alpha1.ParameterTypeDouble,
+						Name:   "ratio",
+						Bounds: &experimentsv1alpha1.Bounds{Min: "0.25", Max: "0.75"},
+					},
+					{
+						Type:   experimentsv1alpha1.ParameterTypeDouble,
+						Name:   "rate",
+						Bounds: &experimentsv1alpha1.Bounds{Min: "-3", Max: "1"},
+					},
+				},
+			},
+			baseline: &experimentsv1alpha1.TrialAssignments{
+				Labels: map[string]string{"baseline": "true"},
+				Assignments: []experimentsv1alpha1.Assignment{
+					{ParameterName: "ratio", Value: numstr.FromFloat64(0.5)},
+					{ParameterName: "rate", Value: numstr.FromFloat64(0)},
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -320,9 +478,11 @@ func TestToCluster(t *testing.T) {
 }
 
 func TestToClusterTrial(t *testing.T) {
+	two32 := int32(2)
 	cases := []struct {
 		desc       string
 		trial      *optimizev1beta2.Trial
+		parameters []optimizev1beta2.Parameter
 		suggestion *experimentsv1alpha1.TrialAssignments
 		trialOut   *optimizev1beta2.Trial
 	}{
@@ -440,10 +600,120 @@ func TestToClusterTrial(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "float",
+			trial: &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{},
+				},
+			},
+			parameters: []optimizev1beta2.Parameter{
+				{Name: "ratio", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0"), Max: resource.MustParse("1"), Precision: &two32}},
+				{Name: "rate", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.001"), Max: resource.MustParse("10"), LogScale: true}},
+			},
+			suggestion: &experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{
+					{ParameterName: "ratio", Value: numstr.FromFloat64(0.12345)},
+					{ParameterName: "rate", Value: numstr.FromFloat64(-2)},
+				},
+			},
+			trialOut: &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"stormforge.io/report-trial-url": "",
+					},
+					Finalizers: []string{"serverFinalizer.stormforge.io"},
+				},
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{
+						{Name: "ratio", Value: intstr.FromString("0.12"), Float: true},
+						{Name: "rate", Value: intstr.FromString("0.01"), Float: true},
+					},
+				},
+				Status: optimizev1beta2.TrialStatus{
+					Phase:       "Created",
+					Assignments: "ratio=0.12, rate=0.01",
+				},
+			},
+		},
+		{
+			desc: "step",
+			trial: &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{},
+				},
+			},
+			parameters: []optimizev1beta2.Parameter{
+				{Name: "memory", Min: 128, Max: 4096, Step: 64},
+				{Name: "replicas", Min: 1, Max: 9, Step: 2},
+			},
+			suggestion: &experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{
+					{ParameterName: "memory", Value: numstr.FromInt64(1000)},
+					{ParameterName: "replicas", Value: numstr.FromInt64(9)},
+				},
+			},
+			trialOut: &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"stormforge.io/report-trial-url": "",
+					},
+					Finalizers: []string{"serverFinalizer.stormforge.io"},
+				},
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{
+						{Name: "memory", Value: intstr.FromInt(1024)},
+						{Name: "replicas", Value: intstr.FromInt(8)},
+					},
+				},
+				Status: optimizev1beta2.TrialStatus{
+					Phase:       "Created",
+					Assignments: "memory=1024, replicas=8",
+				},
+			},
+		},
+		{
+			desc: "conditional",
+			trial: &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{},
+				},
+			},
+			parameters: []optimizev1beta2.Parameter{
+				{Name: "gc", Values: []string{"G1", "Parallel"}},
+				{Name: "g1_region", Min: 1, Max: 32, ActiveWhen: &optimizev1beta2.ParameterCondition{Parameter: "gc", Values: []string{"G1"}}},
+				{Name: "parallel_threads", Min: 1, Max: 16, ActiveWhen: &optimizev1beta2.ParameterCondition{Parameter: "gc", Values: []string{"Parallel"}}},
+			},
+			suggestion: &experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{
+					{ParameterName: "gc", Value: numstr.FromString("Parallel")},
+					{ParameterName: "g1_region", Value: numstr.FromInt64(16)},
+					{ParameterName: "parallel_threads", Value: numstr.FromInt64(4)},
+				},
+			},
+			trialOut: &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"stormforge.io/report-trial-url": "",
+					},
+					Finalizers: []string{"serverFinalizer.stormforge.io"},
+				},
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{
+						{Name: "gc", Value: intstr.FromString("Parallel")},
+						{Name: "parallel_threads", Value: intstr.FromInt(4)},
+					},
+				},
+				Status: optimizev1beta2.TrialStatus{
+					Phase:       "Created",
+					Assignments: "gc=Parallel, parallel_threads=4",
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
-			ToClusterTrial(c.trial, c.suggestion)
+			ToClusterTrial(c.trial, c.parameters, c.suggestion)
 			assert.Equal(t, c.trialOut, c.trial)
 		})
 	}
@@ -528,3 +798,157 @@ func TestFromClusterTrial(t *testing.T) {
 		})
 	}
 }
+
+func TestFromClusterTrialAssignments(t *testing.T) {
+	one := intstr.FromInt(1)
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "replicas", Min: 1, Max: 10, Baseline: &one},
+				{Name: "mode", Values: []string{"fast", "slow"}},
+				{Name: "ratio", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.1"), Max: resource.MustParse("10"), LogScale: true}},
+				{Name: "cache", Min: 64, Max: 1024, Step: 64, ActiveWhen: &optimizev1beta2.ParameterCondition{Parameter: "mode", Values: []string{"fast"}}},
+				{Name: "fixed", Min: 1, Max: 1},
+			},
+		},
+	}
+
+	trial := &optimizev1beta2.Trial{
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments: []optimizev1beta2.Assignment{
+				{Name: "replicas", Value: intstr.FromInt(3)},
+				{Name: "mode", Value: intstr.FromString("slow")},
+				{Name: "ratio", Value: intstr.FromString("1"), Float: true},
+			},
+		},
+	}
+
+	out, err := FromClusterTrialAssignments(exp, trial)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []experimentsv1alpha1.Assignment{
+			{ParameterName: "replicas", Value: numstr.FromInt64(3)},
+			{ParameterName: "mode", Value: numstr.FromString("slow")},
+			{ParameterName: "ratio", Value: numstr.FromFloat64(0)},
+			{ParameterName: "cache", Value: numstr.FromInt64(64)},
+		}, out.Assignments)
+	}
+}
+
+func TestToClusterTrialItem(t *testing.T) {
+	parameters := []optimizev1beta2.Parameter{
+		{Name: "replicas", Min: 1, Max: 10},
+		{Name: "ratio", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.1"), Max: resource.MustParse("10"), LogScale: true}},
+	}
+
+	item := &experimentsv1alpha1.TrialItem{
+		TrialAssignments: experimentsv1alpha1.TrialAssignments{
+			Assignments: []experimentsv1alpha1.Assignment{
+				{ParameterName: "replicas", Value: numstr.FromInt64(3)},
+				{ParameterName: "ratio", Value: numstr.FromFloat64(1)},
+			},
+		},
+		TrialValues: experimentsv1alpha1.TrialValues{
+			Values: []experimentsv1alpha1.Value{
+				{MetricName: "cost", Value: 1.5},
+				{MetricName: "latency", Value: 100, Error: 2},
+			},
+		},
+		Status: experimentsv1alpha1.TrialCompleted,
+	}
+
+	out := &optimizev1beta2.Trial{}
+	ToClusterTrialItem(out, parameters, item)
+	assert.Equal(t, []optimizev1beta2.Assignment{
+		{Name: "replicas", Value: intstr.FromInt(3)},
+		{Name: "ratio", Value: intstr.FromString("10"), Float: true},
+	}, out.Spec.Assignments)
+	assert.Equal(t, []optimizev1beta2.Value{
+		{Name: "cost", Value: "1.5"},
+		{Name: "latency", Value: "100", Error: "2"},
+	}, out.Spec.Values)
+	if assert.Len(t, out.Status.Conditions, 1) {
+		assert.Equal(t, optimizev1beta2.TrialComplete, out.Status.Conditions[0].Type)
+		assert.Equal(t, corev1.ConditionTrue, out.Status.Conditions[0].Status)
+	}
+}
+
+func TestToClusterTrialItem_Invalid(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "memory", Min: 128, Max: 1024, Step: 128},
+				{Name: "ratio", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.5"), Max: resource.MustParse("2")}},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		assignments []experimentsv1alpha1.Assignment
+		expected    []optimizev1beta2.Assignment
+		expectErr   bool
+	}{
+		{
+			desc: "valid",
+			assignments: []experimentsv1alpha1.Assignment{
+				{ParameterName: "memory", Value: numstr.FromInt64(512)},
+				{ParameterName: "ratio", Value: numstr.FromFloat64(1.5)},
+			},
+			expected: []optimizev1beta2.Assignment{
+				{Name: "memory", Value: intstr.FromInt(512)},
+				{Name: "ratio", Value: intstr.FromString("1.5"), Float: true},
+			},
+		},
+		{
+			desc: "off step",
+			assignments: []experimentsv1alpha1.Assignment{
+				{ParameterName: "memory", Value: numstr.FromInt64(500)},
+				{ParameterName: "ratio", Value: numstr.FromFloat64(1.5)},
+			},
+			expected: []optimizev1beta2.Assignment{
+				{Name: "memory", Value: intstr.FromInt(500)},
+				{Name: "ratio", Value: intstr.FromString("1.5"), Float: true},
+			},
+			expectErr: true,
+		},
+		{
+			desc: "integer out of range",
+			assignments: []experimentsv1alpha1.Assignment{
+				{ParameterName: "memory", Value: numstr.FromInt64(2048)},
+				{ParameterName: "ratio", Value: numstr.FromFloat64(1.5)},
+			},
+			expected: []optimizev1beta2.Assignment{
+				{Name: "memory", Value: intstr.FromInt(2048)},
+				{Name: "ratio", Value: intstr.FromString("1.5"), Float: true},
+			},
+			expectErr: true,
+		},
+		{
+			desc: "float out of range",
+			assignments: []experimentsv1alpha1.Assignment{
+				{ParameterName: "memory", Value: numstr.FromInt64(512)},
+				{ParameterName: "ratio", Value: numstr.FromFloat64(4)},
+			},
+			expected: []optimizev1beta2.Assignment{
+				{Name: "memory", Value: intstr.FromInt(512)},
+				{Name: "ratio", Value: intstr.FromString("4"), Float: true},
+			},
+			expectErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			out := &optimizev1beta2.Trial{}
+			item := &experimentsv1alpha1.TrialItem{TrialAssignments: experimentsv1alpha1.TrialAssignments{Assignments: c.assignments}}
+			ToClusterTrialItem(out, exp.Spec.Parameters, item)
+			assert.Equal(t, c.expected, out.Spec.Assignments)
+
+			err := validation.CheckAssignments(out, exp)
+			if c.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
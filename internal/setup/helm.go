@@ -0,0 +1,214 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package setup
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// HelmCommand is the setup tools script used to run the Helm CLI directly
+const HelmCommand = "/workspace/helm.sh"
+
+// helmValues evaluates the Helm values for a setup task, adding volumes and mounts to the container as necessary
+func helmValues(t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask, volumes map[string]*corev1.Volume, c *corev1.Container) ([]helmGeneratorValue, error) {
+	var values []helmGeneratorValue
+	te := template.New()
+
+	// Helm Values
+	for _, hv := range task.HelmValues {
+		hgv := helmGeneratorValue{
+			Name:        hv.Name,
+			ForceString: hv.ForceString,
+		}
+
+		if hv.ValueFrom != nil {
+			// Evaluate the external value source
+			switch {
+			case hv.ValueFrom.ParameterRef != nil:
+				v, ok := t.GetAssignment(hv.ValueFrom.ParameterRef.Name)
+				if !ok {
+					return nil, fmt.Errorf("invalid parameter reference '%s' for Helm value '%s'", hv.ValueFrom.ParameterRef.Name, hv.Name)
+				}
+				if v.Type == intstr.String {
+					hgv.Value = v.StrVal
+				} else {
+					hgv.Value = v.IntVal
+				}
+
+			default:
+				return nil, fmt.Errorf("unknown source for Helm value '%s'", hv.Name)
+			}
+		} else {
+			// If there is no external source, evaluate the value field as a template
+			v, err := te.RenderHelmValue(&hv, t)
+			if err != nil {
+				return nil, err
+			}
+			hgv.Value = v
+		}
+
+		values = append(values, hgv)
+	}
+
+	// Helm Values From
+	for _, hvf := range task.HelmValuesFrom {
+		var vm corev1.VolumeMount
+		var vs corev1.VolumeSource
+		switch {
+		case hvf.ConfigMap != nil:
+			vm.Name = hvf.ConfigMap.Name
+			vm.MountPath = path.Join("/workspace", "helm-values", hvf.ConfigMap.Name)
+			vs.ConfigMap = &corev1.ConfigMapVolumeSource{LocalObjectReference: hvf.ConfigMap.LocalObjectReference}
+		case hvf.Secret != nil:
+			vm.Name = "helm-secret-" + hvf.Secret.Name
+			vm.MountPath = path.Join("/workspace", "helm-secret-values", hvf.Secret.Name)
+			vs.Secret = &corev1.SecretVolumeSource{SecretName: hvf.Secret.Name}
+		default:
+			continue
+		}
+		vm.ReadOnly = true
+
+		if _, ok := volumes[vm.Name]; !ok {
+			volumes[vm.Name] = &corev1.Volume{Name: vm.Name, VolumeSource: vs}
+		}
+		c.VolumeMounts = append(c.VolumeMounts, vm)
+		values = append(values, helmGeneratorValue{File: path.Join(vm.MountPath, "*values.yaml")})
+	}
+
+	return values, nil
+}
+
+// useHelmCLI checks to see if the setup task requires features only available by running the Helm CLI directly
+func useHelmCLI(task *optimizev1beta2.SetupTask) bool {
+	return task.HelmUpgrade ||
+		task.HelmValuesInline != "" ||
+		task.HelmReleaseNamespace != "" ||
+		strings.HasPrefix(task.HelmChart, "oci://")
+}
+
+// configureHelmCLI configures the container to run the Helm CLI directly
+func configureHelmCLI(t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask, mode string, values []helmGeneratorValue, c *corev1.Container) error {
+	if len(c.Command) == 0 {
+		c.Command = []string{HelmCommand}
+	}
+
+	releaseName := task.HelmReleaseName
+	if releaseName == "" {
+		releaseName = task.Name
+	}
+	releaseNamespace := task.HelmReleaseNamespace
+	if releaseNamespace == "" {
+		releaseNamespace = t.Namespace
+	}
+
+	c.Env = append(c.Env,
+		corev1.EnvVar{Name: "HELM_CHART", Value: task.HelmChart},
+		corev1.EnvVar{Name: "HELM_CHART_VERSION", Value: task.HelmChartVersion},
+		corev1.EnvVar{Name: "HELM_REPOSITORY", Value: task.HelmRepository},
+		corev1.EnvVar{Name: "HELM_RELEASE_NAME", Value: releaseName},
+		corev1.EnvVar{Name: "HELM_RELEASE_NAMESPACE", Value: releaseNamespace},
+		corev1.EnvVar{Name: "HELM_UPGRADE", Value: fmt.Sprintf("%t", task.HelmUpgrade)},
+	)
+
+	// Values files are expanded by the script, individual values are passed as arguments
+	var files []string
+	args := []string{mode}
+	for _, v := range values {
+		switch {
+		case v.File != "":
+			files = append(files, v.File)
+		case v.ForceString:
+			args = append(args, "--set-string", fmt.Sprintf("%s=%v", v.Name, v.Value))
+		default:
+			args = append(args, "--set", fmt.Sprintf("%s=%v", v.Name, v.Value))
+		}
+	}
+	if len(files) > 0 {
+		c.Env = append(c.Env, corev1.EnvVar{Name: "HELM_VALUES_FILES", Value: strings.Join(files, " ")})
+	}
+
+	// Inline values are rendered using the same rules as patches
+	if task.HelmValuesInline != "" {
+		b, err := template.New().RenderYAML(task.Name, task.HelmValuesInline, t)
+		if err != nil {
+			return err
+		}
+		c.Env = append(c.Env, corev1.EnvVar{Name: "HELM_VALUES", Value: base64.StdEncoding.EncodeToString(b)})
+	}
+
+	if len(task.Args) == 0 {
+		c.Args = args
+	}
+
+	return nil
+}
+
+// ResolvedHelmValues are the Helm values of a setup task after evaluating parameter references and templates
+type ResolvedHelmValues struct {
+	// Set are the individual values, keyed by name
+	Set map[string]interface{} `json:"set,omitempty"`
+	// From are the config maps and secrets providing values files, e.g. "configMap/my-values"
+	From []string `json:"from,omitempty"`
+	// Inline is the rendered inline values YAML
+	Inline string `json:"inline,omitempty"`
+}
+
+// HelmValues returns the resolved Helm values of a setup task
+func HelmValues(t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask) (*ResolvedHelmValues, error) {
+	values, err := helmValues(t, task, make(map[string]*corev1.Volume), &corev1.Container{})
+	if err != nil {
+		return nil, err
+	}
+
+	rhv := &ResolvedHelmValues{}
+	for _, v := range values {
+		if v.Name == "" {
+			continue
+		}
+		if rhv.Set == nil {
+			rhv.Set = make(map[string]interface{}, len(values))
+		}
+		rhv.Set[v.Name] = v.Value
+	}
+
+	for _, hvf := range task.HelmValuesFrom {
+		switch {
+		case hvf.ConfigMap != nil:
+			rhv.From = append(rhv.From, "configMap/"+hvf.ConfigMap.Name)
+		case hvf.Secret != nil:
+			rhv.From = append(rhv.From, "secret/"+hvf.Secret.Name)
+		}
+	}
+
+	if task.HelmValuesInline != "" {
+		b, err := template.New().RenderYAML(task.Name, task.HelmValuesInline, t)
+		if err != nil {
+			return nil, err
+		}
+		rhv.Inline = string(b)
+	}
+
+	return rhv, nil
+}
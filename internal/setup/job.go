@@ -20,14 +20,11 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
-	"path"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
-	"github.com/thestormforge/optimize-controller/v2/internal/template"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/yaml"
 )
 
@@ -80,9 +77,16 @@ func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 		RunAsNonRoot: &runAsNonRoot,
 	}
 
+	// Determine the order the setup tasks must run in
+	tasks, err := OrderTasks(t.Spec.SetupTasks, mode)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create containers for each of the setup tasks
-	for _, task := range t.Spec.SetupTasks {
-		if (mode == ModeCreate && task.SkipCreate) || (mode == ModeDelete && task.SkipDelete) {
+	for _, task := range tasks {
+		// Manifests are applied by the controller directly
+		if task.Manifests != nil {
 			continue
 		}
 		c := corev1.Container{
@@ -128,88 +132,43 @@ func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 		// Add the configured volume mounts
 		c.VolumeMounts = append(c.VolumeMounts, task.VolumeMounts...)
 
-		// For Helm installs, serialize a Konjure configuration
-		helmConfig := newHelmGeneratorConfig(&task)
-		if helmConfig != nil {
-			te := template.New()
+		// For Helm installs, either run the Helm CLI directly or serialize a Konjure configuration
+		if task.HelmChart != "" {
+			values, err := helmValues(t, &task, volumes, &c)
+			if err != nil {
+				return nil, err
+			}
 
-			// Helm Values
-			for _, hv := range task.HelmValues {
-				hgv := helmGeneratorValue{
-					Name:        hv.Name,
-					ForceString: hv.ForceString,
+			if useHelmCLI(&task) {
+				if err := configureHelmCLI(t, &task, mode, values, &c); err != nil {
+					return nil, err
 				}
-
-				if hv.ValueFrom != nil {
-					// Evaluate the external value source
-					switch {
-					case hv.ValueFrom.ParameterRef != nil:
-						v, ok := t.GetAssignment(hv.ValueFrom.ParameterRef.Name)
-						if !ok {
-							return nil, fmt.Errorf("invalid parameter reference '%s' for Helm value '%s'", hv.ValueFrom.ParameterRef.Name, hv.Name)
-						}
-						if v.Type == intstr.String {
-							hgv.Value = v.StrVal
-						} else {
-							hgv.Value = v.IntVal
-						}
-
-					default:
-						return nil, fmt.Errorf("unknown source for Helm value '%s'", hv.Name)
-					}
-				} else {
-					// If there is no external source, evaluate the value field as a template
-					v, err := te.RenderHelmValue(&hv, t)
-					if err != nil {
-						return nil, err
-					}
-					hgv.Value = v
+			} else {
+				helmConfig := newHelmGeneratorConfig(&task)
+				helmConfig.Values = values
+				if task.HelmRepository != "" {
+					helmConfig.Repo = task.HelmRepository
 				}
 
-				helmConfig.Values = append(helmConfig.Values, hgv)
-			}
-
-			// Helm Values From
-			for _, hvf := range task.HelmValuesFrom {
-				if hvf.ConfigMap != nil {
-					hgv := helmGeneratorValue{
-						File: path.Join("/workspace", "helm-values", hvf.ConfigMap.Name, "*values.yaml"),
-					}
-					vm := corev1.VolumeMount{
-						Name:      hvf.ConfigMap.Name,
-						MountPath: path.Dir(hgv.File),
-						ReadOnly:  true,
-					}
-
-					if _, ok := volumes[vm.Name]; !ok {
-						vs := corev1.VolumeSource{
-							ConfigMap: &corev1.ConfigMapVolumeSource{
-								LocalObjectReference: corev1.LocalObjectReference{Name: hvf.ConfigMap.Name},
-							},
-						}
-						volumes[vm.Name] = &corev1.Volume{Name: vm.Name, VolumeSource: vs}
-					}
-					c.VolumeMounts = append(c.VolumeMounts, vm)
-					helmConfig.Values = append(helmConfig.Values, hgv)
+				// Record the base64 encoded YAML representation in the environment
+				b, err := yaml.Marshal(helmConfig)
+				if err != nil {
+					return nil, err
 				}
-			}
 
-			if task.HelmRepository != "" {
-				helmConfig.Repo = task.HelmRepository
+				c.Env = append(c.Env, corev1.EnvVar{Name: "HELM_CONFIG", Value: base64.StdEncoding.EncodeToString(b)})
 			}
-
-			// Record the base64 encoded YAML representation in the environment
-			b, err := yaml.Marshal(helmConfig)
-			if err != nil {
-				return nil, err
-			}
-
-			c.Env = append(c.Env, corev1.EnvVar{Name: "HELM_CONFIG", Value: base64.StdEncoding.EncodeToString(b)})
 		}
 
 		job.Spec.Template.Spec.Containers = append(job.Spec.Template.Spec.Containers, c)
 	}
 
+	// If there are dependencies, run everything sequentially as init containers (the last task is the main container)
+	if containers := job.Spec.Template.Spec.Containers; HasDependencies(t.Spec.SetupTasks) && len(containers) > 1 {
+		job.Spec.Template.Spec.InitContainers = containers[:len(containers)-1]
+		job.Spec.Template.Spec.Containers = containers[len(containers)-1:]
+	}
+
 	// Add all of the volumes we collected to the pod
 	for _, v := range volumes {
 		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, *v)
@@ -241,11 +200,15 @@ func newHelmGeneratorConfig(task *optimizev1beta2.SetupTask) *helmGeneratorConfi
 	}
 
 	cfg := &helmGeneratorConfig{
-		ReleaseName: task.Name,
+		ReleaseName: task.HelmReleaseName,
 		Chart:       task.HelmChart,
 		Version:     task.HelmChartVersion,
 	}
 
+	if cfg.ReleaseName == "" {
+		cfg.ReleaseName = task.Name
+	}
+
 	cfg.APIVersion = "konjure.carbonrelay.com/v1beta1"
 	cfg.Kind = "HelmGenerator"
 	cfg.Name = task.Name
@@ -17,13 +17,16 @@ limitations under the License.
 package setup_test
 
 import (
+	"encoding/base64"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/setup"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestNewJob(t *testing.T) {
@@ -100,3 +103,129 @@ func TestNewJob(t *testing.T) {
 		})
 	}
 }
+
+func TestNewJob_Helm(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments: []optimizev1beta2.Assignment{
+				{Name: "replicas", Value: intstr.FromInt(3)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		task     optimizev1beta2.SetupTask
+		mode     string
+		command  []string
+		args     []string
+		env      map[string]string
+		volumes  []string
+		noJobFor bool
+	}{
+		{
+			desc: "generator",
+			task: optimizev1beta2.SetupTask{
+				Name:      "app",
+				HelmChart: "stable/app",
+			},
+			mode: setup.ModeCreate,
+			args: []string{setup.ModeCreate},
+		},
+		{
+			desc: "oci chart",
+			task: optimizev1beta2.SetupTask{
+				Name:             "app",
+				HelmChart:        "oci://registry.example.com/charts/app",
+				HelmChartVersion: "1.2.3",
+				HelmValues: []optimizev1beta2.HelmValue{
+					{Name: "replicaCount", ValueFrom: &optimizev1beta2.HelmValueSource{ParameterRef: &optimizev1beta2.ParameterSelector{Name: "replicas"}}},
+					{Name: "image.tag", Value: intstr.FromString("v1"), ForceString: true},
+				},
+			},
+			mode:    setup.ModeCreate,
+			command: []string{setup.HelmCommand},
+			args:    []string{setup.ModeCreate, "--set", "replicaCount=3", "--set-string", "image.tag=v1"},
+			env: map[string]string{
+				"HELM_CHART":             "oci://registry.example.com/charts/app",
+				"HELM_CHART_VERSION":     "1.2.3",
+				"HELM_RELEASE_NAME":      "app",
+				"HELM_RELEASE_NAMESPACE": "default",
+				"HELM_UPGRADE":           "false",
+			},
+		},
+		{
+			desc: "upgrade with secret and inline values",
+			task: optimizev1beta2.SetupTask{
+				Name:                 "app",
+				HelmChart:            "app",
+				HelmRepository:       "https://charts.example.com",
+				HelmReleaseName:      "my-app",
+				HelmReleaseNamespace: "apps",
+				HelmUpgrade:          true,
+				HelmValuesFrom: []optimizev1beta2.HelmValuesFromSource{
+					{Secret: &optimizev1beta2.SecretHelmValuesFromSource{LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}}},
+				},
+				HelmValuesInline: `replicaCount: {{ .Values.replicas }}`,
+			},
+			mode:    setup.ModeCreate,
+			command: []string{setup.HelmCommand},
+			args:    []string{setup.ModeCreate},
+			env: map[string]string{
+				"HELM_REPOSITORY":        "https://charts.example.com",
+				"HELM_RELEASE_NAME":      "my-app",
+				"HELM_RELEASE_NAMESPACE": "apps",
+				"HELM_UPGRADE":           "true",
+				"HELM_VALUES_FILES":      "/workspace/helm-secret-values/creds/*values.yaml",
+				"HELM_VALUES":            base64.StdEncoding.EncodeToString([]byte("replicaCount: 3")),
+			},
+			volumes: []string{"helm-secret-creds"},
+		},
+		{
+			desc: "upgrade delete",
+			task: optimizev1beta2.SetupTask{
+				Name:        "app",
+				HelmChart:   "app",
+				HelmUpgrade: true,
+			},
+			mode:     setup.ModeDelete,
+			noJobFor: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tt := trial.DeepCopy()
+			tt.Spec.SetupTasks = []optimizev1beta2.SetupTask{tc.task}
+
+			if tc.noJobFor {
+				assert.False(t, setup.NeedsJob(tt, tc.mode))
+				return
+			}
+
+			j, err := setup.NewJob(tt, tc.mode)
+			if assert.NoError(t, err) && assert.Len(t, j.Spec.Template.Spec.Containers, 1) {
+				c := j.Spec.Template.Spec.Containers[0]
+				assert.Equal(t, tc.command, c.Command)
+				assert.Equal(t, tc.args, c.Args)
+
+				env := make(map[string]string, len(c.Env))
+				for _, e := range c.Env {
+					env[e.Name] = e.Value
+				}
+				for k, v := range tc.env {
+					assert.Equal(t, v, env[k], k)
+				}
+
+				var volumes []string
+				for _, v := range j.Spec.Template.Spec.Volumes {
+					volumes = append(volumes, v.Name)
+				}
+				assert.Equal(t, tc.volumes, volumes)
+			}
+		})
+	}
+}
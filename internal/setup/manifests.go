@@ -0,0 +1,269 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package setup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// FieldManager is the name used to track ownership of fields applied from setup task manifests
+const FieldManager = "optimize-controller"
+
+// NeedsJob checks to see if any of the setup tasks for the supplied mode require a setup job
+func NeedsJob(t *optimizev1beta2.Trial, mode string) bool {
+	for i := range t.Spec.SetupTasks {
+		task := &t.Spec.SetupTasks[i]
+		if task.Manifests != nil || skipTask(task, mode) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// ApplyManifests applies the manifests of all the setup tasks using server-side apply
+func ApplyManifests(ctx context.Context, c client.Client, t *optimizev1beta2.Trial) error {
+	tasks, err := OrderTasks(t.Spec.SetupTasks, ModeCreate)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		if task.Manifests == nil {
+			continue
+		}
+
+		// Record the applied objects (even on failure) so they can be pruned without rendering the manifests again
+		objs, err := applyManifests(ctx, c, t, task)
+		if err != nil {
+			ApplyTaskStatus(&t.Status, optimizev1beta2.SetupTaskStatus{Name: task.Name, Mode: ModeCreate, Phase: corev1.PodFailed, Message: err.Error(), Objects: objs})
+			return err
+		}
+		ApplyTaskStatus(&t.Status, optimizev1beta2.SetupTaskStatus{Name: task.Name, Mode: ModeCreate, Phase: corev1.PodSucceeded, Objects: objs})
+	}
+	return nil
+}
+
+// applyManifests applies the manifests of a single setup task, returning references to the applied objects
+func applyManifests(ctx context.Context, c client.Client, t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask) ([]corev1.ObjectReference, error) {
+	objs, err := RenderManifests(ctx, c, t, task)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]corev1.ObjectReference, 0, len(objs))
+	for _, obj := range objs {
+		// RBAC: We assume that we have "patch" permission from a customer defined role so we do not limit what types we can apply
+		if err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+			return refs, err
+		}
+		refs = append(refs, corev1.ObjectReference{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		})
+	}
+	return refs, nil
+}
+
+// PruneManifests deletes the objects applied from the manifests of all the setup tasks, only objects recorded in the
+// setup task status are deleted so the manifests are not rendered again
+func PruneManifests(ctx context.Context, c client.Client, t *optimizev1beta2.Trial) error {
+	tasks, err := OrderTasks(t.Spec.SetupTasks, ModeDelete)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		if task.Manifests == nil {
+			continue
+		}
+
+		if err := pruneManifests(ctx, c, t, task); err != nil {
+			ApplyTaskStatus(&t.Status, optimizev1beta2.SetupTaskStatus{Name: task.Name, Mode: ModeDelete, Phase: corev1.PodFailed, Message: err.Error()})
+			return err
+		}
+		ApplyTaskStatus(&t.Status, optimizev1beta2.SetupTaskStatus{Name: task.Name, Mode: ModeDelete, Phase: corev1.PodSucceeded})
+	}
+	return nil
+}
+
+// pruneManifests deletes the objects recorded when the manifests of a single setup task were applied
+func pruneManifests(ctx context.Context, c client.Client, t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask) error {
+	for i := range t.Status.SetupTasks {
+		ts := &t.Status.SetupTasks[i]
+		if ts.Name != task.Name || ts.Mode != ModeCreate {
+			continue
+		}
+
+		// Delete in reverse order of creation
+		for j := len(ts.Objects) - 1; j >= 0; j-- {
+			ref := &ts.Objects[j]
+
+			// RBAC: We assume that we have "delete" permission from a customer defined role
+			u := &unstructured.Unstructured{}
+			u.SetAPIVersion(ref.APIVersion)
+			u.SetKind(ref.Kind)
+			u.SetNamespace(ref.Namespace)
+			u.SetName(ref.Name)
+			if err := c.Delete(ctx, u, client.PropagationPolicy("Background")); err != nil && !apierrs.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderManifests returns the labeled objects from the manifests of the supplied setup task
+func RenderManifests(ctx context.Context, r client.Reader, t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask) ([]*unstructured.Unstructured, error) {
+	te := template.New()
+	var data []byte
+
+	if task.Manifests.Inline != "" {
+		b, err := te.RenderYAML(task.Name, task.Manifests.Inline, t)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+		data = append(data, []byte("\n---\n")...)
+	}
+
+	if task.Manifests.ConfigMap != nil {
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: t.Namespace, Name: task.Manifests.ConfigMap.Name}, cm); err != nil {
+			return nil, err
+		}
+
+		b, err := renderConfigMap(te, t, cm)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+
+	return decodeManifests(t, task, data)
+}
+
+// renderConfigMap renders each value of a config map, building the result as a kustomization if necessary
+func renderConfigMap(te *template.Engine, t *optimizev1beta2.Trial, cm *corev1.ConfigMap) ([]byte, error) {
+	// Sort the keys so the output is predictable
+	keys := make([]string, 0, len(cm.Data))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	isKustomization := false
+	fs := filesys.MakeFsInMemory()
+	var data []byte
+	for _, k := range keys {
+		b, err := te.RenderYAML(path.Join(cm.Name, k), cm.Data[k], t)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, kn := range konfig.RecognizedKustomizationFileNames() {
+			isKustomization = isKustomization || k == kn
+		}
+		if err := fs.WriteFile(path.Join("/", k), b); err != nil {
+			return nil, err
+		}
+
+		data = append(data, b...)
+		data = append(data, []byte("\n---\n")...)
+	}
+
+	if !isKustomization {
+		return data, nil
+	}
+
+	rm, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, "/")
+	if err != nil {
+		return nil, err
+	}
+	return rm.AsYaml()
+}
+
+// decodeManifests decodes a YAML document stream into a list of objects labeled for the setup task
+func decodeManifests(t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask, data []byte) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	d := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := d.Decode(&u.Object); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		// Skip empty documents
+		if len(u.Object) == 0 {
+			continue
+		}
+
+		if u.GetKind() == "" || u.GetName() == "" {
+			return nil, fmt.Errorf("invalid manifest for setup task '%s': missing kind or name", task.Name)
+		}
+
+		// Default the namespace to the trial namespace (it will be ignored for cluster scoped objects)
+		if u.GetNamespace() == "" {
+			u.SetNamespace(t.Namespace)
+		}
+
+		// Label the object so it can be pruned later
+		labels := u.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		for k, v := range manifestLabels(t, task) {
+			labels[k] = v
+		}
+		u.SetLabels(labels)
+
+		objs = append(objs, u)
+	}
+	return objs, nil
+}
+
+// manifestLabels returns the labels applied to objects created from setup task manifests
+func manifestLabels(t *optimizev1beta2.Trial, task *optimizev1beta2.SetupTask) map[string]string {
+	return map[string]string{
+		optimizev1beta2.LabelExperiment: t.ExperimentNamespacedName().Name,
+		optimizev1beta2.LabelTrial:      t.Name,
+		optimizev1beta2.LabelSetupTask:  task.Name,
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package setup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRenderManifests(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-001",
+			Namespace: "default",
+			Labels:    map[string]string{optimizev1beta2.LabelExperiment: "test"},
+		},
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments: []optimizev1beta2.Assignment{
+				{Name: "size", Value: intstr.FromInt(10)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		objs     []runtime.Object
+		task     optimizev1beta2.SetupTask
+		expected []string
+	}{
+		{
+			desc: "inline",
+			task: optimizev1beta2.SetupTask{
+				Name: "inline",
+				Manifests: &optimizev1beta2.SetupManifests{
+					Inline: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-{{ .Values.size }}
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: test
+`,
+				},
+			},
+			expected: []string{"ConfigMap default/test-10", "Namespace default/test"},
+		},
+		{
+			desc: "config map",
+			objs: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "default"},
+					Data: map[string]string{
+						"a.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: a\n  namespace: other\n",
+						"b.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: b-{{ .Values.size }}\n",
+					},
+				},
+			},
+			task: optimizev1beta2.SetupTask{
+				Name: "configmap",
+				Manifests: &optimizev1beta2.SetupManifests{
+					ConfigMap: &corev1.LocalObjectReference{Name: "manifests"},
+				},
+			},
+			expected: []string{"Service other/a", "Service default/b-10"},
+		},
+		{
+			desc: "kustomization",
+			objs: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "kustomize", Namespace: "default"},
+					Data: map[string]string{
+						"kustomization.yaml": "resources:\n- service.yaml\nnamePrefix: k-\n",
+						"service.yaml":       "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc-{{ .Values.size }}\n",
+					},
+				},
+			},
+			task: optimizev1beta2.SetupTask{
+				Name: "kustomize",
+				Manifests: &optimizev1beta2.SetupManifests{
+					ConfigMap: &corev1.LocalObjectReference{Name: "kustomize"},
+				},
+			},
+			expected: []string{"Service default/k-svc-10"},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tc.objs...)
+			objs, err := setup.RenderManifests(context.TODO(), c, trial, &tc.task)
+			if assert.NoError(t, err) {
+				var actual []string
+				for _, obj := range objs {
+					actual = append(actual, obj.GetKind()+" "+obj.GetNamespace()+"/"+obj.GetName())
+					assert.Equal(t, map[string]string{
+						optimizev1beta2.LabelExperiment: "test",
+						optimizev1beta2.LabelTrial:      "test-001",
+						optimizev1beta2.LabelSetupTask:  tc.task.Name,
+					}, obj.GetLabels())
+				}
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPruneManifests(t *testing.T) {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc-10", Namespace: "default"}}
+	other := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+
+	// The source config map no longer exists, only the recorded objects are used
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-001", Namespace: "default"},
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{Name: "manifests", Manifests: &optimizev1beta2.SetupManifests{ConfigMap: &corev1.LocalObjectReference{Name: "missing"}}},
+			},
+		},
+		Status: optimizev1beta2.TrialStatus{
+			SetupTasks: []optimizev1beta2.SetupTaskStatus{
+				{
+					Name:  "manifests",
+					Mode:  setup.ModeCreate,
+					Phase: corev1.PodSucceeded,
+					Objects: []corev1.ObjectReference{
+						{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "svc-10"},
+						{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "already-deleted"},
+					},
+				},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	c := fake.NewFakeClientWithScheme(scheme, svc, other)
+
+	ctx := context.TODO()
+	if assert.NoError(t, setup.PruneManifests(ctx, c, trial)) {
+		assert.True(t, apierrs.IsNotFound(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "svc-10"}, &corev1.Service{})))
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "other"}, &corev1.Service{}))
+		assert.Contains(t, trial.Status.SetupTasks, optimizev1beta2.SetupTaskStatus{Name: "manifests", Mode: setup.ModeDelete, Phase: corev1.PodSucceeded})
+	}
+}
+
+func TestNeedsJob(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{Name: "manifests", Manifests: &optimizev1beta2.SetupManifests{Inline: "{}"}},
+				{Name: "container", SkipCreate: true},
+			},
+		},
+	}
+
+	assert.False(t, setup.NeedsJob(trial, setup.ModeCreate))
+	assert.True(t, setup.NeedsJob(trial, setup.ModeDelete))
+}
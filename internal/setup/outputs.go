@@ -0,0 +1,214 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package setup
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetTaskOutputs returns the outputs published in the termination messages of the successful containers of a
+// setup create job. Each line of the termination message of the form "key=value" is treated as an output.
+func GetTaskOutputs(j *batchv1.Job, pods []corev1.Pod) map[string]string {
+	if ct, err := GetTrialConditionType(j); err != nil || ct != optimizev1beta2.TrialSetupCreated {
+		return nil
+	}
+
+	outputs := make(map[string]string)
+	for i := range pods {
+		var containerStatuses []corev1.ContainerStatus
+		containerStatuses = append(containerStatuses, pods[i].Status.InitContainerStatuses...)
+		containerStatuses = append(containerStatuses, pods[i].Status.ContainerStatuses...)
+		for _, cs := range containerStatuses {
+			if cs.State.Terminated == nil || cs.State.Terminated.ExitCode != 0 {
+				continue
+			}
+
+			s := bufio.NewScanner(strings.NewReader(cs.State.Terminated.Message))
+			for s.Scan() {
+				if kv := strings.SplitN(s.Text(), "=", 2); len(kv) == 2 && strings.TrimSpace(kv[0]) != "" {
+					outputs[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+				}
+			}
+		}
+	}
+	return outputs
+}
+
+// ReadOutputs returns the outputs from the config maps of the setup tasks
+func ReadOutputs(ctx context.Context, r client.Reader, t *optimizev1beta2.Trial) (map[string]string, error) {
+	tasks, err := OrderTasks(t.Spec.SetupTasks, ModeCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]string)
+	for i := range tasks {
+		if tasks[i].OutputsConfigMap == nil {
+			continue
+		}
+
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: t.Namespace, Name: tasks[i].OutputsConfigMap.Name}, cm); err != nil {
+			return nil, err
+		}
+		for k, v := range cm.Data {
+			outputs[k] = v
+		}
+	}
+	return outputs, nil
+}
+
+// ReadSecretOutputs returns references to the outputs from the secrets of the setup tasks, the secret values are not read
+func ReadSecretOutputs(ctx context.Context, r client.Reader, t *optimizev1beta2.Trial) (map[string]corev1.SecretKeySelector, error) {
+	tasks, err := OrderTasks(t.Spec.SetupTasks, ModeCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]corev1.SecretKeySelector)
+	for i := range tasks {
+		if tasks[i].OutputsSecret == nil {
+			continue
+		}
+
+		// RBAC: We assume that we have "get" permission on the outputs secret from a customer defined role
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: t.Namespace, Name: tasks[i].OutputsSecret.Name}, secret); err != nil {
+			return nil, err
+		}
+		for k := range secret.Data {
+			refs[k] = corev1.SecretKeySelector{LocalObjectReference: *tasks[i].OutputsSecret, Key: k}
+		}
+	}
+	return refs, nil
+}
+
+// ApplyOutputs merges the supplied outputs into the trial status, returning true if the status changed
+func ApplyOutputs(status *optimizev1beta2.TrialStatus, outputs map[string]string) bool {
+	var dirty bool
+	for k, v := range outputs {
+		if cur, ok := status.SetupOutputs[k]; ok && cur == v {
+			continue
+		}
+		if status.SetupOutputs == nil {
+			status.SetupOutputs = make(map[string]string, len(outputs))
+		}
+		status.SetupOutputs[k] = v
+		dirty = true
+	}
+	return dirty
+}
+
+// ApplySecretOutputs merges the supplied secret output references into the trial status, returning true if the
+// status changed
+func ApplySecretOutputs(status *optimizev1beta2.TrialStatus, refs map[string]corev1.SecretKeySelector) bool {
+	var dirty bool
+	for k, v := range refs {
+		if cur, ok := status.SetupSecretOutputs[k]; ok && cur == v {
+			continue
+		}
+		if status.SetupSecretOutputs == nil {
+			status.SetupSecretOutputs = make(map[string]corev1.SecretKeySelector, len(refs))
+		}
+		status.SetupSecretOutputs[k] = v
+		dirty = true
+	}
+	return dirty
+}
+
+// CheckOutputsEnv verifies the setup task outputs can be exposed to the containers of the trial run job without
+// replacing any of the existing environment variables.
+func CheckOutputsEnv(t *optimizev1beta2.Trial) error {
+	var containers []corev1.Container
+	if t.Spec.JobTemplate != nil {
+		containers = t.Spec.JobTemplate.Spec.Template.Spec.Containers
+	}
+
+	for i := range containers {
+		env := AppendAssignmentEnv(t, containers[i].Env)
+		env = AppendPrometheusEnv(t, env)
+
+		names := make(map[string]bool, len(env))
+		for _, e := range env {
+			names[e.Name] = true
+		}
+
+		for _, k := range outputKeys(t) {
+			name := outputEnvName(k)
+			if names[name] {
+				return fmt.Errorf("setup output %q conflicts with the environment variable %s", k, name)
+			}
+			names[name] = true
+		}
+	}
+	return nil
+}
+
+// AppendOutputsEnv appends an environment variable for each setup task output, outputs which would replace an
+// existing environment variable are skipped (see `CheckOutputsEnv`)
+func AppendOutputsEnv(t *optimizev1beta2.Trial, env []corev1.EnvVar) []corev1.EnvVar {
+	names := make(map[string]bool, len(env))
+	for _, e := range env {
+		names[e.Name] = true
+	}
+
+	for _, k := range outputKeys(t) {
+		name := outputEnvName(k)
+		if names[name] {
+			continue
+		}
+		names[name] = true
+
+		if ref, ok := t.Status.SetupSecretOutputs[k]; ok {
+			env = append(env, corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ref.DeepCopy()}})
+		} else {
+			env = append(env, corev1.EnvVar{Name: name, Value: t.Status.SetupOutputs[k]})
+		}
+	}
+
+	return env
+}
+
+// outputKeys returns the sorted keys of all the setup task outputs so the environment is predictable
+func outputKeys(t *optimizev1beta2.Trial) []string {
+	keys := make([]string, 0, len(t.Status.SetupOutputs)+len(t.Status.SetupSecretOutputs))
+	for k := range t.Status.SetupOutputs {
+		keys = append(keys, k)
+	}
+	for k := range t.Status.SetupSecretOutputs {
+		if _, ok := t.Status.SetupOutputs[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// outputEnvName returns the environment variable name for a setup task output key
+func outputEnvName(key string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(key))
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package setup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetTaskOutputs(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{{Name: "db"}},
+		},
+	}
+
+	terminated := func(exitCode int32, message string) corev1.Pod {
+		return corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{
+				Name:  "test-create-db",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Message: message}},
+			},
+		}}}
+	}
+
+	testCases := []struct {
+		desc     string
+		mode     string
+		pods     []corev1.Pod
+		expected map[string]string
+	}{
+		{
+			desc:     "no pods",
+			mode:     setup.ModeCreate,
+			expected: map[string]string{},
+		},
+		{
+			desc:     "termination message",
+			mode:     setup.ModeCreate,
+			pods:     []corev1.Pod{terminated(0, "url=postgres://db:5432\nignored line\n password = s3cr=t \n")},
+			expected: map[string]string{"url": "postgres://db:5432", "password": "s3cr=t"},
+		},
+		{
+			desc:     "failed container",
+			mode:     setup.ModeCreate,
+			pods:     []corev1.Pod{terminated(1, "url=postgres://db:5432")},
+			expected: map[string]string{},
+		},
+		{
+			desc: "delete job",
+			mode: setup.ModeDelete,
+			pods: []corev1.Pod{terminated(0, "url=postgres://db:5432")},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			j, err := setup.NewJob(trial, tc.mode)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, setup.GetTaskOutputs(j, tc.pods))
+			}
+		})
+	}
+}
+
+func TestReadOutputs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{Name: "db", OutputsConfigMap: &corev1.LocalObjectReference{Name: "db-outputs"}},
+				{Name: "app"},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-outputs", Namespace: "default"},
+		Data:       map[string]string{"url": "postgres://db:5432"},
+	})
+
+	outputs, err := setup.ReadOutputs(context.TODO(), c, trial)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"url": "postgres://db:5432"}, outputs)
+	}
+
+	assert.True(t, setup.ApplyOutputs(&trial.Status, outputs))
+	assert.False(t, setup.ApplyOutputs(&trial.Status, outputs))
+	assert.Equal(t, []corev1.EnvVar{{Name: "URL", Value: "postgres://db:5432"}}, setup.AppendOutputsEnv(trial, nil))
+
+	trial.Spec.SetupTasks[1].OutputsConfigMap = &corev1.LocalObjectReference{Name: "missing"}
+	_, err = setup.ReadOutputs(context.TODO(), c, trial)
+	assert.Error(t, err)
+}
+
+func TestSecretOutputs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{Name: "db", OutputsSecret: &corev1.LocalObjectReference{Name: "db-credentials"}},
+			},
+			JobTemplate: &batchv1beta1.JobTemplateSpec{},
+		},
+		Status: optimizev1beta2.TrialStatus{SetupOutputs: map[string]string{"url": "postgres://db:5432"}},
+	}
+	trial.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "load", Env: []corev1.EnvVar{{Name: "USER", Value: "load"}}}}
+
+	c := fake.NewFakeClientWithScheme(scheme, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	})
+
+	refs, err := setup.ReadSecretOutputs(context.TODO(), c, trial)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	passwordRef := corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"}, Key: "password"}
+	assert.Equal(t, map[string]corev1.SecretKeySelector{"password": passwordRef}, refs)
+	assert.True(t, setup.ApplySecretOutputs(&trial.Status, refs))
+	assert.False(t, setup.ApplySecretOutputs(&trial.Status, refs))
+	assert.NotContains(t, trial.Status.SetupOutputs, "password")
+
+	assert.NoError(t, setup.CheckOutputsEnv(trial))
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "USER", Value: "load"},
+		{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &passwordRef}},
+		{Name: "URL", Value: "postgres://db:5432"},
+	}, setup.AppendOutputsEnv(trial, trial.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env))
+
+	// Outputs must not replace the existing environment
+	trial.Status.SetupOutputs["user"] = "admin"
+	assert.EqualError(t, setup.CheckOutputsEnv(trial), `setup output "user" conflicts with the environment variable USER`)
+	assert.Equal(t, "load", setup.AppendOutputsEnv(trial, trial.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env)[0].Value)
+}
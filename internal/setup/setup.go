@@ -41,9 +41,9 @@ const (
 // UpdateStatus returns true if there are setup tasks
 func UpdateStatus(t *optimizev1beta2.Trial, probeTime *metav1.Time) bool {
 	var needsCreate, needsDelete bool
-	for _, task := range t.Spec.SetupTasks {
-		needsCreate = needsCreate || !task.SkipCreate
-		needsDelete = needsDelete || !task.SkipDelete
+	for i := range t.Spec.SetupTasks {
+		needsCreate = needsCreate || !skipTask(&t.Spec.SetupTasks[i], ModeCreate)
+		needsDelete = needsDelete || !skipTask(&t.Spec.SetupTasks[i], ModeDelete)
 	}
 
 	// Short circuit, there are no setup tasks
@@ -0,0 +1,185 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package setup
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// OrderTasks returns the setup tasks for the supplied mode in the order they must be executed. The tasks are sorted
+// so dependencies come first when creating and last when deleting; tasks without dependencies retain their original
+// relative order. Tasks which are skipped for the mode are omitted.
+func OrderTasks(tasks []optimizev1beta2.SetupTask, mode string) ([]optimizev1beta2.SetupTask, error) {
+	// Index the tasks which are relevant for this mode
+	index := make(map[string]int, len(tasks))
+	for i := range tasks {
+		if _, ok := index[tasks[i].Name]; ok {
+			return nil, fmt.Errorf("duplicate setup task name '%s'", tasks[i].Name)
+		}
+		index[tasks[i].Name] = i
+	}
+
+	// Verify the dependencies
+	for i := range tasks {
+		for _, d := range tasks[i].DependsOn {
+			j, ok := index[d]
+			if !ok {
+				return nil, fmt.Errorf("setup task '%s' depends on unknown task '%s'", tasks[i].Name, d)
+			}
+			if tasks[i].Manifests != nil && tasks[j].Manifests == nil {
+				return nil, fmt.Errorf("setup task '%s' with manifests cannot depend on task '%s'", tasks[i].Name, d)
+			}
+		}
+	}
+
+	// Repeatedly take the first task whose dependencies have all been taken
+	var ordered []optimizev1beta2.SetupTask
+	done := make(map[string]bool, len(tasks))
+	for len(done) < len(tasks) {
+		next := -1
+		for i := range tasks {
+			if done[tasks[i].Name] {
+				continue
+			}
+
+			ready := true
+			for _, d := range tasks[i].DependsOn {
+				ready = ready && done[d]
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			var remaining []string
+			for i := range tasks {
+				if !done[tasks[i].Name] {
+					remaining = append(remaining, tasks[i].Name)
+				}
+			}
+			return nil, fmt.Errorf("setup tasks have a circular dependency: %s", strings.Join(remaining, ", "))
+		}
+
+		done[tasks[next].Name] = true
+		if skipTask(&tasks[next], mode) {
+			continue
+		}
+		ordered = append(ordered, tasks[next])
+	}
+
+	// Deletion happens in the reverse order
+	if mode == ModeDelete {
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	}
+
+	return ordered, nil
+}
+
+// skipTask checks to see if the supplied task should be skipped for the specified mode
+func skipTask(task *optimizev1beta2.SetupTask, mode string) bool {
+	switch mode {
+	case ModeCreate:
+		return task.SkipCreate
+	case ModeDelete:
+		// Helm releases upgraded in place are never deleted
+		return task.SkipDelete || task.HelmUpgrade
+	default:
+		return false
+	}
+}
+
+// HasDependencies checks to see if any of the setup tasks depend on another task
+func HasDependencies(tasks []optimizev1beta2.SetupTask) bool {
+	for i := range tasks {
+		if len(tasks[i].DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTaskStatus updates the status of an individual setup task, returning true if the status changed
+func ApplyTaskStatus(status *optimizev1beta2.TrialStatus, ts optimizev1beta2.SetupTaskStatus) bool {
+	for i := range status.SetupTasks {
+		if status.SetupTasks[i].Name == ts.Name && status.SetupTasks[i].Mode == ts.Mode {
+			if reflect.DeepEqual(status.SetupTasks[i], ts) {
+				return false
+			}
+			status.SetupTasks[i] = ts
+			return true
+		}
+	}
+
+	status.SetupTasks = append(status.SetupTasks, ts)
+	return true
+}
+
+// GetTaskStatuses returns the status of the individual setup tasks run by a setup job
+func GetTaskStatuses(j *batchv1.Job, pods []corev1.Pod) []optimizev1beta2.SetupTaskStatus {
+	mode := ModeCreate
+	if ct, err := GetTrialConditionType(j); err == nil && ct == optimizev1beta2.TrialSetupDeleted {
+		mode = ModeDelete
+	}
+
+	var statuses []optimizev1beta2.SetupTaskStatus
+	var containers []corev1.Container
+	containers = append(containers, j.Spec.Template.Spec.InitContainers...)
+	containers = append(containers, j.Spec.Template.Spec.Containers...)
+	for _, c := range containers {
+		ts := optimizev1beta2.SetupTaskStatus{
+			Name:  strings.TrimPrefix(c.Name, j.Name+"-"),
+			Mode:  mode,
+			Phase: corev1.PodPending,
+		}
+
+		for i := range pods {
+			var containerStatuses []corev1.ContainerStatus
+			containerStatuses = append(containerStatuses, pods[i].Status.InitContainerStatuses...)
+			containerStatuses = append(containerStatuses, pods[i].Status.ContainerStatuses...)
+			for _, cs := range containerStatuses {
+				if cs.Name != c.Name {
+					continue
+				}
+
+				switch {
+				case cs.State.Terminated != nil && cs.State.Terminated.ExitCode == 0:
+					ts.Phase, ts.Message = corev1.PodSucceeded, ""
+				case cs.State.Terminated != nil:
+					ts.Phase, ts.Message = corev1.PodFailed, cs.State.Terminated.Message
+					if ts.Message == "" {
+						ts.Message = fmt.Sprintf("Exited with code %d", cs.State.Terminated.ExitCode)
+					}
+				case cs.State.Running != nil && ts.Phase != corev1.PodSucceeded:
+					ts.Phase = corev1.PodRunning
+				}
+			}
+		}
+
+		statuses = append(statuses, ts)
+	}
+	return statuses
+}
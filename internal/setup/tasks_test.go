@@ -0,0 +1,148 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package setup_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOrderTasks(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		tasks    []optimizev1beta2.SetupTask
+		mode     string
+		expected []string
+		err      string
+	}{
+		{
+			desc: "no dependencies",
+			tasks: []optimizev1beta2.SetupTask{
+				{Name: "a"},
+				{Name: "b"},
+			},
+			mode:     setup.ModeCreate,
+			expected: []string{"a", "b"},
+		},
+		{
+			desc: "dependencies",
+			tasks: []optimizev1beta2.SetupTask{
+				{Name: "seed", DependsOn: []string{"migrate"}},
+				{Name: "migrate", DependsOn: []string{"database"}},
+				{Name: "database"},
+			},
+			mode:     setup.ModeCreate,
+			expected: []string{"database", "migrate", "seed"},
+		},
+		{
+			desc: "dependencies delete",
+			tasks: []optimizev1beta2.SetupTask{
+				{Name: "seed", DependsOn: []string{"migrate"}, SkipDelete: true},
+				{Name: "migrate", DependsOn: []string{"database"}},
+				{Name: "database"},
+			},
+			mode:     setup.ModeDelete,
+			expected: []string{"migrate", "database"},
+		},
+		{
+			desc: "unknown dependency",
+			tasks: []optimizev1beta2.SetupTask{
+				{Name: "a", DependsOn: []string{"b"}},
+			},
+			mode: setup.ModeCreate,
+			err:  "setup task 'a' depends on unknown task 'b'",
+		},
+		{
+			desc: "circular dependency",
+			tasks: []optimizev1beta2.SetupTask{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c"},
+			},
+			mode: setup.ModeCreate,
+			err:  "setup tasks have a circular dependency: a, b",
+		},
+		{
+			desc: "manifests dependency",
+			tasks: []optimizev1beta2.SetupTask{
+				{Name: "a", Manifests: &optimizev1beta2.SetupManifests{}, DependsOn: []string{"b"}},
+				{Name: "b"},
+			},
+			mode: setup.ModeCreate,
+			err:  "setup task 'a' with manifests cannot depend on task 'b'",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tasks, err := setup.OrderTasks(tc.tasks, tc.mode)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				var actual []string
+				for _, task := range tasks {
+					actual = append(actual, task.Name)
+				}
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNewJob_Dependencies(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{Name: "seed", DependsOn: []string{"migrate"}},
+				{Name: "migrate", DependsOn: []string{"database"}},
+				{Name: "database"},
+			},
+		},
+	}
+
+	job, err := setup.NewJob(trial, setup.ModeCreate)
+	if assert.NoError(t, err) {
+		if assert.Len(t, job.Spec.Template.Spec.InitContainers, 2) {
+			assert.Equal(t, "test-create-database", job.Spec.Template.Spec.InitContainers[0].Name)
+			assert.Equal(t, "test-create-migrate", job.Spec.Template.Spec.InitContainers[1].Name)
+		}
+		if assert.Len(t, job.Spec.Template.Spec.Containers, 1) {
+			assert.Equal(t, "test-create-seed", job.Spec.Template.Spec.Containers[0].Name)
+		}
+
+		statuses := setup.GetTaskStatuses(job, []corev1.Pod{{
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "test-create-database", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+					{Name: "test-create-migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+				},
+			},
+		}})
+		assert.Equal(t, []optimizev1beta2.SetupTaskStatus{
+			{Name: "database", Mode: setup.ModeCreate, Phase: corev1.PodSucceeded},
+			{Name: "migrate", Mode: setup.ModeCreate, Phase: corev1.PodFailed, Message: "Exited with code 1"},
+			{Name: "seed", Mode: setup.ModeCreate, Phase: corev1.PodPending},
+		}, statuses)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfigMapKey is the binary data key used to store a snapshot in a config map
+	ConfigMapKey = "snapshot.json.gz"
+
+	// maxConfigMapSize is the largest snapshot that can be stored in a config map
+	maxConfigMapSize = 1000 * 1024
+)
+
+// Sink stores encoded snapshots
+type Sink interface {
+	// Save stores the snapshot for a trial and returns a URL describing where it was saved
+	Save(ctx context.Context, t *optimizev1beta2.Trial, data []byte) (string, error)
+}
+
+// NewSink returns the sink described by the supplied URL: an empty value or "configmap:" stores snapshots in a config
+// map in the experiment namespace and "none" disables snapshots (a nil sink is returned)
+func NewSink(sinkURL string, c client.Client) (Sink, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case sinkURL == "none":
+		return nil, nil
+	case sinkURL == "" || u.Scheme == "configmap":
+		return &ConfigMapSink{Client: c}, nil
+	default:
+		return nil, fmt.Errorf("unsupported snapshot sink %q", sinkURL)
+	}
+}
+
+// ConfigMapSink stores snapshots in a config map in the experiment namespace, the config map is deleted with the experiment
+type ConfigMapSink struct {
+	Client client.Client
+}
+
+// Save creates or replaces the snapshot config map for the trial
+func (s *ConfigMapSink) Save(ctx context.Context, t *optimizev1beta2.Trial, data []byte) (string, error) {
+	if len(data) > maxConfigMapSize {
+		return "", fmt.Errorf("snapshot of %d bytes is too large for a config map", len(data))
+	}
+
+	// Snapshots are always stored in the experiment namespace (even if the trial runs in another namespace) so they
+	// can be owned by the experiment and are retained after the trial is cleaned up
+	cm := &corev1.ConfigMap{}
+	cm.Namespace = t.ExperimentNamespacedName().Namespace
+	cm.Name = t.Name + "-snapshot"
+
+	create := false
+	if err := s.Client.Get(ctx, types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}, cm); apierrs.IsNotFound(err) {
+		create = true
+	} else if err != nil {
+		return "", err
+	}
+
+	exp := &optimizev1beta2.Experiment{}
+	if err := s.Client.Get(ctx, t.ExperimentNamespacedName(), exp); err != nil && !apierrs.IsNotFound(err) {
+		return "", err
+	} else if err == nil {
+		cm.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(exp, optimizev1beta2.GroupVersion.WithKind("Experiment"))}
+	}
+	cm.Labels = map[string]string{
+		optimizev1beta2.LabelExperiment: t.ExperimentNamespacedName().Name,
+		optimizev1beta2.LabelTrial:      t.Name,
+	}
+	cm.BinaryData = map[string][]byte{ConfigMapKey: data}
+
+	var err error
+	if create {
+		err = s.Client.Create(ctx, cm)
+	} else {
+		err = s.Client.Update(ctx, cm)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return ConfigMapLocation(cm.Namespace, cm.Name), nil
+}
+
+// ConfigMapLocation returns the location of a snapshot stored in a config map
+func ConfigMapLocation(namespace, name string) string {
+	return "configmap:" + namespace + "/" + name
+}
+
+// ParseConfigMapLocation returns the namespace and name of the config map from a snapshot location, the namespace is
+// empty if the location does not include one
+func ParseConfigMapLocation(location string) (namespace, name string, ok bool) {
+	ref := strings.TrimPrefix(location, "configmap:")
+	if ref == location || ref == "" {
+		return "", "", false
+	}
+	if pos := strings.Index(ref, "/"); pos >= 0 {
+		return ref[:pos], ref[pos+1:], true
+	}
+	return "", ref, true
+}
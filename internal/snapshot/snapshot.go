@@ -0,0 +1,170 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Snapshot is a record of the state applied to the cluster for a trial
+type Snapshot struct {
+	// Trial is the namespaced name of the trial
+	Trial string `json:"trial"`
+	// Assignments are the trial parameter assignments
+	Assignments []optimizev1beta2.Assignment `json:"assignments,omitempty"`
+	// Patches are the rendered patch operations
+	Patches []optimizev1beta2.PatchOperation `json:"patches,omitempty"`
+	// Manifests are the patch targets as they existed after the patches were applied
+	Manifests []*unstructured.Unstructured `json:"manifests,omitempty"`
+	// HelmValues are the resolved Helm values of the setup tasks, keyed by task name
+	HelmValues map[string]*setup.ResolvedHelmValues `json:"helmValues,omitempty"`
+}
+
+// Capture records the patches, post-patch target manifests and setup task Helm values of a trial
+func Capture(ctx context.Context, r client.Reader, t *optimizev1beta2.Trial) (*Snapshot, error) {
+	s := &Snapshot{
+		Trial:       t.Namespace + "/" + t.Name,
+		Assignments: t.Spec.Assignments,
+	}
+
+	for i := range t.Status.PatchOperations {
+		s.Patches = append(s.Patches, redactPatch(t.Status.PatchOperations[i]))
+	}
+
+	seen := make(map[corev1.ObjectReference]bool, len(t.Status.PatchOperations))
+	for i := range t.Status.PatchOperations {
+		ref := t.Status.PatchOperations[i].TargetRef
+		key := corev1.ObjectReference{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(ref.GroupVersionKind())
+		if err := r.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, u); err != nil {
+			return nil, err
+		}
+		s.Manifests = append(s.Manifests, sanitize(u))
+	}
+
+	for i := range t.Spec.SetupTasks {
+		task := &t.Spec.SetupTasks[i]
+		if task.HelmChart == "" {
+			continue
+		}
+
+		rhv, err := setup.HelmValues(t, task)
+		if err != nil {
+			return nil, err
+		}
+		if s.HelmValues == nil {
+			s.HelmValues = make(map[string]*setup.ResolvedHelmValues)
+		}
+		s.HelmValues[task.Name] = rhv
+	}
+
+	return s, nil
+}
+
+// sanitize removes server populated fields so the manifest can be applied again; secret values are not retained
+func sanitize(u *unstructured.Unstructured) *unstructured.Unstructured {
+	for _, f := range []string{"managedFields", "resourceVersion", "uid", "selfLink", "creationTimestamp", "generation"} {
+		unstructured.RemoveNestedField(u.Object, "metadata", f)
+	}
+	unstructured.RemoveNestedField(u.Object, "status")
+
+	if u.GetAPIVersion() == "v1" && u.GetKind() == "Secret" {
+		redactSecretData(u.Object)
+	}
+
+	return u
+}
+
+// redactPatch removes the secret values from a patch of a secret
+func redactPatch(po optimizev1beta2.PatchOperation) optimizev1beta2.PatchOperation {
+	if po.TargetRef.APIVersion != "v1" || po.TargetRef.Kind != "Secret" {
+		return po
+	}
+
+	// Only merge patches can be redacted without losing their structure, drop anything else entirely
+	patch := make(map[string]interface{})
+	if po.PatchType == types.JSONPatchType || json.Unmarshal(po.Data, &patch) != nil {
+		po.Data = nil
+		return po
+	}
+
+	redactSecretData(patch)
+	po.Data, _ = json.Marshal(patch)
+	return po
+}
+
+// redactSecretData replaces the values of a secret (or secret patch) with empty strings
+func redactSecretData(obj map[string]interface{}) {
+	for _, f := range []string{"data", "stringData"} {
+		if m, ok, _ := unstructured.NestedMap(obj, f); ok {
+			for k := range m {
+				m[k] = ""
+			}
+			_ = unstructured.SetNestedMap(obj, m, f)
+		}
+	}
+}
+
+// Encode returns the compressed JSON representation of a snapshot
+func Encode(s *Snapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(s); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode reads a snapshot from its compressed JSON representation
+func Decode(data []byte) (*Snapshot, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCapture(t *testing.T) {
+	replicas := int32(3)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "deployment-uid", ResourceVersion: "42"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: 3},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	c := fake.NewFakeClientWithScheme(clientgoscheme.Scheme, deployment, secret)
+
+	deploymentRef := corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "app"}
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "trial-1", Namespace: "default"},
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments: []optimizev1beta2.Assignment{{Name: "replicas", Value: intstr.FromInt(3)}},
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{
+					Name:      "chart",
+					HelmChart: "example/chart",
+					HelmValues: []optimizev1beta2.HelmValue{
+						{Name: "replicaCount", ValueFrom: &optimizev1beta2.HelmValueSource{ParameterRef: &optimizev1beta2.ParameterSelector{Name: "replicas"}}},
+					},
+					HelmValuesFrom: []optimizev1beta2.HelmValuesFromSource{
+						{ConfigMap: &optimizev1beta2.ConfigMapHelmValuesFromSource{LocalObjectReference: corev1.LocalObjectReference{Name: "values"}}},
+					},
+				},
+				{Name: "other"},
+			},
+		},
+		Status: optimizev1beta2.TrialStatus{
+			PatchOperations: []optimizev1beta2.PatchOperation{
+				{TargetRef: deploymentRef, PatchType: types.StrategicMergePatchType, Data: []byte(`{"spec":{"replicas":3}}`)},
+				{TargetRef: deploymentRef, PatchType: types.MergePatchType, Data: []byte(`{"metadata":{"labels":{"a":"b"}}}`)},
+				{TargetRef: corev1.ObjectReference{APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "creds"}, PatchType: types.MergePatchType, Data: []byte(`{"stringData":{"password":"hunter3"}}`)},
+			},
+		},
+	}
+
+	s, err := Capture(context.TODO(), c, tr)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "default/trial-1", s.Trial)
+	if assert.Len(t, s.Patches, 3) {
+		assert.JSONEq(t, `{"stringData":{"password":""}}`, string(s.Patches[2].Data))
+		assert.Contains(t, string(tr.Status.PatchOperations[2].Data), "hunter3")
+	}
+	if assert.Len(t, s.Manifests, 2) {
+		assert.Equal(t, "app", s.Manifests[0].GetName())
+		assert.Empty(t, s.Manifests[0].GetResourceVersion())
+		assert.Empty(t, s.Manifests[0].GetUID())
+		assert.NotContains(t, s.Manifests[0].Object, "status")
+
+		assert.Equal(t, map[string]interface{}{"password": ""}, s.Manifests[1].Object["data"])
+	}
+	if assert.Contains(t, s.HelmValues, "chart") {
+		assert.Equal(t, map[string]interface{}{"replicaCount": int32(3)}, s.HelmValues["chart"].Set)
+		assert.Equal(t, []string{"configMap/values"}, s.HelmValues["chart"].From)
+	}
+	assert.NotContains(t, s.HelmValues, "other")
+
+	// Make sure the snapshot survives encoding
+	data, err := Encode(s)
+	if assert.NoError(t, err) {
+		ds, err := Decode(data)
+		if assert.NoError(t, err) {
+			assert.Equal(t, s.Trial, ds.Trial)
+			assert.Equal(t, s.Patches, ds.Patches)
+			if assert.Len(t, ds.Manifests, 2) {
+				assert.Equal(t, s.Manifests[0].GetName(), ds.Manifests[0].GetName())
+				assert.Equal(t, s.Manifests[0].GetKind(), ds.Manifests[0].GetKind())
+			}
+		}
+	}
+}
+
+func TestSink(t *testing.T) {
+	ctx := context.TODO()
+	tr := &optimizev1beta2.Trial{ObjectMeta: metav1.ObjectMeta{Name: "trial-1", Namespace: "default", Labels: map[string]string{optimizev1beta2.LabelExperiment: "exp"}}}
+	data := []byte("snapshot")
+
+	t.Run("configmap", func(t *testing.T) {
+		cases := []struct {
+			desc     string
+			trial    *optimizev1beta2.Trial
+			location string
+		}{
+			{
+				desc:     "experiment namespace",
+				trial:    tr,
+				location: "configmap:default/trial-1-snapshot",
+			},
+			{
+				desc: "other namespace",
+				trial: &optimizev1beta2.Trial{
+					ObjectMeta: metav1.ObjectMeta{Name: "trial-2", Namespace: "other", Labels: map[string]string{optimizev1beta2.LabelExperiment: "exp"}},
+					Spec:       optimizev1beta2.TrialSpec{ExperimentRef: &corev1.ObjectReference{Namespace: "default", Name: "exp"}},
+				},
+				location: "configmap:default/trial-2-snapshot",
+			},
+		}
+		for _, c := range cases {
+			t.Run(c.desc, func(t *testing.T) {
+				scheme := runtime.NewScheme()
+				_ = clientgoscheme.AddToScheme(scheme)
+				_ = optimizev1beta2.AddToScheme(scheme)
+				exp := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", UID: "experiment-uid"}}
+				cl := fake.NewFakeClientWithScheme(scheme, exp)
+				sink, err := NewSink("", cl)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				// Saving twice should update the existing config map
+				for i := 0; i < 2; i++ {
+					loc, err := sink.Save(ctx, c.trial, data)
+					if assert.NoError(t, err) {
+						assert.Equal(t, c.location, loc)
+					}
+				}
+
+				namespace, name, ok := ParseConfigMapLocation(c.location)
+				assert.True(t, ok)
+				assert.Equal(t, "default", namespace)
+
+				cm := &corev1.ConfigMap{}
+				if assert.NoError(t, cl.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cm)) {
+					assert.Equal(t, data, cm.BinaryData[ConfigMapKey])
+					assert.Equal(t, c.trial.Name, cm.Labels[optimizev1beta2.LabelTrial])
+					if assert.Len(t, cm.OwnerReferences, 1) {
+						assert.Equal(t, exp.UID, cm.OwnerReferences[0].UID)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		sink, err := NewSink("none", nil)
+		assert.NoError(t, err)
+		assert.Nil(t, sink)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewSink("s3://bucket", nil)
+		assert.Error(t, err)
+		_, err = NewSink("file:///tmp/snapshots", nil)
+		assert.Error(t, err)
+	})
+}
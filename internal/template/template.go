@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -36,6 +38,8 @@ type PatchData struct {
 	Trial metav1.ObjectMeta
 	// Trial assignments
 	Values map[string]interface{}
+	// Setup task outputs
+	Outputs map[string]string
 }
 
 // MetricData represents a trial during metric evaluation
@@ -53,6 +57,8 @@ type MetricData struct {
 	Range string
 	// Trial assignments
 	Values map[string]interface{}
+	// Setup task outputs
+	Outputs map[string]string
 }
 
 // Pods returns the metric target if available.
@@ -61,6 +67,20 @@ func (m *MetricData) Pods() runtime.Object {
 	return m.Target
 }
 
+// assignmentValue returns the template value of a trial assignment. Floating point values are rendered from the string
+// formatted using the parameter precision (never exponent notation) instead of a float64 which renders as "1e-05".
+func assignmentValue(a *optimizev1beta2.Assignment) interface{} {
+	if a.Value.Type == intstr.Int {
+		return a.Value.IntVal
+	}
+	if a.Float {
+		if f, err := strconv.ParseFloat(a.Value.StrVal, 64); err == nil && strings.ContainsAny(a.Value.StrVal, "eE") {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return a.Value.StrVal
+}
+
 func newPatchData(t *optimizev1beta2.Trial) *PatchData {
 	d := &PatchData{}
 
@@ -68,11 +88,12 @@ func newPatchData(t *optimizev1beta2.Trial) *PatchData {
 
 	d.Values = make(map[string]interface{}, len(t.Spec.Assignments))
 	for _, a := range t.Spec.Assignments {
-		if a.Value.Type == intstr.String {
-			d.Values[a.Name] = a.Value.StrVal
-		} else {
-			d.Values[a.Name] = a.Value.IntVal
-		}
+		d.Values[a.Name] = assignmentValue(&a)
+	}
+
+	d.Outputs = make(map[string]string, len(t.Status.SetupOutputs))
+	for k, v := range t.Status.SetupOutputs {
+		d.Outputs[k] = v
 	}
 
 	return d
@@ -86,11 +107,12 @@ func newMetricData(t *optimizev1beta2.Trial, target runtime.Object) *MetricData
 
 	d.Values = make(map[string]interface{}, len(t.Spec.Assignments))
 	for _, a := range t.Spec.Assignments {
-		if a.Value.Type == intstr.String {
-			d.Values[a.Name] = a.Value.StrVal
-		} else {
-			d.Values[a.Name] = a.Value.IntVal
-		}
+		d.Values[a.Name] = assignmentValue(&a)
+	}
+
+	d.Outputs = make(map[string]string, len(t.Status.SetupOutputs))
+	for k, v := range t.Status.SetupOutputs {
+		d.Outputs[k] = v
 	}
 
 	if t.Status.StartTime != nil {
@@ -142,6 +164,16 @@ func (e *Engine) RenderHelmValue(helmValue *optimizev1beta2.HelmValue, trial *op
 	return b.String(), nil
 }
 
+// RenderYAML returns the rendered YAML of a setup task template (e.g. manifests or inline Helm values)
+func (e *Engine) RenderYAML(name, text string, trial *optimizev1beta2.Trial) ([]byte, error) {
+	data := newPatchData(trial)
+	b, err := e.render(name, text, data)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
 // RenderMetricQueries returns the metric query and the metric error query
 func (e *Engine) RenderMetricQueries(metric *optimizev1beta2.Metric, trial *optimizev1beta2.Trial, target runtime.Object) (string, string, error) {
 	data := newMetricData(trial, target)
@@ -69,6 +69,62 @@ func TestEngine_RenderPatch(t *testing.T) {
 			},
 			expected: []byte(`{"spec":{"replicas":2}}`),
 		},
+
+		{
+			desc: "float assignment",
+			patchTemplate: optimizev1beta2.PatchTemplate{
+				Patch: "spec:\n  ratio: {{ .Values.ratio }}\n",
+			},
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{
+						{
+							Name:  "ratio",
+							Value: intstr.FromString("0.25"),
+							Float: true,
+						},
+					},
+				},
+			},
+			expected: []byte(`{"spec":{"ratio":0.25}}`),
+		},
+
+		{
+			desc: "small float assignment",
+			patchTemplate: optimizev1beta2.PatchTemplate{
+				Patch: "spec:\n  args:\n  - \"--learning-rate={{ .Values.rate }}\"\n  - \"--decay={{ .Values.decay }}\"\n",
+			},
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{
+						{
+							Name:  "rate",
+							Value: intstr.FromString("0.00001"),
+							Float: true,
+						},
+						{
+							Name:  "decay",
+							Value: intstr.FromString("2.5e-06"),
+							Float: true,
+						},
+					},
+				},
+			},
+			expected: []byte(`{"spec":{"args":["--learning-rate=0.00001","--decay=0.0000025"]}}`),
+		},
+
+		{
+			desc: "setup output",
+			patchTemplate: optimizev1beta2.PatchTemplate{
+				Patch: "spec:\n  template:\n    spec:\n      containers:\n      - name: app\n        env:\n        - name: DATABASE_URL\n          value: {{ .Outputs.url }}\n",
+			},
+			trial: optimizev1beta2.Trial{
+				Status: optimizev1beta2.TrialStatus{
+					SetupOutputs: map[string]string{"url": "postgres://db:5432"},
+				},
+			},
+			expected: []byte(`{"spec":{"template":{"spec":{"containers":[{"env":[{"name":"DATABASE_URL","value":"postgres://db:5432"}],"name":"app"}]}}}}`),
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
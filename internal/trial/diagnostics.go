@@ -0,0 +1,252 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// DiagnosticsTailLines is the number of log lines captured from each container
+	DiagnosticsTailLines = 200
+	// DiagnosticsMaxSize is the maximum number of bytes stored in a diagnostics config map
+	DiagnosticsMaxSize = 256 * 1024
+
+	// truncatedMarker is prepended to values that were trimmed to fit in the diagnostics config map
+	truncatedMarker = "[truncated]\n"
+)
+
+// DiagnosticsReader reads pod logs and events directly from the API server (these are not available from the cache)
+type DiagnosticsReader interface {
+	// TailLogs returns the last lines of the logs for a container
+	TailLogs(namespace, pod, container string, lines int64) ([]byte, error)
+	// ListEvents returns the events for the object with the specified UID
+	ListEvents(namespace string, uid types.UID) ([]corev1.Event, error)
+}
+
+// NewDiagnosticsReader returns a diagnostics reader backed by the core API client
+func NewDiagnosticsReader(c corev1client.CoreV1Interface) DiagnosticsReader {
+	return &diagnosticsClient{c: c}
+}
+
+type diagnosticsClient struct {
+	c corev1client.CoreV1Interface
+}
+
+func (d *diagnosticsClient) TailLogs(namespace, pod, container string, lines int64) ([]byte, error) {
+	return d.c.Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{Container: container, TailLines: &lines}).Do().Raw()
+}
+
+func (d *diagnosticsClient) ListEvents(namespace string, uid types.UID) ([]corev1.Event, error) {
+	el, err := d.c.Events(namespace).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.uid", string(uid)).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return el.Items, nil
+}
+
+// CollectDiagnostics returns the tail of the container logs and the events for a failed job and its pods, keyed by
+// "<pod>.<container>.log" and "<job>.events" respectively
+func CollectDiagnostics(dr DiagnosticsReader, job metav1.Object, pods []corev1.Pod) (map[string]string, error) {
+	data := make(map[string]string)
+
+	events, err := dr.ListEvents(job.GetNamespace(), job.GetUID())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+
+		pe, err := dr.ListEvents(pod.Namespace, pod.UID)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, pe...)
+
+		var statuses []corev1.ContainerStatus
+		statuses = append(statuses, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			// Containers that never started do not have any logs
+			if cs.State.Running == nil && cs.State.Terminated == nil && cs.LastTerminationState.Terminated == nil {
+				continue
+			}
+
+			logs, err := dr.TailLogs(pod.Namespace, pod.Name, cs.Name, DiagnosticsTailLines)
+			if err != nil {
+				logs = []byte(fmt.Sprintf("unable to read logs: %v\n", err))
+			}
+			data[pod.Name+"."+cs.Name+".log"] = string(logs)
+		}
+	}
+
+	if len(events) > 0 {
+		data[job.GetName()+".events"] = formatEvents(events)
+	}
+
+	return data, nil
+}
+
+// formatEvents renders events in chronological order, one per line
+func formatEvents(events []corev1.Event) string {
+	sort.SliceStable(events, func(i, j int) bool {
+		ti, tj := eventTime(&events[i]), eventTime(&events[j])
+		return ti.Before(&tj)
+	})
+
+	var sb strings.Builder
+	for i := range events {
+		e := &events[i]
+		_, _ = fmt.Fprintf(&sb, "%s\t%s\t%s\t%s/%s\t%s\n",
+			eventTime(e).UTC().Format(metav1.RFC3339Micro), e.Type, e.Reason,
+			strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, strings.TrimSpace(e.Message))
+	}
+	return sb.String()
+}
+
+// eventTime returns the most recent time recorded on an event
+func eventTime(e *corev1.Event) metav1.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp
+	case !e.EventTime.IsZero():
+		return metav1.NewTime(e.EventTime.Time)
+	default:
+		return e.FirstTimestamp
+	}
+}
+
+// ArchiveDiagnostics merges the supplied diagnostics into the config map linked from the trial status, creating
+// the config map (owned by the trial) if necessary; the total size of the config map is limited by trimming the
+// beginning of the largest values
+func ArchiveDiagnostics(ctx context.Context, c client.Client, scheme *runtime.Scheme, t *optimizev1beta2.Trial, data map[string]string) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	cm.Namespace = t.Namespace
+	cm.Name = t.Name + "-diagnostics"
+	if t.Status.Diagnostics != nil {
+		cm.Name = t.Status.Diagnostics.Name
+	}
+
+	create := false
+	if err := c.Get(ctx, types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}, cm); apierrs.IsNotFound(err) {
+		create = true
+	} else if err != nil {
+		return err
+	}
+
+	cm.Labels = map[string]string{
+		optimizev1beta2.LabelExperiment: t.ExperimentNamespacedName().Name,
+		optimizev1beta2.LabelTrial:      t.Name,
+	}
+	if err := controllerutil.SetControllerReference(t, cm, scheme); err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, len(data))
+	}
+	for k, v := range data {
+		cm.Data[k] = v
+	}
+	boundData(cm.Data, DiagnosticsMaxSize)
+
+	if create {
+		if err := c.Create(ctx, cm); err != nil {
+			return err
+		}
+	} else if err := c.Update(ctx, cm); err != nil {
+		return err
+	}
+
+	t.Status.Diagnostics = &corev1.LocalObjectReference{Name: cm.Name}
+	return nil
+}
+
+// boundData trims the beginning of the largest values so the total size of the data does not exceed the limit; the
+// available space is shared evenly by the values that need to be trimmed
+func boundData(data map[string]string, limit int) {
+	keys := make([]string, 0, len(data))
+	size := 0
+	for k, v := range data {
+		keys = append(keys, k)
+		size += len(k) + len(v)
+	}
+	if size <= limit {
+		return
+	}
+
+	// Visit the smallest values first so any space they do not use is available to the larger values
+	sort.Slice(keys, func(i, j int) bool {
+		if li, lj := len(data[keys[i]]), len(data[keys[j]]); li != lj {
+			return li < lj
+		}
+		return keys[i] < keys[j]
+	})
+
+	remaining := limit
+	for _, k := range keys {
+		remaining -= len(k)
+	}
+	for i, k := range keys {
+		share := remaining / (len(keys) - i)
+		if share < 0 {
+			share = 0
+		}
+
+		v := data[k]
+		if len(v) > share {
+			v = truncateHead(v, share)
+			data[k] = v
+		}
+		remaining -= len(v)
+	}
+}
+
+// truncateHead removes the beginning of the value (leaving a marker) so it fits in the specified number of bytes
+func truncateHead(v string, n int) string {
+	if n < len(truncatedMarker) {
+		return ""
+	}
+
+	start := len(v) - (n - len(truncatedMarker))
+	for start < len(v) && !utf8.RuneStart(v[start]) {
+		start++
+	}
+	return truncatedMarker + v[start:]
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeDiagnosticsReader struct {
+	logs   map[string]string
+	events []corev1.Event
+}
+
+func (f *fakeDiagnosticsReader) TailLogs(namespace, pod, container string, lines int64) ([]byte, error) {
+	if l, ok := f.logs[pod+"/"+container]; ok {
+		return []byte(l), nil
+	}
+	return nil, fmt.Errorf("container %q not found", container)
+}
+
+func (f *fakeDiagnosticsReader) ListEvents(namespace string, uid types.UID) ([]corev1.Event, error) {
+	var result []corev1.Event
+	for _, e := range f.events {
+		if e.InvolvedObject.UID == uid {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func TestCollectDiagnostics(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "trial-1", Namespace: "default", UID: "job-uid"}}
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "trial-1-abcde", Namespace: "default", UID: "pod-uid"},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "init", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "main", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+					{Name: "sidecar", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+				},
+			},
+		},
+	}
+	dr := &fakeDiagnosticsReader{
+		logs: map[string]string{
+			"trial-1-abcde/init": "init done\n",
+			"trial-1-abcde/main": "panic: boom\n",
+		},
+		events: []corev1.Event{
+			{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "trial-1-abcde", UID: "pod-uid"},
+				Type:           corev1.EventTypeWarning,
+				Reason:         "BackOff",
+				Message:        "Back-off pulling image",
+				LastTimestamp:  metav1.NewTime(now.Add(time.Minute)),
+			},
+			{
+				InvolvedObject: corev1.ObjectReference{Kind: "Job", Name: "trial-1", UID: "job-uid"},
+				Type:           corev1.EventTypeNormal,
+				Reason:         "SuccessfulCreate",
+				Message:        "Created pod: trial-1-abcde",
+				LastTimestamp:  metav1.NewTime(now),
+			},
+			{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "other", UID: "other-uid"},
+				Reason:         "Ignored",
+			},
+		},
+	}
+
+	data, err := CollectDiagnostics(dr, job, pods)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{
+			"trial-1-abcde.init.log": "init done\n",
+			"trial-1-abcde.main.log": "panic: boom\n",
+			"trial-1.events": "2021-03-01T12:00:00.000000Z\tNormal\tSuccessfulCreate\tjob/trial-1\tCreated pod: trial-1-abcde\n" +
+				"2021-03-01T12:01:00.000000Z\tWarning\tBackOff\tpod/trial-1-abcde\tBack-off pulling image\n",
+		}, data)
+	}
+}
+
+func TestArchiveDiagnostics(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = optimizev1beta2.AddToScheme(scheme)
+
+	tr := &optimizev1beta2.Trial{ObjectMeta: metav1.ObjectMeta{Name: "trial-1", Namespace: "default", UID: "trial-uid"}}
+	c := fake.NewFakeClientWithScheme(scheme, tr)
+	ctx := context.TODO()
+
+	big := strings.Repeat("x", DiagnosticsMaxSize)
+	if assert.NoError(t, ArchiveDiagnostics(ctx, c, scheme, tr, map[string]string{"a.log": "small\n", "b.log": big + "end\n"})) &&
+		assert.NotNil(t, tr.Status.Diagnostics) {
+		cm := &corev1.ConfigMap{}
+		if assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: tr.Status.Diagnostics.Name}, cm)) {
+			assert.Equal(t, "small\n", cm.Data["a.log"])
+			assert.True(t, strings.HasPrefix(cm.Data["b.log"], truncatedMarker))
+			assert.True(t, strings.HasSuffix(cm.Data["b.log"], "end\n"))
+			assert.Equal(t, "trial-1", cm.Labels[optimizev1beta2.LabelTrial])
+			if assert.Len(t, cm.OwnerReferences, 1) {
+				assert.Equal(t, tr.UID, cm.OwnerReferences[0].UID)
+			}
+
+			size := 0
+			for k, v := range cm.Data {
+				size += len(k) + len(v)
+			}
+			assert.LessOrEqual(t, size, DiagnosticsMaxSize)
+		}
+	}
+
+	// Additional diagnostics are merged into the existing config map
+	if assert.NoError(t, ArchiveDiagnostics(ctx, c, scheme, tr, map[string]string{"c.log": "more\n"})) {
+		cm := &corev1.ConfigMap{}
+		if assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: tr.Status.Diagnostics.Name}, cm)) {
+			assert.Len(t, cm.Data, 3)
+			assert.Equal(t, "more\n", cm.Data["c.log"])
+		}
+	}
+}
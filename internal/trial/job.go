@@ -57,20 +57,14 @@ func NewJob(t *optimizev1beta2.Trial) *batchv1.Job {
 		job.Name = t.Name
 	}
 
-	// The default restart policy for a pod is not acceptable in the context of a job
-	if job.Spec.Template.Spec.RestartPolicy == "" {
-		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
-	}
-
-	// The default backoff limit will restart the trial job which is unlikely to produce desirable results
-	if job.Spec.BackoffLimit == nil {
-		job.Spec.BackoffLimit = new(int32)
-	}
+	// Fill in the defaults required to run the job as a trial
+	ApplyJobDefaults(&job.Spec)
 
 	// Expose the current assignments as environment variables to every container (except the default sleep container added below)
 	for i := range job.Spec.Template.Spec.Containers {
 		c := &job.Spec.Template.Spec.Containers[i]
 		c.Env = setup.AppendAssignmentEnv(t, c.Env)
+		c.Env = setup.AppendOutputsEnv(t, c.Env)
 		c.Env = setup.AppendPrometheusEnv(t, c.Env)
 	}
 
@@ -85,6 +79,19 @@ func NewJob(t *optimizev1beta2.Trial) *batchv1.Job {
 	return job
 }
 
+// ApplyJobDefaults fills in job specification values that do not have acceptable Kubernetes defaults for a trial run.
+func ApplyJobDefaults(spec *batchv1.JobSpec) {
+	// The default restart policy for a pod is not acceptable in the context of a job
+	if spec.Template.Spec.RestartPolicy == "" {
+		spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+
+	// The default backoff limit will restart the trial job which is unlikely to produce desirable results
+	if spec.BackoffLimit == nil {
+		spec.BackoffLimit = new(int32)
+	}
+}
+
 func addDefaultContainer(t *optimizev1beta2.Trial, job *batchv1.Job) {
 	// Determine the sleep time
 	s := t.Spec.ApproximateRuntime
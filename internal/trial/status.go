@@ -23,6 +23,7 @@ import (
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -238,6 +239,29 @@ func ApplyCondition(status *optimizev1beta2.TrialStatus, conditionType optimizev
 	})
 }
 
+// ApplyFailure marks the trial as failed, recording the category of the failure
+func ApplyFailure(status *optimizev1beta2.TrialStatus, category optimizev1beta2.TrialFailureCategory, reason, message string, time *metav1.Time) {
+	// Only record the category of the first failure
+	if !CheckCondition(status, optimizev1beta2.TrialFailed, corev1.ConditionTrue) {
+		status.FailureCategory = category
+	}
+	ApplyCondition(status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, reason, message, time)
+}
+
+// PatchFailureCategory returns the failure category for an error applying a patch: patches rejected by the cluster
+// (e.g. invalid values or exceeding a resource quota) indicate an infeasible configuration. Other forbidden errors
+// (e.g. missing RBAC permissions) are misconfigurations that must not be reported against the assignments.
+func PatchFailureCategory(err error) optimizev1beta2.TrialFailureCategory {
+	switch {
+	case apierrs.IsInvalid(err), apierrs.IsBadRequest(err), apierrs.IsRequestEntityTooLargeError(err):
+		return optimizev1beta2.FailureInfeasible
+	case apierrs.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		return optimizev1beta2.FailureInfeasible
+	default:
+		return optimizev1beta2.FailureInfrastructure
+	}
+}
+
 // CheckCondition checks to see if a condition has a specific status
 func CheckCondition(status *optimizev1beta2.TrialStatus, conditionType optimizev1beta2.TrialConditionType, conditionStatus corev1.ConditionStatus) bool {
 	for i := range status.Conditions {
@@ -17,11 +17,14 @@ limitations under the License.
 package trial
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestUpdateStatus_Summarize(t *testing.T) {
@@ -207,3 +210,88 @@ func TestUpdateStatus_Values(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyFailure(t *testing.T) {
+	cases := []struct {
+		desc      string
+		failures  []optimizev1beta2.TrialFailureCategory
+		category  optimizev1beta2.TrialFailureCategory
+		retryable bool
+	}{
+		{
+			desc:     "Infeasible",
+			failures: []optimizev1beta2.TrialFailureCategory{optimizev1beta2.FailureInfeasible},
+			category: optimizev1beta2.FailureInfeasible,
+		},
+		{
+			desc:      "Infrastructure",
+			failures:  []optimizev1beta2.TrialFailureCategory{optimizev1beta2.FailureInfrastructure},
+			category:  optimizev1beta2.FailureInfrastructure,
+			retryable: true,
+		},
+		{
+			desc:      "Measurement",
+			failures:  []optimizev1beta2.TrialFailureCategory{optimizev1beta2.FailureMeasurement},
+			category:  optimizev1beta2.FailureMeasurement,
+			retryable: true,
+		},
+		{
+			desc:      "FirstFailure",
+			failures:  []optimizev1beta2.TrialFailureCategory{optimizev1beta2.FailureMeasurement, optimizev1beta2.FailureInfeasible},
+			category:  optimizev1beta2.FailureMeasurement,
+			retryable: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tt := &optimizev1beta2.Trial{}
+			for _, f := range c.failures {
+				ApplyFailure(&tt.Status, f, "TestFailure", "test failure message", nil)
+			}
+			assert.Equal(t, c.category, tt.Status.FailureCategory)
+			assert.Equal(t, c.retryable, IsRetryable(tt))
+			assert.True(t, IsFinished(tt))
+		})
+	}
+}
+
+func TestPatchFailureCategory(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	cases := []struct {
+		desc     string
+		err      error
+		category optimizev1beta2.TrialFailureCategory
+	}{
+		{
+			desc:     "invalid",
+			err:      apierrs.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "app", nil),
+			category: optimizev1beta2.FailureInfeasible,
+		},
+		{
+			desc:     "quota exceeded",
+			err:      apierrs.NewForbidden(deployments, "app", fmt.Errorf("exceeded quota: compute, requested: limits.cpu=4, used: limits.cpu=2, limited: limits.cpu=4")),
+			category: optimizev1beta2.FailureInfeasible,
+		},
+		{
+			desc:     "rbac forbidden",
+			err:      apierrs.NewForbidden(deployments, "app", fmt.Errorf(`User "system:serviceaccount:stormforge-system:optimize-controller-manager" cannot patch resource "deployments"`)),
+			category: optimizev1beta2.FailureInfrastructure,
+		},
+		{
+			desc:     "not found",
+			err:      apierrs.NewNotFound(deployments, "app"),
+			category: optimizev1beta2.FailureInfrastructure,
+		},
+		{
+			desc:     "timeout",
+			err:      apierrs.NewServerTimeout(deployments, "patch", 1),
+			category: optimizev1beta2.FailureInfrastructure,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.category, PatchFailureCategory(c.err))
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package trial
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -43,6 +44,74 @@ func IsAbandoned(t *optimizev1beta2.Trial) bool {
 	return !IsFinished(t) && !t.GetDeletionTimestamp().IsZero()
 }
 
+// MaxRetries is the number of times the assignments of a trial are retried before the failure is reported
+const MaxRetries = 3
+
+// IsRetryable checks to see if the specified trial failed for reasons unrelated to the trial assignments and the
+// assignments have not already been retried the maximum number of times
+func IsRetryable(t *optimizev1beta2.Trial) bool {
+	return hasRetryableFailure(t) && RetryCount(t) < MaxRetries
+}
+
+// RetryCount returns the number of times the assignments of the specified trial were previously retried
+func RetryCount(t *optimizev1beta2.Trial) int {
+	n, _ := strconv.Atoi(t.GetAnnotations()[optimizev1beta2.AnnotationRetryCount])
+	return n
+}
+
+// ApplyRetryCount records the number of times the assignments of the specified trial were previously retried using
+// the earlier trials with the same assignments that failed for reasons unrelated to the trial assignments
+func ApplyRetryCount(t *optimizev1beta2.Trial, trials []optimizev1beta2.Trial) {
+	count := 0
+	for i := range trials {
+		if hasRetryableFailure(&trials[i]) && sameAssignments(t, &trials[i]) {
+			if n := RetryCount(&trials[i]) + 1; n > count {
+				count = n
+			}
+		}
+	}
+	if count == 0 {
+		return
+	}
+
+	if t.Annotations == nil {
+		t.Annotations = make(map[string]string)
+	}
+	t.Annotations[optimizev1beta2.AnnotationRetryCount] = strconv.Itoa(count)
+}
+
+// hasRetryableFailure checks to see if the specified trial failed for reasons unrelated to the trial assignments
+func hasRetryableFailure(t *optimizev1beta2.Trial) bool {
+	if !CheckCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue) {
+		return false
+	}
+
+	switch t.Status.FailureCategory {
+	case optimizev1beta2.FailureInfrastructure, optimizev1beta2.FailureMeasurement:
+		return true
+	default:
+		return false
+	}
+}
+
+// sameAssignments checks to see if two trials have the same assignments
+func sameAssignments(t1, t2 *optimizev1beta2.Trial) bool {
+	if len(t1.Spec.Assignments) != len(t2.Spec.Assignments) {
+		return false
+	}
+
+	values := make(map[string]string, len(t1.Spec.Assignments))
+	for _, a := range t1.Spec.Assignments {
+		values[a.Name] = a.Value.String()
+	}
+	for _, a := range t2.Spec.Assignments {
+		if v, ok := values[a.Name]; !ok || v != a.Value.String() {
+			return false
+		}
+	}
+	return true
+}
+
 // IsActive checks to see if the specified trial and any setup delete tasks are NOT finished
 func IsActive(t *optimizev1beta2.Trial) bool {
 	// Not finished, definitely active
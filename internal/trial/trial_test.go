@@ -0,0 +1,110 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestApplyRetryCount(t *testing.T) {
+	assignments := []optimizev1beta2.Assignment{
+		{Name: "cpu", Value: intstr.FromInt(500)},
+		{Name: "memory", Value: intstr.FromInt(1024)},
+	}
+	otherAssignments := []optimizev1beta2.Assignment{
+		{Name: "cpu", Value: intstr.FromInt(750)},
+		{Name: "memory", Value: intstr.FromInt(1024)},
+	}
+
+	newTrial := func(assignments []optimizev1beta2.Assignment, category optimizev1beta2.TrialFailureCategory, retries int) optimizev1beta2.Trial {
+		t := optimizev1beta2.Trial{Spec: optimizev1beta2.TrialSpec{Assignments: assignments}}
+		if retries > 0 {
+			t.Annotations = map[string]string{optimizev1beta2.AnnotationRetryCount: strconv.Itoa(retries)}
+		}
+		if category != "" {
+			ApplyFailure(&t.Status, category, "TestFailure", "test failure message", nil)
+		}
+		return t
+	}
+
+	cases := []struct {
+		desc      string
+		trials    []optimizev1beta2.Trial
+		count     int
+		retryable bool
+	}{
+		{
+			desc:      "first attempt",
+			retryable: true,
+		},
+		{
+			desc: "previous infrastructure failure",
+			trials: []optimizev1beta2.Trial{
+				newTrial(assignments, optimizev1beta2.FailureInfrastructure, 0),
+			},
+			count:     1,
+			retryable: true,
+		},
+		{
+			desc: "previous retries",
+			trials: []optimizev1beta2.Trial{
+				newTrial(assignments, optimizev1beta2.FailureMeasurement, 0),
+				newTrial(assignments, optimizev1beta2.FailureInfrastructure, 1),
+			},
+			count:     2,
+			retryable: true,
+		},
+		{
+			desc: "retries exhausted",
+			trials: []optimizev1beta2.Trial{
+				newTrial(assignments, optimizev1beta2.FailureMeasurement, MaxRetries-1),
+			},
+			count: MaxRetries,
+		},
+		{
+			desc: "other assignments",
+			trials: []optimizev1beta2.Trial{
+				newTrial(otherAssignments, optimizev1beta2.FailureInfrastructure, 2),
+			},
+			retryable: true,
+		},
+		{
+			desc: "infeasible or active",
+			trials: []optimizev1beta2.Trial{
+				newTrial(assignments, optimizev1beta2.FailureInfeasible, 0),
+				newTrial(assignments, "", 0),
+			},
+			retryable: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tt := newTrial(assignments, "", 0)
+			ApplyRetryCount(&tt, c.trials)
+			assert.Equal(t, c.count, RetryCount(&tt))
+
+			// Fail the new trial to see if it would be retried again
+			ApplyFailure(&tt.Status, optimizev1beta2.FailureInfrastructure, "TestFailure", "test failure message", nil)
+			assert.Equal(t, c.retryable, IsRetryable(&tt))
+		})
+	}
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package validation
 
 import (
+	"fmt"
+	"strconv"
+
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -31,6 +34,8 @@ type AssignmentError struct {
 	OutOfBounds []string
 	// Parameter names for which multiple assignments exist
 	Duplicated []string
+	// Parameter names for which the assignment exists but the activation condition is not met
+	Inactive []string
 }
 
 // Error returns a message describing the nature of the problems with the assignments
@@ -45,9 +50,11 @@ func CheckAssignments(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment)
 
 	// Index the assignments, checking for duplicates
 	assignments := make(map[string]intstr.IntOrString, len(t.Spec.Assignments))
+	values := make(map[string]string, len(t.Spec.Assignments))
 	for _, a := range t.Spec.Assignments {
 		if _, ok := assignments[a.Name]; !ok {
 			assignments[a.Name] = a.Value
+			values[a.Name] = a.Value.String()
 		} else {
 			err.Duplicated = append(err.Duplicated, a.Name)
 		}
@@ -55,12 +62,15 @@ func CheckAssignments(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment)
 
 	// Verify against the parameter specifications
 	for _, p := range exp.Spec.Parameters {
+		active := p.IsActive(values)
 		if a, ok := assignments[p.Name]; ok {
-			if !CheckParameterValue(&p, a) {
+			if !active {
+				err.Inactive = append(err.Inactive, p.Name)
+			} else if !CheckParameterValue(&p, a) {
 				err.OutOfBounds = append(err.OutOfBounds, p.Name)
 			}
 			delete(assignments, p.Name)
-		} else {
+		} else if active {
 			err.Unassigned = append(err.Unassigned, p.Name)
 		}
 	}
@@ -69,7 +79,7 @@ func CheckAssignments(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment)
 	}
 
 	// If there were no problems found, return nil
-	if len(err.Unassigned) == 0 && len(err.Undefined) == 0 && len(err.OutOfBounds) == 0 && len(err.Duplicated) == 0 {
+	if len(err.Unassigned) == 0 && len(err.Undefined) == 0 && len(err.OutOfBounds) == 0 && len(err.Duplicated) == 0 && len(err.Inactive) == 0 {
 		return nil
 	}
 	return err
@@ -77,10 +87,51 @@ func CheckAssignments(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment)
 
 // CheckParameterValue ensures the supplied value in range for the parameter.
 func CheckParameterValue(p *optimizev1beta2.Parameter, v intstr.IntOrString) bool {
+	if p.Float != nil {
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return false
+		}
+		min, max := p.Float.Bounds()
+		return f >= min && f <= max
+	}
 	if v.Type == intstr.String {
 		return contains(p.Values, v.StrVal)
 	}
-	return v.IntVal >= p.Min && v.IntVal <= p.Max
+	if v.IntVal < p.Min || v.IntVal > p.Max {
+		return false
+	}
+	return p.Step <= 1 || v.IntVal%p.Step == 0
+}
+
+// CheckParameterCondition ensures the activation condition of the parameter refers to an unconditional categorical
+// parameter and only uses values of that parameter.
+func CheckParameterCondition(parameters []optimizev1beta2.Parameter, p *optimizev1beta2.Parameter) error {
+	if p.ActiveWhen == nil {
+		return nil
+	}
+
+	for i := range parameters {
+		cp := &parameters[i]
+		if cp.Name != p.ActiveWhen.Parameter {
+			continue
+		}
+
+		if cp.Name == p.Name || len(cp.Values) == 0 || cp.ActiveWhen != nil {
+			return fmt.Errorf("parameter '%s' must be activated by an unconditional categorical parameter", p.Name)
+		}
+		if len(p.ActiveWhen.Values) == 0 {
+			return fmt.Errorf("parameter '%s' is never active", p.Name)
+		}
+		for _, v := range p.ActiveWhen.Values {
+			if !contains(cp.Values, v) {
+				return fmt.Errorf("parameter '%s' is activated by unknown value '%s' of parameter '%s'", p.Name, v, cp.Name)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("parameter '%s' is activated by undefined parameter '%s'", p.Name, p.ActiveWhen.Parameter)
 }
 
 func contains(values []string, strVal string) bool {
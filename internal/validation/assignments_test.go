@@ -0,0 +1,131 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCheckAssignments(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "gc", Values: []string{"G1", "Parallel"}},
+				{Name: "g1_region", Min: 1, Max: 32, ActiveWhen: &optimizev1beta2.ParameterCondition{Parameter: "gc", Values: []string{"G1"}}},
+				{Name: "parallel_threads", Min: 2, Max: 16, Step: 2, ActiveWhen: &optimizev1beta2.ParameterCondition{Parameter: "gc", Values: []string{"Parallel"}}},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		assignments []optimizev1beta2.Assignment
+		expected    *AssignmentError
+	}{
+		{
+			desc: "active",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "gc", Value: intstr.FromString("G1")},
+				{Name: "g1_region", Value: intstr.FromInt(16)},
+			},
+		},
+		{
+			desc: "unassigned",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "gc", Value: intstr.FromString("Parallel")},
+			},
+			expected: &AssignmentError{Unassigned: []string{"parallel_threads"}},
+		},
+		{
+			desc: "inactive",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "gc", Value: intstr.FromString("Parallel")},
+				{Name: "g1_region", Value: intstr.FromInt(16)},
+				{Name: "parallel_threads", Value: intstr.FromInt(4)},
+			},
+			expected: &AssignmentError{Inactive: []string{"g1_region"}},
+		},
+		{
+			desc: "off step",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "gc", Value: intstr.FromString("Parallel")},
+				{Name: "parallel_threads", Value: intstr.FromInt(5)},
+			},
+			expected: &AssignmentError{OutOfBounds: []string{"parallel_threads"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckAssignments(&optimizev1beta2.Trial{Spec: optimizev1beta2.TrialSpec{Assignments: c.assignments}}, exp)
+			if c.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, c.expected, err)
+			}
+		})
+	}
+}
+
+func TestCheckParameterCondition(t *testing.T) {
+	parameters := []optimizev1beta2.Parameter{
+		{Name: "gc", Values: []string{"G1", "Parallel"}},
+		{Name: "threads", Min: 1, Max: 8},
+	}
+
+	cases := []struct {
+		desc      string
+		condition *optimizev1beta2.ParameterCondition
+		hasError  bool
+	}{
+		{
+			desc: "unconditional",
+		},
+		{
+			desc:      "valid",
+			condition: &optimizev1beta2.ParameterCondition{Parameter: "gc", Values: []string{"G1"}},
+		},
+		{
+			desc:      "undefined parameter",
+			condition: &optimizev1beta2.ParameterCondition{Parameter: "collector", Values: []string{"G1"}},
+			hasError:  true,
+		},
+		{
+			desc:      "not categorical",
+			condition: &optimizev1beta2.ParameterCondition{Parameter: "threads", Values: []string{"1"}},
+			hasError:  true,
+		},
+		{
+			desc:      "unknown value",
+			condition: &optimizev1beta2.ParameterCondition{Parameter: "gc", Values: []string{"ZGC"}},
+			hasError:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckParameterCondition(parameters, &optimizev1beta2.Parameter{Name: "p", Min: 1, Max: 2, ActiveWhen: c.condition})
+			if c.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package validation
 import (
 	"fmt"
 	"math"
+	"strings"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
@@ -125,3 +126,85 @@ func CheckConstraints(constraints []experimentsv1alpha1.Constraint, baselines []
 
 	return nil
 }
+
+// CheckConstraintScale ensures a constraint does not reference log scale parameters in a way that changes its
+// meaning. The server applies constraints to the log of a log scale parameter, which preserves the order of values
+// but not their sums or ratios.
+func CheckConstraintScale(parameters []optimizev1beta2.Parameter, c *optimizev1beta2.Constraint) error {
+	logScale := make(map[string]bool, len(parameters))
+	for i := range parameters {
+		logScale[parameters[i].Name] = parameters[i].Float != nil && parameters[i].Float.LogScale
+	}
+
+	var names []string
+	switch {
+	case c.Order != nil:
+		if logScale[c.Order.LowerParameter] != logScale[c.Order.UpperParameter] {
+			return fmt.Errorf("constraint %q cannot order a log scale parameter against a linear scale parameter", c.Name)
+		}
+	case c.Sum != nil:
+		for _, p := range c.Sum.Parameters {
+			names = append(names, p.Name)
+		}
+	case c.Linear != nil:
+		for _, t := range c.Linear.Terms {
+			names = append(names, t.Name)
+		}
+	case c.Ratio != nil:
+		names = append(names, c.Ratio.Numerator, c.Ratio.Denominator)
+	}
+
+	for _, name := range names {
+		if logScale[name] {
+			return fmt.Errorf("constraint %q cannot reference log scale parameter %q", c.Name, name)
+		}
+	}
+	return nil
+}
+
+// CheckConstraintTerms ensures a constraint can be expressed as a linear inequality. Ratio constraints are converted
+// by multiplying through by the denominator, which is only valid when the denominator is always positive; products
+// of parameters (e.g. "replicas * cpu") cannot be expressed at all.
+func CheckConstraintTerms(parameters []optimizev1beta2.Parameter, c *optimizev1beta2.Constraint) error {
+	byName := make(map[string]*optimizev1beta2.Parameter, len(parameters))
+	for i := range parameters {
+		byName[parameters[i].Name] = &parameters[i]
+	}
+
+	var names []string
+	switch {
+	case c.Sum != nil:
+		for _, p := range c.Sum.Parameters {
+			names = append(names, p.Name)
+		}
+	case c.Linear != nil:
+		for _, t := range c.Linear.Terms {
+			names = append(names, t.Name)
+		}
+	case c.Ratio != nil:
+		names = append(names, c.Ratio.Numerator, c.Ratio.Denominator)
+	}
+
+	for _, name := range names {
+		if _, ok := byName[name]; !ok && strings.ContainsAny(name, "*×") {
+			return fmt.Errorf("constraint %q cannot multiply parameters (%q), only linear combinations and ratios of parameters are supported", c.Name, name)
+		}
+	}
+
+	if c.Ratio != nil {
+		if p, ok := byName[c.Ratio.Denominator]; ok {
+			switch {
+			case len(p.Values) > 0:
+				return fmt.Errorf("constraint %q cannot use the categorical parameter %q as a denominator", c.Name, p.Name)
+			case p.Float != nil:
+				if min, _ := p.Float.Bounds(); min <= 0 {
+					return fmt.Errorf("constraint %q requires a positive denominator, the minimum of parameter %q is %s", c.Name, p.Name, p.Float.Min.String())
+				}
+			case p.Min <= 0:
+				return fmt.Errorf("constraint %q requires a positive denominator, the minimum of parameter %q is %d", c.Name, p.Name, p.Min)
+			}
+		}
+	}
+
+	return nil
+}
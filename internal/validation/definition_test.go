@@ -20,8 +20,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1/numstr"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestCheckConstraints(t *testing.T) {
@@ -216,6 +218,49 @@ func TestCheckConstraints(t *testing.T) {
 				{ParameterName: "b", Value: numstr.FromInt64(2)},
 			},
 		},
+
+		{
+			desc:      "ratio-satisfied",
+			expectErr: false,
+			constraints: []experimentsv1alpha1.Constraint{
+				{
+					Name:           "limit-at-least-125-percent-of-request",
+					ConstraintType: experimentsv1alpha1.ConstraintSum,
+					SumConstraint: &experimentsv1alpha1.SumConstraint{
+						Bound: 0,
+						Parameters: []experimentsv1alpha1.SumConstraintParameter{
+							{ParameterName: "limit", Weight: 1.0},
+							{ParameterName: "request", Weight: -1.25},
+						},
+					},
+				},
+			},
+			baselines: []experimentsv1alpha1.Assignment{
+				{ParameterName: "limit", Value: numstr.FromInt64(1250)},
+				{ParameterName: "request", Value: numstr.FromInt64(1000)},
+			},
+		},
+		{
+			desc:      "ratio-violated",
+			expectErr: true,
+			constraints: []experimentsv1alpha1.Constraint{
+				{
+					Name:           "limit-at-least-125-percent-of-request",
+					ConstraintType: experimentsv1alpha1.ConstraintSum,
+					SumConstraint: &experimentsv1alpha1.SumConstraint{
+						Bound: 0,
+						Parameters: []experimentsv1alpha1.SumConstraintParameter{
+							{ParameterName: "limit", Weight: 1.0},
+							{ParameterName: "request", Weight: -1.25},
+						},
+					},
+				},
+			},
+			baselines: []experimentsv1alpha1.Assignment{
+				{ParameterName: "limit", Value: numstr.FromInt64(1200)},
+				{ParameterName: "request", Value: numstr.FromInt64(1000)},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -228,3 +273,139 @@ func TestCheckConstraints(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckConstraintScale(t *testing.T) {
+	parameters := []optimizev1beta2.Parameter{
+		{Name: "a", Min: 1, Max: 10},
+		{Name: "b", Min: 1, Max: 10},
+		{Name: "x", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("1"), Max: resource.MustParse("100"), LogScale: true}},
+		{Name: "y", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("1"), Max: resource.MustParse("100"), LogScale: true}},
+	}
+
+	cases := []struct {
+		desc       string
+		expectErr  bool
+		constraint optimizev1beta2.Constraint
+	}{
+		{
+			desc:       "order-linear",
+			constraint: optimizev1beta2.Constraint{Order: &optimizev1beta2.OrderConstraint{LowerParameter: "a", UpperParameter: "b"}},
+		},
+		{
+			desc:       "order-log",
+			constraint: optimizev1beta2.Constraint{Order: &optimizev1beta2.OrderConstraint{LowerParameter: "x", UpperParameter: "y"}},
+		},
+		{
+			desc:       "order-mixed",
+			expectErr:  true,
+			constraint: optimizev1beta2.Constraint{Order: &optimizev1beta2.OrderConstraint{LowerParameter: "a", UpperParameter: "x"}},
+		},
+		{
+			desc: "sum-linear",
+			constraint: optimizev1beta2.Constraint{Sum: &optimizev1beta2.SumConstraint{Parameters: []optimizev1beta2.SumConstraintParameter{
+				{Name: "a", Weight: resource.MustParse("1")},
+				{Name: "b", Weight: resource.MustParse("1")},
+			}}},
+		},
+		{
+			desc:      "sum-log",
+			expectErr: true,
+			constraint: optimizev1beta2.Constraint{Sum: &optimizev1beta2.SumConstraint{Parameters: []optimizev1beta2.SumConstraintParameter{
+				{Name: "a", Weight: resource.MustParse("1")},
+				{Name: "x", Weight: resource.MustParse("1")},
+			}}},
+		},
+		{
+			desc:      "linear-log",
+			expectErr: true,
+			constraint: optimizev1beta2.Constraint{Linear: &optimizev1beta2.LinearConstraint{Terms: []optimizev1beta2.LinearConstraintTerm{
+				{Name: "x", Coefficient: resource.MustParse("1")},
+			}}},
+		},
+		{
+			desc:       "ratio-log",
+			expectErr:  true,
+			constraint: optimizev1beta2.Constraint{Ratio: &optimizev1beta2.RatioConstraint{Numerator: "a", Denominator: "y"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckConstraintScale(parameters, &c.constraint)
+			if c.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckConstraintTerms(t *testing.T) {
+	parameters := []optimizev1beta2.Parameter{
+		{Name: "replicas", Min: 1, Max: 10},
+		{Name: "cpu", Min: 100, Max: 4000},
+		{Name: "offset", Min: 0, Max: 10},
+		{Name: "gc", Values: []string{"G1", "Parallel"}},
+		{Name: "fraction", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.001"), Max: resource.MustParse("1")}},
+		{Name: "delta", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("-1"), Max: resource.MustParse("1")}},
+	}
+
+	cases := []struct {
+		desc       string
+		expectErr  bool
+		constraint optimizev1beta2.Constraint
+	}{
+		{
+			desc: "linear",
+			constraint: optimizev1beta2.Constraint{Linear: &optimizev1beta2.LinearConstraint{Terms: []optimizev1beta2.LinearConstraintTerm{
+				{Name: "replicas", Coefficient: resource.MustParse("1")},
+				{Name: "cpu", Coefficient: resource.MustParse("0.001")},
+			}}},
+		},
+		{
+			desc:      "linear-product",
+			expectErr: true,
+			constraint: optimizev1beta2.Constraint{Linear: &optimizev1beta2.LinearConstraint{Terms: []optimizev1beta2.LinearConstraintTerm{
+				{Name: "replicas * cpu", Coefficient: resource.MustParse("1")},
+			}}},
+		},
+		{
+			desc:       "ratio",
+			constraint: optimizev1beta2.Constraint{Ratio: &optimizev1beta2.RatioConstraint{Numerator: "cpu", Denominator: "replicas"}},
+		},
+		{
+			desc:       "ratio-float",
+			constraint: optimizev1beta2.Constraint{Ratio: &optimizev1beta2.RatioConstraint{Numerator: "cpu", Denominator: "fraction"}},
+		},
+		{
+			desc:       "ratio-zero",
+			expectErr:  true,
+			constraint: optimizev1beta2.Constraint{Ratio: &optimizev1beta2.RatioConstraint{Numerator: "cpu", Denominator: "offset"}},
+		},
+		{
+			desc:       "ratio-negative-float",
+			expectErr:  true,
+			constraint: optimizev1beta2.Constraint{Ratio: &optimizev1beta2.RatioConstraint{Numerator: "cpu", Denominator: "delta"}},
+		},
+		{
+			desc:       "ratio-categorical",
+			expectErr:  true,
+			constraint: optimizev1beta2.Constraint{Ratio: &optimizev1beta2.RatioConstraint{Numerator: "cpu", Denominator: "gc"}},
+		},
+		{
+			desc:       "ratio-product",
+			expectErr:  true,
+			constraint: optimizev1beta2.Constraint{Ratio: &optimizev1beta2.RatioConstraint{Numerator: "replicas×cpu", Denominator: "replicas"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckConstraintTerms(parameters, &c.constraint)
+			if c.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-optimize-stormforge-io-v1beta2-cronexperiment,mutating=false,failurePolicy=fail,groups=optimize.stormforge.io,resources=cronexperiments,verbs=create,versions=v1beta2,name=vcronexperiment.optimize.stormforge.io
+
+// CronExperimentValidator rejects cron experiments whose names are too long to generate experiment names from.
+type CronExperimentValidator struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &CronExperimentValidator{}
+var _ admission.DecoderInjector = &CronExperimentValidator{}
+
+// InjectDecoder injects the decoder.
+func (h *CronExperimentValidator) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// Handle checks the cron experiment in the request.
+func (h *CronExperimentValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	ce := &optimizev1beta2.CronExperiment{}
+	if err := h.decoder.Decode(req, ce); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Names cannot change after creation
+	if req.Operation != v1beta1.Create {
+		return admission.Allowed("")
+	}
+
+	if err := experiment.CheckName(ce); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/server"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	"k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-optimize-stormforge-io-v1beta2-experiment,mutating=true,failurePolicy=fail,groups=optimize.stormforge.io,resources=experiments,verbs=create;update,versions=v1beta2,name=mexperiment.optimize.stormforge.io
+// +kubebuilder:webhook:path=/validate-optimize-stormforge-io-v1beta2-experiment,mutating=false,failurePolicy=fail,groups=optimize.stormforge.io,resources=experiments,verbs=create;update,versions=v1beta2,name=vexperiment.optimize.stormforge.io
+
+// ExperimentDefaulter fills in default values on experiments.
+type ExperimentDefaulter struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ExperimentDefaulter{}
+var _ admission.DecoderInjector = &ExperimentDefaulter{}
+
+// InjectDecoder injects the decoder.
+func (h *ExperimentDefaulter) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// Handle returns the patches necessary to default the experiment in the request.
+func (h *ExperimentDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	exp := &optimizev1beta2.Experiment{}
+	if err := h.decoder.Decode(req, exp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	defaultExperiment(exp)
+
+	return patchResponse(req, exp)
+}
+
+// ExperimentValidator rejects invalid experiments.
+type ExperimentValidator struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ExperimentValidator{}
+var _ admission.DecoderInjector = &ExperimentValidator{}
+
+// InjectDecoder injects the decoder.
+func (h *ExperimentValidator) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// Handle checks the experiment in the request.
+func (h *ExperimentValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	exp := &optimizev1beta2.Experiment{}
+	if err := h.decoder.Decode(req, exp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Do not get in the way of finalizers being removed
+	if !exp.DeletionTimestamp.IsZero() {
+		return admission.Allowed("")
+	}
+
+	if req.Operation == v1beta1.Update {
+		old := &optimizev1beta2.Experiment{}
+		if err := h.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		// Only check changes to the specification (ignoring the defaults) so experiments stored before the webhook was
+		// enabled do not prevent the controller from updating the status, annotations or finalizers
+		defaultExperiment(old)
+		if reflect.DeepEqual(old.Spec, exp.Spec) {
+			return admission.Allowed("")
+		}
+
+		if err := validateExperimentUpdate(old, exp); err != nil {
+			return admission.Denied(err.Error())
+		}
+	}
+
+	if err := validateExperiment(ctx, exp); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
+}
+
+// defaultExperiment applies the same defaults to the experiment that the controllers use.
+func defaultExperiment(exp *optimizev1beta2.Experiment) {
+	// Make the effective replica count explicit
+	if exp.Spec.Replicas == nil {
+		exp.SetReplicas(int(exp.Replicas()))
+	}
+
+	// Trial jobs will always be created using these defaults
+	if exp.Spec.TrialTemplate.Spec.JobTemplate != nil {
+		trial.ApplyJobDefaults(&exp.Spec.TrialTemplate.Spec.JobTemplate.Spec)
+	}
+}
+
+// validateExperiment uses the linter errors which make the experiment invalid and the server synchronization to verify
+// the experiment. Everything else the linter reports is advisory and is left to `check experiment`.
+func validateExperiment(ctx context.Context, exp *optimizev1beta2.Experiment) error {
+	lint := &lintErrors{}
+	experiment.Walk(ctx, experiment.NewLinter(lint, exp), exp)
+	if len(*lint.errors()) > 0 {
+		return fmt.Errorf("invalid experiment: %s", strings.Join(*lint.errors(), "; "))
+	}
+
+	if _, _, _, err := server.FromCluster(exp); err != nil {
+		return fmt.Errorf("invalid experiment: %w", err)
+	}
+
+	return nil
+}
+
+// validateExperimentUpdate prevents changes to an experiment that would no longer match the server experiment.
+func validateExperimentUpdate(old, exp *optimizev1beta2.Experiment) error {
+	// The server experiment is not modified after it is created
+	u := old.GetAnnotations()[optimizev1beta2.AnnotationExperimentURL]
+	if u == "" || u != exp.GetAnnotations()[optimizev1beta2.AnnotationExperimentURL] {
+		return nil
+	}
+
+	_, oldServer, _, err := server.FromCluster(old)
+	if err != nil {
+		// If the old experiment could not be converted, it is unlikely to have been synchronized
+		return nil
+	}
+
+	_, newServer, _, err := server.FromCluster(exp)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(oldServer.Parameters, newServer.Parameters) {
+		return fmt.Errorf("parameters cannot be changed after the experiment is created on the server")
+	}
+	if !reflect.DeepEqual(oldServer.Metrics, newServer.Metrics) {
+		return fmt.Errorf("metrics cannot be changed after the experiment is created on the server")
+	}
+	if !reflect.DeepEqual(oldServer.Constraints, newServer.Constraints) {
+		return fmt.Errorf("constraints cannot be changed after the experiment is created on the server")
+	}
+
+	return nil
+}
+
+// lintErrors is a logger that collects the errors reported by the linter which make the experiment invalid.
+type lintErrors struct {
+	level   int
+	path    string
+	invalid bool
+	errs    *[]string
+}
+
+var _ logr.Logger = &lintErrors{}
+
+func (l *lintErrors) errors() *[]string {
+	if l.errs == nil {
+		l.errs = &[]string{}
+	}
+	return l.errs
+}
+
+func (l *lintErrors) record(msg string, err error) {
+	if !l.invalid {
+		return
+	}
+	if err != nil {
+		msg = msg + ": " + err.Error()
+	}
+	if l.path != "" {
+		msg = l.path + ": " + msg
+	}
+	*l.errors() = append(*l.errors(), msg)
+}
+
+// Info records the message only when logged at the error level.
+func (l *lintErrors) Info(msg string, _ ...interface{}) {
+	if l.level == experiment.LintError {
+		l.record(msg, nil)
+	}
+}
+
+// Enabled always returns true.
+func (l *lintErrors) Enabled() bool { return true }
+
+// Error records the message along with the error.
+func (l *lintErrors) Error(err error, msg string, _ ...interface{}) {
+	l.record(msg, err)
+}
+
+// V returns a logger for the specified level.
+func (l *lintErrors) V(level int) logr.InfoLogger {
+	return &lintErrors{level: level, path: l.path, invalid: l.invalid, errs: l.errors()}
+}
+
+// WithValues returns a logger which records the path and severity of the reported errors.
+func (l *lintErrors) WithValues(keysAndValues ...interface{}) logr.Logger {
+	ll := &lintErrors{level: l.level, path: l.path, invalid: l.invalid, errs: l.errors()}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		switch keysAndValues[i] {
+		case "path":
+			ll.path = fmt.Sprintf("%v", keysAndValues[i+1])
+		case experiment.LintSeverity:
+			ll.invalid = keysAndValues[i+1] == experiment.LintInvalid
+		}
+	}
+	return ll
+}
+
+// WithName returns the same logger.
+func (l *lintErrors) WithName(string) logr.Logger { return l }
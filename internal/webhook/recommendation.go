@@ -0,0 +1,97 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-optimize-stormforge-io-v1beta2-recommendation,mutating=true,failurePolicy=fail,groups=optimize.stormforge.io,resources=recommendations,verbs=create;update,versions=v1beta2,name=mrecommendation.optimize.stormforge.io
+
+// RecommendationApprover records the authenticated user approving a recommendation.
+type RecommendationApprover struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &RecommendationApprover{}
+var _ admission.DecoderInjector = &RecommendationApprover{}
+
+// InjectDecoder injects the decoder.
+func (h *RecommendationApprover) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// Handle returns the patches necessary to replace the self-reported approver with the requesting user.
+func (h *RecommendationApprover) Handle(ctx context.Context, req admission.Request) admission.Response {
+	rec := &optimizev1beta2.Recommendation{}
+	if err := h.decoder.Decode(req, rec); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	old := &optimizev1beta2.Recommendation{}
+	if req.Operation == v1beta1.Update {
+		if err := h.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	recordApprover(old, rec, req.UserInfo.Username)
+
+	return patchResponse(req, rec)
+}
+
+// recordApprover overwrites a newly added approval with the supplied user name.
+func recordApprover(old, rec *optimizev1beta2.Recommendation, username string) {
+	// Once the promotion starts the approver is recorded by the controller
+	if rec.Status.Promotion != nil || username == "" {
+		return
+	}
+
+	if approver, ok := rec.Annotations[optimizev1beta2.AnnotationApprovedBy]; ok {
+		if oldApprover, ok := old.Annotations[optimizev1beta2.AnnotationApprovedBy]; !ok || oldApprover != approver {
+			rec.Annotations[optimizev1beta2.AnnotationApprovedBy] = username
+		}
+	}
+
+	for i := range rec.Status.Conditions {
+		c := &rec.Status.Conditions[i]
+		if c.Type != optimizev1beta2.RecommendationApproved || c.Status != corev1.ConditionTrue {
+			continue
+		}
+		if approvedBy(old) != c.Message {
+			c.Message = username
+		}
+	}
+}
+
+// approvedBy returns the message of the approved condition, if it is "True".
+func approvedBy(rec *optimizev1beta2.Recommendation) string {
+	for i := range rec.Status.Conditions {
+		c := &rec.Status.Conditions[i]
+		if c.Type == optimizev1beta2.RecommendationApproved && c.Status == corev1.ConditionTrue {
+			return c.Message
+		}
+	}
+	return ""
+}
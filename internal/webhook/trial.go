@@ -0,0 +1,172 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	"github.com/thestormforge/optimize-controller/v2/internal/validation"
+	"k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-optimize-stormforge-io-v1beta2-trial,mutating=true,failurePolicy=fail,groups=optimize.stormforge.io,resources=trials,verbs=create;update,versions=v1beta2,name=mtrial.optimize.stormforge.io
+// +kubebuilder:webhook:path=/validate-optimize-stormforge-io-v1beta2-trial,mutating=false,failurePolicy=fail,groups=optimize.stormforge.io,resources=trials,verbs=create;update,versions=v1beta2,name=vtrial.optimize.stormforge.io
+
+// TrialDefaulter fills in default values on trials.
+type TrialDefaulter struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &TrialDefaulter{}
+var _ admission.DecoderInjector = &TrialDefaulter{}
+
+// InjectDecoder injects the decoder.
+func (h *TrialDefaulter) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// Handle returns the patches necessary to default the trial in the request.
+func (h *TrialDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	t := &optimizev1beta2.Trial{}
+	if err := h.decoder.Decode(req, t); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	defaultTrial(t)
+
+	return patchResponse(req, t)
+}
+
+// TrialValidator rejects trials whose assignments do not match the experiment.
+type TrialValidator struct {
+	Client  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &TrialValidator{}
+var _ admission.DecoderInjector = &TrialValidator{}
+
+// InjectClient injects the client used to fetch experiments.
+func (h *TrialValidator) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder.
+func (h *TrialValidator) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// Handle checks the trial in the request.
+func (h *TrialValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	t := &optimizev1beta2.Trial{}
+	if err := h.decoder.Decode(req, t); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Do not get in the way of finalizers being removed
+	if !t.DeletionTimestamp.IsZero() {
+		return admission.Allowed("")
+	}
+
+	// Assignments are only checked when they are first made
+	if req.Operation == v1beta1.Update {
+		old := &optimizev1beta2.Trial{}
+		if err := h.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if !reflect.DeepEqual(old.Spec.Assignments, t.Spec.Assignments) {
+			return admission.Denied("trial assignments cannot be changed")
+		}
+		return admission.Allowed("")
+	}
+
+	exp := &optimizev1beta2.Experiment{}
+	if err := h.Client.Get(ctx, t.ExperimentNamespacedName(), exp); err != nil {
+		if errors.IsNotFound(err) {
+			return admission.Denied(fmt.Sprintf("experiment %s does not exist", t.ExperimentNamespacedName()))
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if err := validation.CheckAssignments(t, exp); err != nil {
+		return admission.Denied(assignmentErrorMessage(err))
+	}
+
+	return admission.Allowed("")
+}
+
+// defaultTrial applies the same defaults to the trial that the controllers use.
+func defaultTrial(t *optimizev1beta2.Trial) {
+	// Record the experiment the same way trials created from the template do
+	if nn := t.ExperimentNamespacedName(); nn.Name != "" {
+		if t.Labels == nil {
+			t.Labels = map[string]string{}
+		}
+		if t.Labels[optimizev1beta2.LabelExperiment] == "" {
+			t.Labels[optimizev1beta2.LabelExperiment] = nn.Name
+		}
+		if t.Spec.ExperimentRef == nil {
+			t.Spec.ExperimentRef = &corev1.ObjectReference{Name: nn.Name, Namespace: nn.Namespace}
+		}
+	}
+
+	// The trial job will always be created using these defaults
+	if t.Spec.JobTemplate != nil {
+		trial.ApplyJobDefaults(&t.Spec.JobTemplate.Spec)
+	}
+}
+
+// assignmentErrorMessage returns a description of the problems with trial assignments.
+func assignmentErrorMessage(err error) string {
+	ae, ok := err.(*validation.AssignmentError)
+	if !ok {
+		return err.Error()
+	}
+
+	var msgs []string
+	for _, p := range []struct {
+		desc  string
+		names []string
+	}{
+		{"unassigned", ae.Unassigned},
+		{"undefined", ae.Undefined},
+		{"out of bounds", ae.OutOfBounds},
+		{"duplicated", ae.Duplicated},
+		{"inactive", ae.Inactive},
+	} {
+		if len(p.names) > 0 {
+			sort.Strings(p.names)
+			msgs = append(msgs, fmt.Sprintf("%s parameters: %s", p.desc, strings.Join(p.names, ", ")))
+		}
+	}
+	return fmt.Sprintf("%s (%s)", ae.Error(), strings.Join(msgs, "; "))
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook contains the admission webhooks used to default and validate experiments and trials, to validate
+// cron experiments and to record the approvers of recommendations.
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The paths the admission webhooks are served from, these must match the generated webhook configurations.
+const (
+	MutateExperimentPath       = "/mutate-optimize-stormforge-io-v1beta2-experiment"
+	ValidateExperimentPath     = "/validate-optimize-stormforge-io-v1beta2-experiment"
+	MutateTrialPath            = "/mutate-optimize-stormforge-io-v1beta2-trial"
+	ValidateTrialPath          = "/validate-optimize-stormforge-io-v1beta2-trial"
+	MutateRecommendationPath   = "/mutate-optimize-stormforge-io-v1beta2-recommendation"
+	ValidateCronExperimentPath = "/validate-optimize-stormforge-io-v1beta2-cronexperiment"
+)
+
+// Register adds all of the admission webhooks to the supplied server. Dependencies (like the client and decoder)
+// are injected when the server is started by the manager.
+func Register(srv *webhook.Server) {
+	srv.Register(MutateExperimentPath, &webhook.Admission{Handler: &ExperimentDefaulter{}})
+	srv.Register(ValidateExperimentPath, &webhook.Admission{Handler: &ExperimentValidator{}})
+	srv.Register(MutateTrialPath, &webhook.Admission{Handler: &TrialDefaulter{}})
+	srv.Register(ValidateTrialPath, &webhook.Admission{Handler: &TrialValidator{}})
+	srv.Register(MutateRecommendationPath, &webhook.Admission{Handler: &RecommendationApprover{}})
+	srv.Register(ValidateCronExperimentPath, &webhook.Admission{Handler: &CronExperimentValidator{}})
+}
+
+// patchResponse returns a response containing the patches necessary to produce the supplied object.
+func patchResponse(req admission.Request, obj runtime.Object) admission.Response {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, data)
+}
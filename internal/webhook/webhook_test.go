@@ -0,0 +1,445 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func TestWebhooks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, optimizev1beta2.AddToScheme(scheme))
+
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: optimizev1beta2.ExperimentSpec{
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "one", Min: 1, Max: 10},
+				{Name: "two", Values: []string{"a", "b"}},
+			},
+			Metrics: []optimizev1beta2.Metric{
+				{Name: "duration", Query: "{{ duration .StartTime .CompletionTime }}"},
+			},
+			TrialTemplate: optimizev1beta2.TrialTemplateSpec{
+				Spec: optimizev1beta2.TrialSpec{
+					JobTemplate: &batchv1beta1.JobTemplateSpec{},
+				},
+			},
+		},
+	}
+
+	synced := exp.DeepCopy()
+	synced.Annotations = map[string]string{optimizev1beta2.AnnotationExperimentURL: "http://example.com/experiments/test"}
+
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-001", Namespace: "default", Labels: map[string]string{optimizev1beta2.LabelExperiment: "test"}},
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments: []optimizev1beta2.Assignment{
+				{Name: "one", Value: intstr.FromInt(5)},
+				{Name: "two", Value: intstr.FromString("b")},
+			},
+		},
+	}
+
+	rec := &optimizev1beta2.Recommendation{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+	}
+
+	// Start a webhook server using generated certificates
+	dir := t.TempDir()
+	caBundle, err := GenerateCertificates(dir, "127.0.0.1")
+	require.NoError(t, err)
+
+	srv := &webhook.Server{Host: "127.0.0.1", Port: freePort(t), CertDir: dir}
+	Register(srv)
+
+	c := fake.NewFakeClientWithScheme(scheme, exp.DeepCopy())
+	var setFields inject.Func
+	setFields = func(i interface{}) error {
+		if _, err := inject.SchemeInto(scheme, i); err != nil {
+			return err
+		}
+		if _, err := inject.ClientInto(c, i); err != nil {
+			return err
+		}
+		_, err := inject.InjectorInto(setFields, i)
+		return err
+	}
+	require.NoError(t, srv.InjectFunc(setFields))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() { _ = srv.Start(stop) }()
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caBundle))
+	hc := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	baseURL := "https://" + net.JoinHostPort(srv.Host, strconv.Itoa(srv.Port))
+
+	cases := []struct {
+		desc      string
+		path      string
+		operation admissionv1beta1.Operation
+		obj       runtime.Object
+		oldObj    runtime.Object
+		allowed   bool
+		message   string
+		patches   []string
+	}{
+		{
+			desc:      "experiment defaults",
+			path:      MutateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj:       exp,
+			allowed:   true,
+			patches:   []string{"/spec/replicas", "/spec/trialTemplate/spec/jobTemplate/spec/backoffLimit", "/spec/trialTemplate/spec/jobTemplate/spec/template/spec/restartPolicy"},
+		},
+		{
+			desc:      "experiment valid",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj:       exp,
+			allowed:   true,
+		},
+		{
+			desc:      "experiment no parameters",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Parameters = nil
+			}),
+			message: "invalid experiment: /spec/parameters: Parameters are required",
+		},
+		{
+			desc:      "experiment metric range",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				min, max := resource.MustParse("10"), resource.MustParse("1")
+				e.Spec.Metrics[0].Min, e.Spec.Metrics[0].Max = &min, &max
+			}),
+			message: "invalid experiment: /spec/metrics/[name=duration]: Metric minimum must be strictly less then maximum",
+		},
+		{
+			desc:      "experiment invalid condition",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Parameters[0].ActiveWhen = &optimizev1beta2.ParameterCondition{Parameter: "two", Values: []string{"c"}}
+			}),
+			message: "invalid experiment: /spec/parameters: Parameter activation condition is invalid",
+		},
+		{
+			desc:      "experiment log scale constraint",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Parameters = append(e.Spec.Parameters, optimizev1beta2.Parameter{Name: "rate", Float: &optimizev1beta2.FloatParameter{Min: resource.MustParse("0.1"), Max: resource.MustParse("10"), LogScale: true}})
+				e.Spec.Constraints = []optimizev1beta2.Constraint{{Name: "total", Ratio: &optimizev1beta2.RatioConstraint{Numerator: "rate", Denominator: "one"}}}
+			}),
+			message: "invalid experiment: /spec: Constraint cannot use the log scale of a parameter",
+		},
+		{
+			desc:      "experiment ratio constraint categorical denominator",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Constraints = []optimizev1beta2.Constraint{{Name: "ratio", Ratio: &optimizev1beta2.RatioConstraint{Numerator: "one", Denominator: "two"}}}
+			}),
+			message: "invalid experiment: /spec: Constraint must be a linear combination or ratio of parameters",
+		},
+		{
+			desc:      "experiment warm start other namespace",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.WarmStart = &optimizev1beta2.WarmStart{ExperimentRef: &corev1.ObjectReference{Name: "previous", Namespace: "other"}}
+			}),
+			message: "invalid experiment: Warm start experiment must be in the same namespace",
+		},
+		{
+			desc:      "experiment omitted parameter",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Parameters = append(e.Spec.Parameters, optimizev1beta2.Parameter{Name: "three", Min: 5, Max: 5})
+			}),
+			allowed: true,
+		},
+		{
+			desc:      "experiment patch using values",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Patches = []optimizev1beta2.PatchTemplate{{Patch: `{"metadata":{"labels":{"two":"{{ .Values.two | upper }}"}}}`}}
+			}),
+			allowed: true,
+		},
+		{
+			desc:      "experiment synced parameter change",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    synced,
+			obj: modifyExperiment(synced, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Parameters[0].Max = 20
+			}),
+			message: "parameters cannot be changed after the experiment is created on the server",
+		},
+		{
+			desc:      "experiment synced metric change",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    synced,
+			obj: modifyExperiment(synced, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Metrics[0].Minimize = true
+			}),
+			message: "metrics cannot be changed after the experiment is created on the server",
+		},
+		{
+			desc:      "experiment synced other change",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    synced,
+			obj: modifyExperiment(synced, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Metrics[0].Query = "{{ percent 1 2 }}"
+				e.Spec.Replicas = new(int32)
+			}),
+			allowed: true,
+		},
+		{
+			desc:      "experiment unsynced parameter change",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    exp,
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Parameters[0].Max = 20
+			}),
+			allowed: true,
+		},
+		{
+			desc:      "experiment stored invalid status update",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    modifyExperiment(exp, func(e *optimizev1beta2.Experiment) { e.Spec.Metrics = nil }),
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Metrics = nil
+				e.Finalizers = []string{"example.com/finalizer"}
+				e.Status.Phase = "Running"
+				defaultExperiment(e)
+			}),
+			allowed: true,
+		},
+		{
+			desc:      "experiment stored invalid spec update",
+			path:      ValidateExperimentPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    modifyExperiment(exp, func(e *optimizev1beta2.Experiment) { e.Spec.Metrics = nil }),
+			obj: modifyExperiment(exp, func(e *optimizev1beta2.Experiment) {
+				e.Spec.Metrics = nil
+				e.Spec.Parameters[0].Max = 20
+			}),
+			message: "invalid experiment: /spec/metrics: Metrics are required",
+		},
+		{
+			desc:      "trial defaults",
+			path:      MutateTrialPath,
+			operation: admissionv1beta1.Create,
+			obj:       trial,
+			allowed:   true,
+			patches:   []string{"/spec/experimentRef"},
+		},
+		{
+			desc:      "trial valid",
+			path:      ValidateTrialPath,
+			operation: admissionv1beta1.Create,
+			obj:       trial,
+			allowed:   true,
+		},
+		{
+			desc:      "trial out of bounds",
+			path:      ValidateTrialPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyTrial(trial, func(t *optimizev1beta2.Trial) {
+				t.Spec.Assignments[0].Value = intstr.FromInt(50)
+			}),
+			message: "invalid assignments (out of bounds parameters: one)",
+		},
+		{
+			desc:      "trial missing experiment",
+			path:      ValidateTrialPath,
+			operation: admissionv1beta1.Create,
+			obj: modifyTrial(trial, func(t *optimizev1beta2.Trial) {
+				t.Labels[optimizev1beta2.LabelExperiment] = "missing"
+			}),
+			message: "experiment default/missing does not exist",
+		},
+		{
+			desc:      "trial assignment change",
+			path:      ValidateTrialPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    trial,
+			obj: modifyTrial(trial, func(t *optimizev1beta2.Trial) {
+				t.Spec.Assignments[0].Value = intstr.FromInt(6)
+			}),
+			message: "trial assignments cannot be changed",
+		},
+		{
+			desc:      "recommendation approved",
+			path:      MutateRecommendationPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    rec,
+			obj: modifyRecommendation(rec, func(r *optimizev1beta2.Recommendation) {
+				r.Annotations = map[string]string{optimizev1beta2.AnnotationApprovedBy: "someone else"}
+			}),
+			allowed: true,
+			patches: []string{"/metadata/annotations/stormforge.io~1approved-by"},
+		},
+		{
+			desc:      "recommendation approved condition",
+			path:      MutateRecommendationPath,
+			operation: admissionv1beta1.Update,
+			oldObj:    rec,
+			obj: modifyRecommendation(rec, func(r *optimizev1beta2.Recommendation) {
+				r.Status.Conditions = []optimizev1beta2.RecommendationCondition{
+					{Type: optimizev1beta2.RecommendationApproved, Status: corev1.ConditionTrue, Message: "someone else"},
+				}
+			}),
+			allowed: true,
+			patches: []string{"/status/conditions/0/message"},
+		},
+		{
+			desc:      "cron experiment valid",
+			path:      ValidateCronExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj:       &optimizev1beta2.CronExperiment{ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"}},
+			allowed:   true,
+		},
+		{
+			desc:      "cron experiment name too long",
+			path:      ValidateCronExperimentPath,
+			operation: admissionv1beta1.Create,
+			obj:       &optimizev1beta2.CronExperiment{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 53), Namespace: "default"}},
+			message:   "cron experiment name must be no more than 52 characters",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			resp := review(t, hc, baseURL+c.path, c.operation, c.obj, c.oldObj)
+			if !assert.Equal(t, c.allowed, resp.Allowed) {
+				return
+			}
+			if c.message != "" && assert.NotNil(t, resp.Result) {
+				assert.Contains(t, string(resp.Result.Reason), c.message)
+			}
+			for _, p := range c.patches {
+				assert.Contains(t, string(resp.Patch), `"path":"`+p+`"`)
+			}
+		})
+	}
+}
+
+// review sends an admission review request to the webhook server.
+func review(t *testing.T, hc *http.Client, u string, op admissionv1beta1.Operation, obj, oldObj runtime.Object) *admissionv1beta1.AdmissionResponse {
+	ar := &admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1beta1", Kind: "AdmissionReview"},
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       types.UID("test"),
+			Operation: op,
+			UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+		},
+	}
+
+	var err error
+	ar.Request.Object.Raw, err = json.Marshal(obj)
+	require.NoError(t, err)
+	if oldObj != nil {
+		ar.Request.OldObject.Raw, err = json.Marshal(oldObj)
+		require.NoError(t, err)
+	}
+
+	body, err := json.Marshal(ar)
+	require.NoError(t, err)
+
+	// Retry while the server is starting
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		resp, err = hc.Post(u, "application/json", bytes.NewReader(body))
+		if err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	result := &admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(result))
+	require.NotNil(t, result.Response)
+	return result.Response
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func modifyExperiment(exp *optimizev1beta2.Experiment, f func(*optimizev1beta2.Experiment)) *optimizev1beta2.Experiment {
+	exp = exp.DeepCopy()
+	f(exp)
+	return exp
+}
+
+func modifyTrial(t *optimizev1beta2.Trial, f func(*optimizev1beta2.Trial)) *optimizev1beta2.Trial {
+	t = t.DeepCopy()
+	f(t)
+	return t
+}
+
+func modifyRecommendation(r *optimizev1beta2.Recommendation, f func(*optimizev1beta2.Recommendation)) *optimizev1beta2.Recommendation {
+	r = r.DeepCopy()
+	f(r)
+	return r
+}
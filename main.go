@@ -25,6 +25,7 @@ import (
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/controllers"
 	"github.com/thestormforge/optimize-controller/v2/internal/version"
+	"github.com/thestormforge/optimize-controller/v2/internal/webhook"
 	"github.com/thestormforge/optimize-go/pkg/config"
 	zap2 "go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -52,9 +53,15 @@ func main() {
 
 	var metricsAddr string
 	var enableLeaderElection bool
+	var enableWebhooks bool
+	var webhookPort int
+	var webhookCertDir string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the experiment and trial admission webhooks.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "", "The directory containing the webhook serving certificate (tls.crt) and key (tls.key).")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
@@ -72,6 +79,8 @@ func main() {
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
+		Port:               webhookPort,
+		CertDir:            webhookCertDir,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -133,8 +142,36 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Metric")
 		os.Exit(1)
 	}
+	if err = (&controllers.RecommendationReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("Recommendation"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Recommendation")
+		os.Exit(1)
+	}
+	if err = (&controllers.PromotionReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("Promotion"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Promotion")
+		os.Exit(1)
+	}
+	if err = (&controllers.CronExperimentReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("CronExperiment"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CronExperiment")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
+	if enableWebhooks {
+		webhook.Register(mgr.GetWebhookServer())
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")